@@ -10,14 +10,18 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	awsscheduler "github.com/aws/aws-sdk-go-v2/service/scheduler"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	"github.com/dat1010/go-api/config"
 	"github.com/dat1010/go-api/controllers"
 	_ "github.com/dat1010/go-api/docs"
-	"github.com/dat1010/go-api/middleware"
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/notify"
 	"github.com/dat1010/go-api/repositories"
 	"github.com/dat1010/go-api/routes"
+	"github.com/dat1010/go-api/scheduler"
 	"github.com/dat1010/go-api/services"
-	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
@@ -42,12 +46,32 @@ import (
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token.
 func main() {
-	if err := config.RunMigrations(); err != nil {
+	// Read the configuration file and environment, reporting every problem
+	cfg, err := config.Load(os.Getenv)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Load the AWS configuration once and share its clients
+	awsCtx, cancelAWS := context.WithTimeout(context.Background(), 10*time.Second)
+	awsConfig, err := config.LoadAWS(awsCtx, cfg.AWS)
+	cancelAWS()
+	if err != nil {
+		log.Fatalf("Failed to load AWS config: %v", err)
+	}
+
+	// Secrets come from Secrets Manager, mounted files or the environment
+	secrets, err := config.NewSecretProvider(cfg.Secrets, os.Getenv, secretsmanager.NewFromConfig(awsConfig))
+	if err != nil {
+		log.Fatalf("Failed to set up secrets: %v", err)
+	}
+
+	if err := config.RunMigrations(cfg.Database, secrets); err != nil {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
 
 	// Initialize database connection
-	db, err := config.NewDB()
+	db, err := config.NewDB(cfg.Database, secrets)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -58,65 +82,134 @@ func main() {
 	postService := services.NewPostService(postRepo)
 	userRepo := repositories.NewUserRepository(db)
 	userService := services.NewUserService(userRepo)
+	auditRepo := repositories.NewAuditRepository(db)
+	auditService := services.NewAuditService(auditRepo)
+	invitationRepo := repositories.NewInvitationRepository(db)
+	invitationService := services.NewInvitationService(
+		invitationRepo,
+		userService,
+		[]byte(cfg.Invitations.SigningSecret),
+		cfg.Invitations.AcceptURL,
+		services.DefaultInvitationTTL,
+	)
 
 	// Set the post service for controllers
 	controllers.SetPostService(postService)
 	controllers.SetUserService(userService)
+	controllers.SetRoleService(services.NewRoleService(repositories.NewRoleRepository(db)))
+	controllers.SetAuditService(auditService)
+	controllers.SetInvitationService(invitationService)
+	// Initialize the event scheduler backend
+	eventScheduler, err := scheduler.New(scheduler.Config{
+		Backend:   cfg.Scheduler.Backend,
+		TargetArn: cfg.Scheduler.TargetArn,
+		RoleArn:   cfg.Scheduler.RoleArn,
+		GroupName: cfg.Scheduler.GroupName,
+		TargetURL: cfg.Scheduler.TargetURL,
+	}, scheduler.Clients{
+		EventBridge: eventbridge.NewFromConfig(awsConfig),
+		Scheduler:   awsscheduler.NewFromConfig(awsConfig),
+	}, db)
+	if err != nil {
+		log.Fatalf("Failed to initialize scheduler: %v", err)
+	}
+	eventTargetService := services.NewEventTargetService(repositories.NewEventTargetRepository(db))
+	controllers.SetEventTargetService(eventTargetService)
+	// Side effects run as background jobs fed by the outbox
+	jobService := services.NewJobService(repositories.NewJobRepository(db))
+	controllers.SetJobService(jobService)
 
-	router := gin.Default()
+	// Notify users over the channels they choose
+	notifiers := notificationChannels(cfg.Notifications.SMTP)
+	notificationService := services.NewNotificationService(repositories.NewNotificationRepository(db), notifiers, jobService.Enqueue)
+	controllers.SetNotificationService(notificationService)
+	jobService.Handle(models.JobKindNotifyPostCreated, notificationService.HandlePostCreated)
+	jobService.Handle(models.JobKindNotifyEventFailed, notificationService.HandleEventFailed)
+	jobService.Handle(models.JobKindNotifyDeliver, notificationService.HandleDeliver)
 
-	// Add CORS middleware
-	router.Use(middleware.CORS())
+	// Post changes reach every instance's stream through Postgres notifications
+	postStream := services.NewPostStream(repositories.NewPostEventListener(db), postRepo.GetByID, services.DefaultPostStreamBuffer)
+	controllers.SetPostStream(postStream)
 
-	// Trust all proxies (for older Gin versions)
-	if err := router.SetTrustedProxies(nil); err != nil {
-		log.Printf("Warning: failed to set trusted proxies: %v", err)
-	}
-	router.ForwardedByClientIP = true
-	router.AppEngine = false
+	webhookService := services.NewWebhookService(repositories.NewWebhookRepository(db), nil)
+	controllers.SetWebhookService(webhookService)
+	jobService.Handle(models.JobKindWebhookEvent, webhookService.HandleEvent)
+	jobService.Handle(models.JobKindWebhookDeliver, webhookService.HandleDeliver)
+	controllers.SetDiscordWebhook(notifiers[notify.ChannelDiscord], cfg.Notifications.DiscordWebhookURL)
 
-	// Add middleware to force HTTPS in URLs when behind load balancer
-	router.Use(func(c *gin.Context) {
-		if c.GetHeader("X-Forwarded-Proto") == "https" {
-			c.Request.URL.Scheme = "https"
-		}
-		c.Next()
-	})
+	eventRepo := repositories.NewEventRepository(db)
+	controllers.SetEventRunService(services.NewEventRunService(
+		repositories.NewEventRunRepository(db),
+		eventRepo,
+		[]byte(cfg.Events.CallbackSecret),
+	))
+	eventService := services.NewEventService(
+		eventRepo,
+		eventScheduler,
+		userService.GetEventQuota,
+		eventTargetService.ResolveTargets,
+		eventTargetService.ValidatePayload,
+	)
+	controllers.SetEventService(eventService)
+	jobService.Handle(models.JobKindEventScheduleSync, eventService.HandleScheduleSync)
+	eventReconciler := services.NewEventReconciler(eventRepo, eventScheduler, eventTargetService.ResolveTargets, jobService.Enqueue)
+	controllers.SetEventReconciler(eventReconciler)
 
-	// Make db available to all routes
-	router.Use(func(c *gin.Context) {
-		c.Set("db", db)
-		c.Next()
-	})
+	accountService := services.NewAccountService(
+		repositories.NewAccountRepository(db),
+		userService,
+		eventScheduler.DeleteByOwner,
+		cfg.Accounts.DeletionGracePeriod(),
+		cfg.Accounts.DeletionPosts,
+	)
+	controllers.SetAccountService(accountService)
+	controllers.SetImpersonationService(services.NewImpersonationService(
+		repositories.NewImpersonationRepository(db),
+		userService,
+	))
 
-	// Register routes
-	api := router.Group("/api")
-	routes.RegisterRoutes(api)
-	routes.RegisterPostRoutes(api)
+	router := routes.NewRouter(cfg, db)
 
 	// serve Swagger UI with custom configuration
 	swaggerHandler := ginSwagger.DisablingWrapHandler(swaggerFiles.Handler, "DISABLE_SWAGGER_HTTP_HANDLER")
 	router.GET("/swagger/*any", swaggerHandler)
 
-	// Get the HTTP bind address
-	bindAddr := os.Getenv("BIND_ADDR")
-	if bindAddr == "" {
-		bindAddr = "0.0.0.0:8080"
-	}
-
 	// Create a context for graceful shutdown
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	// Erase accounts whose deletion grace period has ended
+	go accountService.RunDeletionWorker(ctx, time.Hour)
+
+	// Relay post changes to streaming clients; ends their streams on shutdown
+	go postStream.Run(ctx)
+
+	// Report drift between stored events and the scheduler backend, repairing missing and mismatched rules
+	go eventReconciler.RunReconciler(ctx, cfg.Events.ReconcileInterval())
+
+	// Move outbox messages into jobs and run them. Workers finish their
+	// current job after a shutdown signal, and are waited for below.
+	go jobService.RunOutboxRelay(ctx, time.Second)
+	workersDone := make(chan struct{})
+	go func() {
+		jobService.RunWorkers(ctx, cfg.Jobs.Workers, time.Second)
+		close(workersDone)
+	}()
+
+	// The local scheduler fires events from this process
+	if localScheduler, ok := eventScheduler.(*scheduler.LocalScheduler); ok {
+		go localScheduler.Run(ctx, 15*time.Second)
+	}
+
 	// Create an HTTP server
 	httpServer := &http.Server{
-		Addr:    bindAddr,
+		Addr:    cfg.Server.BindAddr,
 		Handler: router,
 	}
 
 	// Start the server in a goroutine
 	go func() {
-		log.Printf("Server starting on %s", bindAddr)
+		log.Printf("Server starting on %s", cfg.Server.BindAddr)
 		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Failed to start server: %v", err)
 		}
@@ -134,5 +227,39 @@ func main() {
 		log.Printf("Server forced to shutdown: %v", err)
 	}
 
+	// Let jobs in progress finish; any cut off are retried after their lease
+	select {
+	case <-workersDone:
+	case <-time.After(30 * time.Second):
+		log.Println("Job workers did not stop in time")
+	}
+
 	log.Println("Server exiting")
 }
+
+// notificationChannels sets up a notifier for every channel. Email is only
+// offered when smtp names a relay.
+func notificationChannels(smtp config.SMTPConfig) map[string]notify.Notifier {
+	// Users choose HTTP destinations, so none may reach internal addresses
+	client := notify.NewGuardedClient(notify.DefaultTimeout)
+	notifiers := map[string]notify.Notifier{
+		notify.ChannelDiscord: notify.NewDiscordNotifier(client),
+		notify.ChannelSlack:   notify.NewSlackNotifier(client),
+		notify.ChannelHTTP:    notify.NewHTTPNotifier(client),
+	}
+	if smtp.Host == "" {
+		return notifiers
+	}
+	email, err := notify.NewSMTPNotifier(notify.SMTPConfig{
+		Host:     smtp.Host,
+		Port:     smtp.Port,
+		Username: smtp.Username,
+		Password: smtp.Password,
+		From:     smtp.From,
+	})
+	if err != nil {
+		log.Fatalf("Failed to configure email notifications: %v", err)
+	}
+	notifiers[notify.ChannelEmail] = email
+	return notifiers
+}
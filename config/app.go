@@ -0,0 +1,463 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileEnv names the environment variable pointing at an optional YAML
+// (.yaml, .yml) or TOML (.toml) configuration file.
+const ConfigFileEnv = "CONFIG_FILE"
+
+// Scheduler backends, matching the scheduler package's Backend constants.
+const (
+	schedulerBackendEventBridge          = "eventbridge"
+	schedulerBackendEventBridgeScheduler = "eventbridge-scheduler"
+	schedulerBackendLocal                = "local"
+)
+
+// AppConfig is the whole configuration of the API. Load builds it from
+// Defaults, then the configuration file, then environment variables, each
+// overriding the one before.
+type AppConfig struct {
+	// Environment is "development" to also allow the local frontend origin.
+	Environment string `yaml:"environment" toml:"environment"`
+	// Version is reported by the health check.
+	Version string `yaml:"version" toml:"version"`
+	// Secret is served by the public /secrets endpoint.
+	Secret string `yaml:"secret" toml:"secret"`
+
+	Server        ServerConfig        `yaml:"server" toml:"server"`
+	Auth0         Auth0Config         `yaml:"auth0" toml:"auth0"`
+	AWS           AWSOptions          `yaml:"aws" toml:"aws"`
+	Database      DatabaseConfig      `yaml:"database" toml:"database"`
+	Secrets       SecretsConfig       `yaml:"secrets" toml:"secrets"`
+	Scheduler     SchedulerConfig     `yaml:"scheduler" toml:"scheduler"`
+	Jobs          JobsConfig          `yaml:"jobs" toml:"jobs"`
+	Events        EventsConfig        `yaml:"events" toml:"events"`
+	Accounts      AccountsConfig      `yaml:"accounts" toml:"accounts"`
+	Invitations   InvitationsConfig   `yaml:"invitations" toml:"invitations"`
+	Notifications NotificationsConfig `yaml:"notifications" toml:"notifications"`
+}
+
+type ServerConfig struct {
+	BindAddr string `yaml:"bind_addr" toml:"bind_addr"`
+}
+
+// Auth0Config holds the Auth0 tenant the API trusts and the login flow's
+// client settings.
+type Auth0Config struct {
+	Domain       string `yaml:"domain" toml:"domain"`
+	Audience     string `yaml:"audience" toml:"audience"`
+	ClientID     string `yaml:"client_id" toml:"client_id"`
+	ClientSecret string `yaml:"client_secret" toml:"client_secret"`
+	CallbackURL  string `yaml:"callback_url" toml:"callback_url"`
+	// LogoutReturnURL is where Auth0 sends users after logging out.
+	LogoutReturnURL string `yaml:"logout_return_url" toml:"logout_return_url"`
+	// Scheme reaches the token endpoint; "http" is only for tests and mocks.
+	Scheme string       `yaml:"scheme" toml:"scheme"`
+	Cookie CookieConfig `yaml:"cookie" toml:"cookie"`
+}
+
+// CookieConfig sets the attributes of the token cookies.
+type CookieConfig struct {
+	Domain   string `yaml:"domain" toml:"domain"`
+	Secure   bool   `yaml:"secure" toml:"secure"`
+	SameSite string `yaml:"same_site" toml:"same_site"`
+}
+
+// DatabaseConfig says how to reach Postgres: by URL, by a secret holding the
+// RDS-style JSON credentials, or by host, user and database name. Secrets are
+// read through the provider chosen in SecretsConfig.
+type DatabaseConfig struct {
+	URL               string `yaml:"url" toml:"url"`
+	CredentialsSecret string `yaml:"credentials_secret" toml:"credentials_secret"`
+
+	Host     string `yaml:"host" toml:"host"`
+	Port     int    `yaml:"port" toml:"port"`
+	User     string `yaml:"user" toml:"user"`
+	Password string `yaml:"password" toml:"password"`
+	// PasswordSecret names a secret holding only the password, instead of Password.
+	PasswordSecret string `yaml:"password_secret" toml:"password_secret"`
+	Name           string `yaml:"name" toml:"name"`
+
+	// SSLMode overrides any sslmode in URL; require is used when neither sets one.
+	SSLMode string `yaml:"ssl_mode" toml:"ssl_mode"`
+	// SSLRootCert is a PEM file of CAs trusted to sign the server certificate.
+	SSLRootCert string `yaml:"ssl_root_cert" toml:"ssl_root_cert"`
+
+	// MigrationsPath defaults to the migrations directory beside the binary.
+	MigrationsPath     string `yaml:"migrations_path" toml:"migrations_path"`
+	MigrationTimeoutMS int    `yaml:"migration_timeout_ms" toml:"migration_timeout_ms"`
+}
+
+// MigrationTimeout bounds the whole migration run and each statement in it.
+func (c DatabaseConfig) MigrationTimeout() time.Duration {
+	return time.Duration(c.MigrationTimeoutMS) * time.Millisecond
+}
+
+// SecretsConfig selects where named secrets are read from.
+type SecretsConfig struct {
+	// Provider is SecretProviderAWS, SecretProviderFile or SecretProviderEnv.
+	Provider string `yaml:"provider" toml:"provider"`
+	// Dir holds the file provider's secrets.
+	Dir string `yaml:"dir" toml:"dir"`
+}
+
+// SchedulerConfig selects the event scheduler backend; see scheduler.Config.
+type SchedulerConfig struct {
+	Backend   string `yaml:"backend" toml:"backend"`
+	TargetArn string `yaml:"target_arn" toml:"target_arn"`
+	RoleArn   string `yaml:"role_arn" toml:"role_arn"`
+	GroupName string `yaml:"group_name" toml:"group_name"`
+	TargetURL string `yaml:"target_url" toml:"target_url"`
+}
+
+type JobsConfig struct {
+	Workers int `yaml:"workers" toml:"workers"`
+}
+
+type EventsConfig struct {
+	// CallbackSecret signs run reports; run callbacks are refused without it.
+	CallbackSecret   string `yaml:"callback_secret" toml:"callback_secret"`
+	ReconcileMinutes int    `yaml:"reconcile_minutes" toml:"reconcile_minutes"`
+}
+
+// ReconcileInterval is how often drift with the scheduler backend is checked.
+func (c EventsConfig) ReconcileInterval() time.Duration {
+	return time.Duration(c.ReconcileMinutes) * time.Minute
+}
+
+type AccountsConfig struct {
+	DeletionGraceDays int `yaml:"deletion_grace_days" toml:"deletion_grace_days"`
+	// DeletionPosts is models.DeletedPostsAnonymize or models.DeletedPostsDelete.
+	DeletionPosts string `yaml:"deletion_posts" toml:"deletion_posts"`
+}
+
+// DeletionGracePeriod is how long a deletion request can be cancelled.
+func (c AccountsConfig) DeletionGracePeriod() time.Duration {
+	return time.Duration(c.DeletionGraceDays) * 24 * time.Hour
+}
+
+type InvitationsConfig struct {
+	SigningSecret string `yaml:"signing_secret" toml:"signing_secret"`
+	AcceptURL     string `yaml:"accept_url" toml:"accept_url"`
+}
+
+type NotificationsConfig struct {
+	// DiscordWebhookURL receives the /discord-ping message.
+	DiscordWebhookURL string     `yaml:"discord_webhook_url" toml:"discord_webhook_url"`
+	SMTP              SMTPConfig `yaml:"smtp" toml:"smtp"`
+}
+
+// SMTPConfig enables email notifications when Host is set.
+type SMTPConfig struct {
+	Host     string `yaml:"host" toml:"host"`
+	Port     string `yaml:"port" toml:"port"`
+	Username string `yaml:"username" toml:"username"`
+	Password string `yaml:"password" toml:"password"`
+	From     string `yaml:"from" toml:"from"`
+}
+
+// Defaults returns the configuration used for every setting left unset.
+func Defaults() *AppConfig {
+	return &AppConfig{
+		Environment: "production",
+		Server:      ServerConfig{BindAddr: "0.0.0.0:8080"},
+		Auth0: Auth0Config{
+			Scheme: "https",
+			Cookie: CookieConfig{Secure: true, SameSite: "lax"},
+		},
+		Database:  DatabaseConfig{Port: 5432, MigrationTimeoutMS: 30000},
+		Secrets:   SecretsConfig{Provider: SecretProviderAWS, Dir: DefaultSecretsDir},
+		Scheduler: SchedulerConfig{Backend: schedulerBackendEventBridge},
+		Jobs:      JobsConfig{Workers: 4},
+		Events:    EventsConfig{ReconcileMinutes: 60},
+		Accounts: AccountsConfig{
+			DeletionGraceDays: 30,
+			DeletionPosts:     models.DeletedPostsAnonymize,
+		},
+	}
+}
+
+// Load reads the configuration named by CONFIG_FILE, if any, applies the
+// environment variables found through getenv and validates the result.
+// Every problem found is reported in the returned error.
+func Load(getenv func(string) string) (*AppConfig, error) {
+	cfg := Defaults()
+	if path := getenv(ConfigFileEnv); path != "" {
+		if err := cfg.readFile(path); err != nil {
+			return nil, err
+		}
+	}
+	envErr := cfg.applyEnv(getenv)
+	if err := errors.Join(envErr, cfg.Validate()); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return cfg, nil
+}
+
+// readFile decodes a YAML or TOML file over cfg, rejecting unknown keys so
+// misspelt settings are not silently ignored.
+func (c *AppConfig) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	case ".toml":
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(c); err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("config file %s must be .yaml, .yml or .toml, not %q", path, ext)
+	}
+	return nil
+}
+
+// applyEnv overrides settings with the environment variables that are set
+// and not empty.
+func (c *AppConfig) applyEnv(getenv func(string) string) error {
+	var errs []error
+	str := func(dst *string, key string) {
+		if value := getenv(key); value != "" {
+			*dst = value
+		}
+	}
+	number := func(dst *int, key string) {
+		if value := getenv(key); value != "" {
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s must be a whole number, got %q", key, value))
+				return
+			}
+			*dst = n
+		}
+	}
+	boolean := func(dst *bool, key string) {
+		if value := getenv(key); value != "" {
+			b, err := strconv.ParseBool(strings.TrimSpace(value))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s must be true or false, got %q", key, value))
+				return
+			}
+			*dst = b
+		}
+	}
+
+	str(&c.Environment, "ENVIRONMENT")
+	str(&c.Version, "VERSION")
+	str(&c.Secret, "MY_LITTLE_SECRET")
+	str(&c.Server.BindAddr, "BIND_ADDR")
+
+	str(&c.Auth0.Domain, "AUTH0_DOMAIN")
+	str(&c.Auth0.Audience, "AUTH0_AUDIENCE")
+	str(&c.Auth0.ClientID, "AUTH0_CLIENT_ID")
+	str(&c.Auth0.ClientSecret, "AUTH0_CLIENT_SECRET")
+	str(&c.Auth0.CallbackURL, "AUTH0_CALLBACK_URL")
+	str(&c.Auth0.LogoutReturnURL, "AUTH0_LOGOUT_RETURN_URL")
+	str(&c.Auth0.Scheme, "AUTH0_SCHEME")
+	str(&c.Auth0.Cookie.Domain, "AUTH0_COOKIE_DOMAIN")
+	boolean(&c.Auth0.Cookie.Secure, "AUTH0_COOKIE_SECURE")
+	str(&c.Auth0.Cookie.SameSite, "AUTH0_COOKIE_SAMESITE")
+
+	str(&c.AWS.Region, "AWS_REGION")
+	str(&c.AWS.Endpoint, "AWS_ENDPOINT_URL")
+
+	str(&c.Database.URL, "DATABASE_URL")
+	// DB_SECRET_ARN predates the other secret providers
+	str(&c.Database.CredentialsSecret, "DB_SECRET_ARN")
+	str(&c.Database.CredentialsSecret, "DB_CREDENTIALS_SECRET")
+	str(&c.Database.Host, "PGHOST")
+	number(&c.Database.Port, "PGPORT")
+	str(&c.Database.User, "PGUSER")
+	str(&c.Database.Password, "PGPASSWORD")
+	str(&c.Database.PasswordSecret, "DB_PASSWORD_SECRET")
+	str(&c.Database.Name, "PGDATABASE")
+	str(&c.Database.SSLMode, "PGSSLMODE")
+	str(&c.Database.SSLRootCert, "PGSSLROOTCERT")
+	str(&c.Database.MigrationsPath, "MIGRATIONS_PATH")
+	number(&c.Database.MigrationTimeoutMS, "MIGRATION_TIMEOUT_MS")
+	str(&c.Secrets.Provider, "SECRETS_PROVIDER")
+	str(&c.Secrets.Dir, "SECRETS_DIR")
+
+	str(&c.Scheduler.Backend, "SCHEDULER_BACKEND")
+	str(&c.Scheduler.TargetArn, "LAMBDA_ARN")
+	str(&c.Scheduler.RoleArn, "SCHEDULER_ROLE_ARN")
+	str(&c.Scheduler.GroupName, "SCHEDULER_GROUP")
+	str(&c.Scheduler.TargetURL, "LOCAL_SCHEDULER_TARGET_URL")
+
+	number(&c.Jobs.Workers, "JOB_WORKERS")
+	str(&c.Events.CallbackSecret, "EVENT_CALLBACK_SECRET")
+	number(&c.Events.ReconcileMinutes, "EVENT_RECONCILE_MINUTES")
+	number(&c.Accounts.DeletionGraceDays, "ACCOUNT_DELETION_GRACE_DAYS")
+	str(&c.Accounts.DeletionPosts, "ACCOUNT_DELETION_POSTS")
+	str(&c.Invitations.SigningSecret, "INVITE_SIGNING_SECRET")
+	str(&c.Invitations.AcceptURL, "INVITE_ACCEPT_URL")
+
+	str(&c.Notifications.DiscordWebhookURL, "DISCORD_WEBHOOK_URL")
+	str(&c.Notifications.SMTP.Host, "SMTP_HOST")
+	str(&c.Notifications.SMTP.Port, "SMTP_PORT")
+	str(&c.Notifications.SMTP.Username, "SMTP_USERNAME")
+	str(&c.Notifications.SMTP.Password, "SMTP_PASSWORD")
+	str(&c.Notifications.SMTP.From, "SMTP_FROM")
+
+	return errors.Join(errs...)
+}
+
+// Validate reports every invalid or missing setting at once. Settings are
+// named by their configuration file key and environment variable.
+func (c *AppConfig) Validate() error {
+	var errs []error
+	problem := func(key, env, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s (%s) %s", key, env, fmt.Sprintf(format, args...)))
+	}
+	required := func(value, key, env string) {
+		if strings.TrimSpace(value) == "" {
+			problem(key, env, "is required")
+		}
+	}
+	positive := func(value int, key, env string) {
+		if value <= 0 {
+			problem(key, env, "must be greater than zero, got %d", value)
+		}
+	}
+	absoluteURL := func(value, key, env string) {
+		if value == "" {
+			return
+		}
+		if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+			problem(key, env, "must be an absolute URL, got %q", value)
+		}
+	}
+
+	required(c.Server.BindAddr, "server.bind_addr", "BIND_ADDR")
+
+	required(c.Auth0.Domain, "auth0.domain", "AUTH0_DOMAIN")
+	required(c.Auth0.Audience, "auth0.audience", "AUTH0_AUDIENCE")
+	absoluteURL(c.Auth0.CallbackURL, "auth0.callback_url", "AUTH0_CALLBACK_URL")
+	absoluteURL(c.Auth0.LogoutReturnURL, "auth0.logout_return_url", "AUTH0_LOGOUT_RETURN_URL")
+	if c.Auth0.Scheme != "https" && c.Auth0.Scheme != "http" {
+		problem("auth0.scheme", "AUTH0_SCHEME", "must be https or http, got %q", c.Auth0.Scheme)
+	}
+	switch strings.ToLower(c.Auth0.Cookie.SameSite) {
+	case "lax", "strict":
+	case "none":
+		if !c.Auth0.Cookie.Secure {
+			problem("auth0.cookie.same_site", "AUTH0_COOKIE_SAMESITE", "can only be none for secure cookies")
+		}
+	default:
+		problem("auth0.cookie.same_site", "AUTH0_COOKIE_SAMESITE", "must be lax, strict or none, got %q", c.Auth0.Cookie.SameSite)
+	}
+
+	errs = append(errs, c.Database.validate()...)
+	positive(c.Database.MigrationTimeoutMS, "database.migration_timeout_ms", "MIGRATION_TIMEOUT_MS")
+	switch c.Secrets.Provider {
+	case SecretProviderAWS, SecretProviderEnv:
+	case SecretProviderFile:
+		required(c.Secrets.Dir, "secrets.dir", "SECRETS_DIR")
+	default:
+		problem("secrets.provider", "SECRETS_PROVIDER", "must be %s, %s or %s, got %q",
+			SecretProviderAWS, SecretProviderFile, SecretProviderEnv, c.Secrets.Provider)
+	}
+
+	switch c.Scheduler.Backend {
+	case schedulerBackendEventBridge, schedulerBackendLocal:
+	case schedulerBackendEventBridgeScheduler:
+		required(c.Scheduler.RoleArn, "scheduler.role_arn", "SCHEDULER_ROLE_ARN")
+	default:
+		problem("scheduler.backend", "SCHEDULER_BACKEND", "must be %s, %s or %s, got %q",
+			schedulerBackendEventBridge, schedulerBackendEventBridgeScheduler, schedulerBackendLocal, c.Scheduler.Backend)
+	}
+	absoluteURL(c.Scheduler.TargetURL, "scheduler.target_url", "LOCAL_SCHEDULER_TARGET_URL")
+
+	positive(c.Jobs.Workers, "jobs.workers", "JOB_WORKERS")
+	positive(c.Events.ReconcileMinutes, "events.reconcile_minutes", "EVENT_RECONCILE_MINUTES")
+	positive(c.Accounts.DeletionGraceDays, "accounts.deletion_grace_days", "ACCOUNT_DELETION_GRACE_DAYS")
+	if c.Accounts.DeletionPosts != models.DeletedPostsAnonymize && c.Accounts.DeletionPosts != models.DeletedPostsDelete {
+		problem("accounts.deletion_posts", "ACCOUNT_DELETION_POSTS", "must be %s or %s, got %q",
+			models.DeletedPostsAnonymize, models.DeletedPostsDelete, c.Accounts.DeletionPosts)
+	}
+	absoluteURL(c.Invitations.AcceptURL, "invitations.accept_url", "INVITE_ACCEPT_URL")
+
+	absoluteURL(c.Notifications.DiscordWebhookURL, "notifications.discord_webhook_url", "DISCORD_WEBHOOK_URL")
+	if c.Notifications.SMTP.Host != "" {
+		if _, err := mail.ParseAddress(c.Notifications.SMTP.From); err != nil {
+			problem("notifications.smtp.from", "SMTP_FROM", "must be an email address when SMTP is enabled")
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validate checks that exactly one way of connecting is configured, and that
+// it is complete.
+func (c DatabaseConfig) validate() []error {
+	var errs []error
+	sources := 0
+	for _, value := range []string{c.URL, c.CredentialsSecret, c.Host} {
+		if value != "" {
+			sources++
+		}
+	}
+	switch {
+	case sources == 0:
+		errs = append(errs, errors.New("database needs one of database.url (DATABASE_URL), database.credentials_secret (DB_CREDENTIALS_SECRET or DB_SECRET_ARN) or database.host (PGHOST)"))
+	case sources > 1:
+		errs = append(errs, errors.New("database must set only one of database.url (DATABASE_URL), database.credentials_secret (DB_CREDENTIALS_SECRET or DB_SECRET_ARN) and database.host (PGHOST)"))
+	case c.URL != "":
+		if u, err := url.Parse(c.URL); err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+			errs = append(errs, errors.New("database.url (DATABASE_URL) must be a postgres:// URL"))
+		}
+	case c.Host != "":
+		if c.User == "" {
+			errs = append(errs, errors.New("database.user (PGUSER) is required with database.host"))
+		}
+		if c.Name == "" {
+			errs = append(errs, errors.New("database.name (PGDATABASE) is required with database.host"))
+		}
+		if c.Port <= 0 || c.Port > 65535 {
+			errs = append(errs, fmt.Errorf("database.port (PGPORT) must be a TCP port, got %d", c.Port))
+		}
+		if c.Password != "" && c.PasswordSecret != "" {
+			errs = append(errs, errors.New("database must set only one of database.password (PGPASSWORD) and database.password_secret (DB_PASSWORD_SECRET)"))
+		}
+	}
+
+	switch c.SSLMode {
+	case "", "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		errs = append(errs, fmt.Errorf("database.ssl_mode (PGSSLMODE) must be disable, allow, prefer, require, verify-ca or verify-full, got %q", c.SSLMode))
+	}
+	return errs
+}
+
+// CORSOrigins lists the browser origins allowed to call the API.
+func (c *AppConfig) CORSOrigins() []string {
+	origins := []string{"https://nofeed.zone"}
+	if c.Environment == "development" {
+		origins = append(origins, "http://localhost:3000")
+	}
+	return origins
+}
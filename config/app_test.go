@@ -0,0 +1,166 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// envMap stands in for os.Getenv.
+func envMap(values map[string]string) func(string) string {
+	return func(key string) string { return values[key] }
+}
+
+func requiredEnv() map[string]string {
+	return map[string]string{
+		"AUTH0_DOMAIN":   "tenant.auth0.com",
+		"AUTH0_AUDIENCE": "https://api.example.com",
+		"DB_SECRET_ARN":  "arn:db",
+	}
+}
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_DefaultsAndEnvironment(t *testing.T) {
+	env := requiredEnv()
+	env["JOB_WORKERS"] = "8"
+	env["AUTH0_COOKIE_SECURE"] = "false"
+	env["ENVIRONMENT"] = "development"
+
+	cfg, err := Load(envMap(env))
+
+	require.NoError(t, err)
+	assert.Equal(t, "tenant.auth0.com", cfg.Auth0.Domain)
+	assert.Equal(t, "https", cfg.Auth0.Scheme)
+	assert.False(t, cfg.Auth0.Cookie.Secure)
+	assert.Equal(t, 8, cfg.Jobs.Workers)
+	assert.Equal(t, "0.0.0.0:8080", cfg.Server.BindAddr)
+	assert.Equal(t, time.Hour, cfg.Events.ReconcileInterval())
+	assert.Equal(t, 30*time.Second, cfg.Database.MigrationTimeout())
+	assert.Equal(t, []string{"https://nofeed.zone", "http://localhost:3000"}, cfg.CORSOrigins())
+}
+
+func TestLoad_FileThenEnvironment(t *testing.T) {
+	files := map[string]string{
+		"api.yaml": `
+version: "1.2.3"
+auth0:
+  domain: file.auth0.com
+  audience: https://file.example.com
+  cookie:
+    same_site: strict
+database:
+  credentials_secret: arn:file
+jobs:
+  workers: 2
+`,
+		"api.toml": `
+version = "1.2.3"
+
+[auth0]
+domain = "file.auth0.com"
+audience = "https://file.example.com"
+
+[auth0.cookie]
+same_site = "strict"
+
+[database]
+credentials_secret = "arn:file"
+
+[jobs]
+workers = 2
+`,
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			env := map[string]string{
+				ConfigFileEnv:  writeConfigFile(t, name, content),
+				"AUTH0_DOMAIN": "env.auth0.com",
+			}
+
+			cfg, err := Load(envMap(env))
+
+			require.NoError(t, err)
+			assert.Equal(t, "1.2.3", cfg.Version)
+			assert.Equal(t, "env.auth0.com", cfg.Auth0.Domain)
+			assert.Equal(t, "https://file.example.com", cfg.Auth0.Audience)
+			assert.Equal(t, "strict", cfg.Auth0.Cookie.SameSite)
+			assert.True(t, cfg.Auth0.Cookie.Secure)
+			assert.Equal(t, "arn:file", cfg.Database.CredentialsSecret)
+			assert.Equal(t, 2, cfg.Jobs.Workers)
+		})
+	}
+}
+
+func TestLoad_RejectsUnknownFileKeys(t *testing.T) {
+	path := writeConfigFile(t, "api.yaml", "auth0:\n  domian: typo.auth0.com\n")
+
+	_, err := Load(envMap(map[string]string{ConfigFileEnv: path}))
+
+	assert.ErrorContains(t, err, "domian")
+}
+
+func TestLoad_ReportsEveryProblem(t *testing.T) {
+	env := map[string]string{
+		"JOB_WORKERS":            "many",
+		"AUTH0_COOKIE_SAMESITE":  "none",
+		"AUTH0_COOKIE_SECURE":    "false",
+		"SCHEDULER_BACKEND":      "cron",
+		"ACCOUNT_DELETION_POSTS": "keep",
+		"SMTP_HOST":              "smtp.example.com",
+	}
+
+	_, err := Load(envMap(env))
+
+	require.Error(t, err)
+	for _, want := range []string{
+		"JOB_WORKERS must be a whole number",
+		"auth0.domain (AUTH0_DOMAIN) is required",
+		"auth0.audience (AUTH0_AUDIENCE) is required",
+		"database needs one of database.url (DATABASE_URL)",
+		"auth0.cookie.same_site (AUTH0_COOKIE_SAMESITE) can only be none for secure cookies",
+		"scheduler.backend (SCHEDULER_BACKEND) must be",
+		"accounts.deletion_posts (ACCOUNT_DELETION_POSTS) must be",
+		"notifications.smtp.from (SMTP_FROM)",
+	} {
+		assert.ErrorContains(t, err, want)
+	}
+}
+
+func TestLoad_DatabaseConnection(t *testing.T) {
+	local := map[string]string{
+		"AUTH0_DOMAIN":     "tenant.auth0.com",
+		"AUTH0_AUDIENCE":   "https://api.example.com",
+		"PGHOST":           "localhost",
+		"PGUSER":           "app",
+		"PGDATABASE":       "api",
+		"PGSSLMODE":        "disable",
+		"SECRETS_PROVIDER": "file",
+	}
+	cfg, err := Load(envMap(local))
+	require.NoError(t, err)
+	assert.Equal(t, 5432, cfg.Database.Port)
+	assert.Equal(t, DefaultSecretsDir, cfg.Secrets.Dir)
+
+	conflicting := requiredEnv()
+	conflicting["DATABASE_URL"] = "mysql://localhost/api"
+	conflicting["PGSSLMODE"] = "sometimes"
+	_, err = Load(envMap(conflicting))
+	assert.ErrorContains(t, err, "database must set only one of")
+	assert.ErrorContains(t, err, "database.ssl_mode (PGSSLMODE) must be")
+
+	wrongScheme := requiredEnv()
+	delete(wrongScheme, "DB_SECRET_ARN")
+	wrongScheme["DATABASE_URL"] = "mysql://localhost/api"
+	_, err = Load(envMap(wrongScheme))
+	assert.ErrorContains(t, err, "database.url (DATABASE_URL) must be a postgres:// URL")
+}
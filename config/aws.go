@@ -0,0 +1,57 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/ratelimit"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+)
+
+// AWS calls are tried up to awsMaxAttempts times, waiting at most
+// awsMaxBackoff between attempts.
+const (
+	awsMaxAttempts = 6
+	awsMaxBackoff  = 20 * time.Second
+)
+
+// AWSOptions overrides parts of the default AWS configuration; empty fields
+// keep what the SDK finds in the environment and shared config files.
+type AWSOptions struct {
+	Region string `yaml:"region" toml:"region"`
+	// Endpoint sends every client to one URL, such as LocalStack on
+	// http://localhost:4566.
+	Endpoint string `yaml:"endpoint" toml:"endpoint"`
+}
+
+// LoadAWS loads the AWS configuration once for every client the API uses.
+func LoadAWS(ctx context.Context, opts AWSOptions) (aws.Config, error) {
+	loadOptions := []func(*awsconfig.LoadOptions) error{awsconfig.WithRetryer(NewAWSRetryer)}
+	if opts.Region != "" {
+		loadOptions = append(loadOptions, awsconfig.WithRegion(opts.Region))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, loadOptions...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("unable to load AWS config: %w", err)
+	}
+	if opts.Endpoint != "" {
+		cfg.BaseEndpoint = aws.String(opts.Endpoint)
+	}
+	return cfg, nil
+}
+
+// NewAWSRetryer retries throttling and transient AWS errors with exponential
+// backoff and full jitter. Listing a large account's rules can be throttled
+// for a while, so the client-side retry budget that would give up early is
+// turned off.
+func NewAWSRetryer() aws.Retryer {
+	return retry.NewStandard(func(o *retry.StandardOptions) {
+		o.MaxAttempts = awsMaxAttempts
+		o.MaxBackoff = awsMaxBackoff
+		o.Backoff = retry.NewExponentialJitterBackoff(awsMaxBackoff)
+		o.RateLimiter = ratelimit.None
+	})
+}
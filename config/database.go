@@ -5,16 +5,13 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/url"
-	"os"
 	"strconv"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
-	"github.com/jmoiron/sqlx"
 	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
 )
 
 type secretPort struct {
@@ -59,11 +56,13 @@ type dbSecret struct {
 	DBName   string     `json:"dbname"`
 }
 
-func NewDB() (*sqlx.DB, error) {
+// NewDB connects to the database cfg describes, reading any secrets it names
+// through secrets.
+func NewDB(cfg DatabaseConfig, secrets SecretProvider) (*sqlx.DB, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	dsn, err := buildPostgresDSN(ctx)
+	dsn, err := buildPostgresDSN(ctx, cfg, secrets)
 	if err != nil {
 		return nil, err
 	}
@@ -80,42 +79,73 @@ func NewDB() (*sqlx.DB, error) {
 	return sqlx.NewDb(db, "pgx"), nil
 }
 
-func buildPostgresDSN(ctx context.Context) (string, error) {
-	secret, err := loadDBSecret(ctx)
-	if err != nil {
-		return "", err
+// buildPostgresDSN takes the connection URL from cfg.URL, the credentials
+// secret, or the discrete settings, in that order, then applies the TLS
+// settings. Without an sslmode from either cfg or the URL, TLS is required.
+func buildPostgresDSN(ctx context.Context, cfg DatabaseConfig, secrets SecretProvider) (string, error) {
+	var dsn *url.URL
+	switch {
+	case cfg.URL != "":
+		parsed, err := url.Parse(cfg.URL)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse database URL: %w", err)
+		}
+		dsn = parsed
+	case cfg.CredentialsSecret != "":
+		secret, err := loadDBSecret(ctx, cfg.CredentialsSecret, secrets)
+		if err != nil {
+			return "", err
+		}
+		dsn = postgresURL(secret.Host, secret.Port.String(), secret.Username, secret.Password, secret.DBName)
+	default:
+		password := cfg.Password
+		if cfg.PasswordSecret != "" {
+			value, err := secrets.GetSecret(ctx, cfg.PasswordSecret)
+			if err != nil {
+				return "", fmt.Errorf("failed to load db password: %w", err)
+			}
+			password = value
+		}
+		dsn = postgresURL(cfg.Host, strconv.Itoa(cfg.Port), cfg.User, password, cfg.Name)
 	}
 
-	username := url.QueryEscape(secret.Username)
-	password := url.QueryEscape(secret.Password)
-	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=require", username, password, secret.Host, secret.Port.String(), secret.DBName)
-	return dsn, nil
+	query := dsn.Query()
+	if cfg.SSLMode != "" {
+		query.Set("sslmode", cfg.SSLMode)
+	} else if !query.Has("sslmode") {
+		query.Set("sslmode", "require")
+	}
+	if cfg.SSLRootCert != "" {
+		query.Set("sslrootcert", cfg.SSLRootCert)
+	}
+	dsn.RawQuery = query.Encode()
+	return dsn.String(), nil
 }
 
-func loadDBSecret(ctx context.Context) (dbSecret, error) {
-	secretArn := os.Getenv("DB_SECRET_ARN")
-	if secretArn == "" {
-		return dbSecret{}, fmt.Errorf("DB_SECRET_ARN must be set")
+func postgresURL(host, port, user, password, dbName string) *url.URL {
+	dsn := &url.URL{
+		Scheme: "postgres",
+		Host:   net.JoinHostPort(host, port),
+		Path:   "/" + dbName,
 	}
-
-	cfg, err := config.LoadDefaultConfig(ctx)
-	if err != nil {
-		return dbSecret{}, fmt.Errorf("failed to load aws config: %w", err)
+	if password != "" {
+		dsn.User = url.UserPassword(user, password)
+	} else {
+		dsn.User = url.User(user)
 	}
+	return dsn
+}
 
-	client := secretsmanager.NewFromConfig(cfg)
-	secretValue, err := client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
-		SecretId: aws.String(secretArn),
-	})
+// loadDBSecret reads credentials stored as the JSON document RDS keeps in
+// Secrets Manager: username, password, host, port and dbname.
+func loadDBSecret(ctx context.Context, name string, secrets SecretProvider) (dbSecret, error) {
+	value, err := secrets.GetSecret(ctx, name)
 	if err != nil {
 		return dbSecret{}, fmt.Errorf("failed to fetch db secret: %w", err)
 	}
-	if secretValue.SecretString == nil {
-		return dbSecret{}, fmt.Errorf("db secret has no SecretString")
-	}
 
 	var secret dbSecret
-	if err := json.Unmarshal([]byte(*secretValue.SecretString), &secret); err != nil {
+	if err := json.Unmarshal([]byte(value), &secret); err != nil {
 		return dbSecret{}, fmt.Errorf("failed to parse db secret JSON: %w", err)
 	}
 	if secret.Username == "" || secret.Password == "" || secret.Host == "" || secret.DBName == "" || secret.Port.Int == 0 {
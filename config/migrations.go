@@ -7,7 +7,6 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
-	"strconv"
 	"time"
 
 	"github.com/golang-migrate/migrate/v4"
@@ -15,12 +14,14 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 )
 
-func RunMigrations() error {
-	timeout := migrationTimeout()
+// RunMigrations applies pending migrations to the database cfg describes,
+// reading any secrets it names through secrets.
+func RunMigrations(cfg DatabaseConfig, secrets SecretProvider) error {
+	timeout := cfg.MigrationTimeout()
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	dsn, err := buildPostgresDSN(ctx)
+	dsn, err := buildPostgresDSN(ctx, cfg, secrets)
 	if err != nil {
 		return err
 	}
@@ -45,7 +46,7 @@ func RunMigrations() error {
 		return fmt.Errorf("failed to init migration driver: %w", err)
 	}
 
-	sourceURL, err := migrationSourceURL()
+	sourceURL, err := migrationSourceURL(cfg.MigrationsPath)
 	if err != nil {
 		return err
 	}
@@ -62,8 +63,8 @@ func RunMigrations() error {
 	return nil
 }
 
-func migrationSourceURL() (string, error) {
-	if customPath := os.Getenv("MIGRATIONS_PATH"); customPath != "" {
+func migrationSourceURL(customPath string) (string, error) {
+	if customPath != "" {
 		return "file://" + filepath.ToSlash(customPath), nil
 	}
 
@@ -83,21 +84,6 @@ func migrationSourceURL() (string, error) {
 	return "file://" + filepath.ToSlash(migrationsPath), nil
 }
 
-func migrationTimeout() time.Duration {
-	const defaultTimeout = 30 * time.Second
-	raw := os.Getenv("MIGRATION_TIMEOUT_MS")
-	if raw == "" {
-		return defaultTimeout
-	}
-
-	value, err := strconv.Atoi(raw)
-	if err != nil || value <= 0 {
-		return defaultTimeout
-	}
-
-	return time.Duration(value) * time.Millisecond
-}
-
 func withStatementTimeout(dsn string, timeout time.Duration) (string, error) {
 	parsed, err := url.Parse(dsn)
 	if err != nil {
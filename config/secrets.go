@@ -0,0 +1,128 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+)
+
+// Secret providers selectable with SECRETS_PROVIDER.
+const (
+	SecretProviderAWS  = "aws"
+	SecretProviderFile = "file"
+	SecretProviderEnv  = "env"
+)
+
+// DefaultSecretsDir is where Docker mounts secrets; Kubernetes secret volumes
+// can be mounted anywhere and named with SECRETS_DIR.
+const DefaultSecretsDir = "/run/secrets"
+
+// ErrSecretNotFound is returned when a provider has no secret by that name.
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretProvider resolves secrets by name. What a name means depends on the
+// provider: an environment variable, a file, or a Secrets Manager ID or ARN.
+type SecretProvider interface {
+	GetSecret(ctx context.Context, name string) (string, error)
+}
+
+// SecretsManagerAPI is the part of the Secrets Manager client the AWS
+// provider uses, so tests can substitute a fake.
+type SecretsManagerAPI interface {
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// NewSecretProvider builds the provider cfg selects. getenv backs the env
+// provider and client the AWS provider.
+func NewSecretProvider(cfg SecretsConfig, getenv func(string) string, client SecretsManagerAPI) (SecretProvider, error) {
+	switch cfg.Provider {
+	case SecretProviderAWS:
+		return NewAWSSecretProvider(client), nil
+	case SecretProviderFile:
+		return NewFileSecretProvider(cfg.Dir), nil
+	case SecretProviderEnv:
+		return NewEnvSecretProvider(getenv), nil
+	default:
+		return nil, fmt.Errorf("unknown secret provider %q", cfg.Provider)
+	}
+}
+
+type envSecretProvider struct {
+	getenv func(string) string
+}
+
+// NewEnvSecretProvider reads each secret from the environment variable it
+// names.
+func NewEnvSecretProvider(getenv func(string) string) SecretProvider {
+	return &envSecretProvider{getenv: getenv}
+}
+
+func (p *envSecretProvider) GetSecret(ctx context.Context, name string) (string, error) {
+	value := p.getenv(name)
+	if value == "" {
+		return "", fmt.Errorf("%w: environment variable %s is not set", ErrSecretNotFound, name)
+	}
+	return value, nil
+}
+
+type fileSecretProvider struct {
+	dir string
+}
+
+// NewFileSecretProvider reads each secret from the file it names in dir, as
+// mounted by Docker and Kubernetes. Absolute names are read as they are.
+func NewFileSecretProvider(dir string) SecretProvider {
+	if dir == "" {
+		dir = DefaultSecretsDir
+	}
+	return &fileSecretProvider{dir: dir}
+}
+
+func (p *fileSecretProvider) GetSecret(ctx context.Context, name string) (string, error) {
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(p.dir, name)
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%w: no file %s", ErrSecretNotFound, path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	// Files written by hand usually end in a newline that is not part of the secret
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+type awsSecretProvider struct {
+	client SecretsManagerAPI
+}
+
+// NewAWSSecretProvider reads each secret's string value from Secrets Manager.
+func NewAWSSecretProvider(client SecretsManagerAPI) SecretProvider {
+	return &awsSecretProvider{client: client}
+}
+
+func (p *awsSecretProvider) GetSecret(ctx context.Context, name string) (string, error) {
+	output, err := p.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(name),
+	})
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch secret %s: %w", name, err)
+	}
+	if output.SecretString == nil {
+		return "", fmt.Errorf("secret %s has no SecretString", name)
+	}
+	return *output.SecretString, nil
+}
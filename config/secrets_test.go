@@ -0,0 +1,61 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type notFoundSecrets struct{}
+
+func (notFoundSecrets) GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	return nil, &types.ResourceNotFoundException{}
+}
+
+func TestFileSecretProvider(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "db_password"), []byte("s3cret\n"), 0o600))
+	provider := NewFileSecretProvider(dir)
+
+	value, err := provider.GetSecret(context.Background(), "db_password")
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cret", value)
+
+	value, err = provider.GetSecret(context.Background(), filepath.Join(dir, "db_password"))
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cret", value)
+
+	_, err = provider.GetSecret(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrSecretNotFound)
+}
+
+func TestEnvSecretProvider(t *testing.T) {
+	provider := NewEnvSecretProvider(envMap(map[string]string{"DB_PASSWORD": "s3cret"}))
+
+	value, err := provider.GetSecret(context.Background(), "DB_PASSWORD")
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cret", value)
+
+	_, err = provider.GetSecret(context.Background(), "DB_MISSING")
+	assert.ErrorIs(t, err, ErrSecretNotFound)
+}
+
+func TestAWSSecretProvider(t *testing.T) {
+	value, err := NewAWSSecretProvider(&fakeSecrets{values: map[string]string{"arn:db": "s3cret"}}).GetSecret(context.Background(), "arn:db")
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cret", value)
+
+	_, err = NewAWSSecretProvider(notFoundSecrets{}).GetSecret(context.Background(), "arn:missing")
+	assert.ErrorIs(t, err, ErrSecretNotFound)
+}
+
+func TestNewSecretProvider_RejectsUnknownProvider(t *testing.T) {
+	_, err := NewSecretProvider(SecretsConfig{Provider: "vault"}, envMap(nil), nil)
+	assert.ErrorContains(t, err, "vault")
+}
@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/services"
+	"github.com/dat1010/go-api/utils"
+	"github.com/gin-gonic/gin"
+)
+
+var accountService services.AccountService
+
+func SetAccountService(s services.AccountService) {
+	accountService = s
+}
+
+// @Summary Export my data
+// @Description Download a zip of everything stored about the authenticated user: profile, role, posts and events
+// @Tags account
+// @Produce application/zip
+// @Security Bearer
+// @Success 200 {file} file "Zip archive"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 403 {object} object "Impersonating"
+// @Failure 404 {object} object "User not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /me/export [get]
+func ExportMyData(c *gin.Context) {
+	auth0UserID, ok := utils.GetAuth0UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	data, err := accountService.ExportUserData(auth0UserID)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export user data"})
+		return
+	}
+
+	events, err := eventService.ListUserEvents(auth0UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export events"})
+		return
+	}
+
+	archive, err := buildExportArchive(map[string]interface{}{
+		"profile.json": data.Profile,
+		"role.json":    gin.H{"role": data.Role},
+		"posts.json":   data.Posts,
+		"events.json":  events,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build export"})
+		return
+	}
+
+	filename := fmt.Sprintf("nofeed-export-%s.zip", time.Now().UTC().Format("20060102"))
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+func buildExportArchive(files map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	for _, name := range []string{"profile.json", "role.json", "posts.json", "events.json"} {
+		content, ok := files[name]
+		if !ok {
+			continue
+		}
+		w, err := zw.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// @Summary Delete my account
+// @Description Schedule erasure of the authenticated user's account after a grace period. Posts are anonymized or deleted per server configuration and scheduled events are removed
+// @Tags account
+// @Produce json
+// @Security Bearer
+// @Success 202 {object} models.AccountDeletion
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 403 {object} object "Impersonating"
+// @Failure 500 {object} object "Internal server error"
+// @Router /me [delete]
+func DeleteMyAccount(c *gin.Context) {
+	auth0UserID, ok := utils.GetAuth0UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	deletion, err := accountService.ScheduleDeletion(auth0UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to schedule account deletion"})
+		return
+	}
+
+	recordAudit(c, models.AuditActionAccountDeletionRequest, models.AuditTargetUser, auth0UserID, nil, deletion)
+	c.JSON(http.StatusAccepted, deletion)
+}
+
+// @Summary Get pending account deletion
+// @Description Show when the authenticated user's account is scheduled to be erased
+// @Tags account
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} models.AccountDeletion
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 403 {object} object "Impersonating"
+// @Failure 404 {object} object "No deletion pending"
+// @Failure 500 {object} object "Internal server error"
+// @Router /me/deletion [get]
+func GetMyAccountDeletion(c *gin.Context) {
+	auth0UserID, ok := utils.GetAuth0UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	deletion, err := accountService.GetPendingDeletion(auth0UserID)
+	if err != nil {
+		if errors.Is(err, services.ErrNoPendingDeletion) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load account deletion"})
+		return
+	}
+	c.JSON(http.StatusOK, deletion)
+}
+
+// @Summary Cancel account deletion
+// @Description Cancel a pending account deletion during its grace period
+// @Tags account
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} object "Deletion canceled"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 403 {object} object "Impersonating"
+// @Failure 404 {object} object "No deletion pending"
+// @Failure 500 {object} object "Internal server error"
+// @Router /me/deletion [delete]
+func CancelMyAccountDeletion(c *gin.Context) {
+	auth0UserID, ok := utils.GetAuth0UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if err := accountService.CancelDeletion(auth0UserID); err != nil {
+		if errors.Is(err, services.ErrNoPendingDeletion) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel account deletion"})
+		return
+	}
+
+	recordAudit(c, models.AuditActionAccountDeletionCancel, models.AuditTargetUser, auth0UserID, nil, nil)
+	c.JSON(http.StatusOK, gin.H{"canceled": true})
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAccountService struct {
+	services.AccountService
+	ExportUserDataFunc   func(auth0UserID string) (*models.UserDataExport, error)
+	ScheduleDeletionFunc func(auth0UserID string) (*models.AccountDeletion, error)
+}
+
+func (m *mockAccountService) ExportUserData(auth0UserID string) (*models.UserDataExport, error) {
+	return m.ExportUserDataFunc(auth0UserID)
+}
+
+func (m *mockAccountService) ScheduleDeletion(auth0UserID string) (*models.AccountDeletion, error) {
+	return m.ScheduleDeletionFunc(auth0UserID)
+}
+
+func TestExportMyData_Zip(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	accountService = &mockAccountService{
+		ExportUserDataFunc: func(auth0UserID string) (*models.UserDataExport, error) {
+			return &models.UserDataExport{
+				Profile: &models.UserProfile{Auth0UserID: auth0UserID, Status: models.UserStatusActive},
+				Role:    "member",
+				Posts:   []models.Post{{ID: "post-1", Title: "Hello"}},
+			}, nil
+		},
+	}
+	eventService = &mockEventService{
+		ListUserEventsFunc: func(ownerID string) ([]models.Event, error) {
+			return []models.Event{{Name: "daily", OwnerID: ownerID}}, nil
+		},
+	}
+	defer func() {
+		accountService = nil
+		eventService = nil
+	}()
+
+	r := gin.Default()
+	r.GET("/me/export", func(c *gin.Context) {
+		c.Set("user", validator.RegisteredClaims{Subject: "auth0|testuser"})
+		ExportMyData(c)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/me/export", http.NoBody)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	assert.NoError(t, err)
+	contents := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		assert.NoError(t, err)
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		contents[f.Name] = string(data)
+	}
+	assert.Contains(t, contents["profile.json"], `"auth0_user_id": "auth0|testuser"`)
+	assert.Contains(t, contents["role.json"], `"member"`)
+	assert.Contains(t, contents["posts.json"], `"post-1"`)
+	assert.Contains(t, contents["events.json"], `"daily"`)
+}
+
+func TestDeleteMyAccount_Schedules(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	scheduledFor := time.Now().Add(30 * 24 * time.Hour)
+	accountService = &mockAccountService{
+		ScheduleDeletionFunc: func(auth0UserID string) (*models.AccountDeletion, error) {
+			return &models.AccountDeletion{Auth0UserID: auth0UserID, ScheduledFor: scheduledFor}, nil
+		},
+	}
+	defer func() { accountService = nil }()
+
+	r := gin.Default()
+	r.DELETE("/me", func(c *gin.Context) {
+		c.Set("user", validator.RegisteredClaims{Subject: "auth0|testuser"})
+		DeleteMyAccount(c)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/me", http.NoBody)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Contains(t, w.Body.String(), `"scheduled_for"`)
+}
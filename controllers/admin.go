@@ -1,9 +1,12 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
+	"github.com/dat1010/go-api/models"
 	"github.com/dat1010/go-api/services"
+	"github.com/dat1010/go-api/utils"
 	"github.com/gin-gonic/gin"
 )
 
@@ -53,10 +56,12 @@ func CreateUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
 		return
 	}
-	if err := userService.EnsureUserWithDefaultRole(req.Auth0UserID, "member"); err != nil {
+	role, err := userService.EnsureUserWithDefaultRole(req.Auth0UserID, "member")
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create user"})
 		return
 	}
+	recordAudit(c, models.AuditActionUserCreate, models.AuditTargetUser, req.Auth0UserID, nil, gin.H{"role": role})
 	c.JSON(http.StatusCreated, gin.H{"created": true})
 }
 
@@ -84,6 +89,7 @@ func UpdateUserRole(c *gin.Context) {
 		return
 	}
 
+	previousRole, _ := userService.GetUserRole(auth0UserID)
 	if err := userService.SetUserRole(auth0UserID, req.Role); err != nil {
 		if err == services.ErrRoleNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "role not found"})
@@ -92,6 +98,7 @@ func UpdateUserRole(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update role"})
 		return
 	}
+	recordAudit(c, models.AuditActionUserRoleUpdate, models.AuditTargetUser, auth0UserID, gin.H{"role": previousRole}, gin.H{"role": req.Role})
 	c.JSON(http.StatusOK, gin.H{"updated": true})
 }
 
@@ -110,9 +117,171 @@ func DeleteUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "missing user id"})
 		return
 	}
+	previousRole, _ := userService.GetUserRole(auth0UserID)
 	if err := userService.DeleteUser(auth0UserID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete user"})
 		return
 	}
+	recordAudit(c, models.AuditActionUserDelete, models.AuditTargetUser, auth0UserID, gin.H{"role": previousRole}, nil)
 	c.JSON(http.StatusOK, gin.H{"deleted": true})
 }
+
+// @Summary Suspend user
+// @Description Suspend a user until the given time; their data is kept (superadmin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Auth0 user id"
+// @Param body body models.SuspendUserRequest true "Suspension payload"
+// @Success 200 {object} object "User suspended"
+// @Failure 400 {object} object "Bad request"
+// @Failure 404 {object} object "User not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /admin/users/{id}/suspend [post]
+func SuspendUser(c *gin.Context) {
+	var req models.SuspendUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+	auth0UserID, actor, ok := statusChangeTarget(c)
+	if !ok {
+		return
+	}
+
+	before, _ := userService.GetUserStatus(auth0UserID)
+	if err := userService.SuspendUser(auth0UserID, req.Reason, req.Until, actor); err != nil {
+		respondStatusChangeError(c, err)
+		return
+	}
+	after, _ := userService.GetUserStatus(auth0UserID)
+	recordAudit(c, models.AuditActionUserSuspend, models.AuditTargetUser, auth0UserID, before, after)
+	c.JSON(http.StatusOK, gin.H{"suspended": true})
+}
+
+// @Summary Ban user
+// @Description Ban a user indefinitely and hide their content; their data is kept (superadmin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Auth0 user id"
+// @Param body body models.BanUserRequest true "Ban payload"
+// @Success 200 {object} object "User banned"
+// @Failure 400 {object} object "Bad request"
+// @Failure 404 {object} object "User not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /admin/users/{id}/ban [post]
+func BanUser(c *gin.Context) {
+	var req models.BanUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+	auth0UserID, actor, ok := statusChangeTarget(c)
+	if !ok {
+		return
+	}
+
+	before, _ := userService.GetUserStatus(auth0UserID)
+	if err := userService.BanUser(auth0UserID, req.Reason, actor); err != nil {
+		respondStatusChangeError(c, err)
+		return
+	}
+	after, _ := userService.GetUserStatus(auth0UserID)
+	recordAudit(c, models.AuditActionUserBan, models.AuditTargetUser, auth0UserID, before, after)
+	c.JSON(http.StatusOK, gin.H{"banned": true})
+}
+
+// @Summary Reinstate user
+// @Description Lift a suspension or ban (superadmin only)
+// @Tags admin
+// @Produce json
+// @Param id path string true "Auth0 user id"
+// @Success 200 {object} object "User reinstated"
+// @Failure 400 {object} object "Bad request"
+// @Failure 404 {object} object "User not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /admin/users/{id}/reinstate [post]
+func ReinstateUser(c *gin.Context) {
+	auth0UserID, actor, ok := statusChangeTarget(c)
+	if !ok {
+		return
+	}
+
+	before, _ := userService.GetUserStatus(auth0UserID)
+	if err := userService.ReinstateUser(auth0UserID, actor); err != nil {
+		respondStatusChangeError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionUserReinstate, models.AuditTargetUser, auth0UserID, before, gin.H{"status": models.UserStatusActive})
+	c.JSON(http.StatusOK, gin.H{"reinstated": true})
+}
+
+// @Summary Set user event quota
+// @Description Set how many enabled scheduled events a user may have; null restores the default (superadmin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Auth0 user id"
+// @Param body body models.SetEventQuotaRequest true "Quota payload"
+// @Success 200 {object} object "Quota updated"
+// @Failure 400 {object} object "Bad request"
+// @Failure 404 {object} object "User not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /admin/users/{id}/event-quota [put]
+func SetUserEventQuota(c *gin.Context) {
+	var req models.SetEventQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+	auth0UserID := c.Param("id")
+	if auth0UserID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing user id"})
+		return
+	}
+
+	before, _ := userService.GetEventQuota(auth0UserID)
+	if err := userService.SetEventQuota(auth0UserID, req.MaxActiveEvents); err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		case errors.Is(err, services.ErrInvalidEventQuota):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update event quota"})
+		}
+		return
+	}
+	after, _ := userService.GetEventQuota(auth0UserID)
+	recordAudit(c, models.AuditActionUserEventQuotaUpdate, models.AuditTargetUser, auth0UserID,
+		gin.H{"max_active_events": before}, gin.H{"max_active_events": after})
+	c.JSON(http.StatusOK, gin.H{"max_active_events": after})
+}
+
+// statusChangeTarget resolves the user being acted on and the acting admin,
+// refusing changes an admin would make to their own account.
+func statusChangeTarget(c *gin.Context) (string, string, bool) {
+	auth0UserID := c.Param("id")
+	if auth0UserID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing user id"})
+		return "", "", false
+	}
+	actor, _ := utils.GetAuth0UserID(c)
+	if actor == auth0UserID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot change your own account status"})
+		return "", "", false
+	}
+	return auth0UserID, actor, true
+}
+
+func respondStatusChangeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+	case errors.Is(err, services.ErrInvalidSuspension):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user status"})
+	}
+}
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockUserService implements only the calls the tests exercise; anything else panics.
+type mockUserService struct {
+	services.UserService
+	EnsureUserWithDefaultRoleFunc func(auth0UserID, defaultRole string) (string, error)
+	GetUserStatusFunc             func(auth0UserID string) (*models.UserStatus, error)
+	SuspendUserFunc               func(auth0UserID, reason string, until time.Time, changedBy string) error
+}
+
+func (m *mockUserService) EnsureUserWithDefaultRole(auth0UserID, defaultRole string) (string, error) {
+	return m.EnsureUserWithDefaultRoleFunc(auth0UserID, defaultRole)
+}
+
+func (m *mockUserService) GetUserStatus(auth0UserID string) (*models.UserStatus, error) {
+	if m.GetUserStatusFunc != nil {
+		return m.GetUserStatusFunc(auth0UserID)
+	}
+	return &models.UserStatus{Status: models.UserStatusActive}, nil
+}
+
+func (m *mockUserService) SuspendUser(auth0UserID, reason string, until time.Time, changedBy string) error {
+	return m.SuspendUserFunc(auth0UserID, reason, until, changedBy)
+}
+
+func suspendRequest(t *testing.T, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := gin.Default()
+	r.POST("/admin/users/:id/suspend", func(c *gin.Context) {
+		c.Set("user", validator.RegisteredClaims{Subject: "auth0|admin"})
+		SuspendUser(c)
+	})
+
+	body := `{"reason":"spam","until":"` + time.Now().Add(24*time.Hour).UTC().Format(time.RFC3339) + `"}`
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/admin/users/"+target+"/suspend", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestSuspendUser_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var gotUser, gotReason, gotActor string
+	userService = &mockUserService{
+		SuspendUserFunc: func(auth0UserID, reason string, until time.Time, changedBy string) error {
+			gotUser, gotReason, gotActor = auth0UserID, reason, changedBy
+			return nil
+		},
+	}
+	defer func() { userService = nil }()
+
+	w := suspendRequest(t, "member")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "member", gotUser)
+	assert.Equal(t, "spam", gotReason)
+	assert.Equal(t, "auth0|admin", gotActor)
+}
+
+func TestSuspendUser_Self(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	userService = &mockUserService{}
+	defer func() { userService = nil }()
+
+	w := suspendRequest(t, "auth0|admin")
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestSuspendUser_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	userService = &mockUserService{
+		SuspendUserFunc: func(auth0UserID, reason string, until time.Time, changedBy string) error {
+			return services.ErrUserNotFound
+		},
+	}
+	defer func() { userService = nil }()
+
+	w := suspendRequest(t, "missing")
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCreateUser_AuditsTheUsersActualRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	userService = &mockUserService{
+		EnsureUserWithDefaultRoleFunc: func(auth0UserID, defaultRole string) (string, error) {
+			assert.Equal(t, "member", defaultRole)
+			return "superadmin", nil
+		},
+	}
+	var recorded *models.AuditLog
+	auditService = &mockAuditService{RecordFunc: func(entry *models.AuditLog) error {
+		recorded = entry
+		return nil
+	}}
+	defer func() { userService, auditService = nil, nil }()
+
+	r := gin.Default()
+	r.POST("/admin/users", func(c *gin.Context) {
+		c.Set("user", validator.RegisteredClaims{Subject: "auth0|admin"})
+		CreateUser(c)
+	})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/admin/users", bytes.NewBufferString(`{"auth0_user_id":"auth0|existing"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	if assert.NotNil(t, recorded) {
+		assert.Equal(t, models.AuditActionUserCreate, recorded.Action)
+		assert.JSONEq(t, `{"role":"superadmin"}`, string(recorded.After))
+	}
+}
@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/services"
+	"github.com/dat1010/go-api/utils"
+	"github.com/gin-gonic/gin"
+)
+
+var auditService services.AuditService
+
+func SetAuditService(s services.AuditService) {
+	auditService = s
+}
+
+// recordAudit appends an entry to the audit log for the current request.
+// Failures are logged rather than surfaced so that auditing never breaks the action itself.
+func recordAudit(c *gin.Context, action, targetType, targetID string, before, after interface{}) {
+	if auditService == nil {
+		return
+	}
+
+	actor, _ := utils.GetActorUserID(c)
+	recordAuditAs(c, actor, action, targetType, targetID, before, after)
+}
+
+func recordAuditAs(c *gin.Context, actor, action, targetType, targetID string, before, after interface{}) {
+	if auditService == nil {
+		return
+	}
+
+	entry := &models.AuditLog{
+		ActorAuth0UserID: actor,
+		Action:           action,
+		TargetType:       targetType,
+		TargetID:         targetID,
+		Before:           auditState(before),
+		After:            auditState(after),
+		IPAddress:        c.ClientIP(),
+		UserAgent:        c.Request.UserAgent(),
+		RequestID:        utils.GetRequestID(c),
+	}
+	if session, ok := utils.GetImpersonation(c); ok {
+		entry.OnBehalfOf = session.TargetAuth0UserID
+	}
+	if err := auditService.Record(entry); err != nil {
+		log.Printf("failed to record audit entry %s on %s/%s: %v", action, targetType, targetID, err)
+	}
+}
+
+func auditState(state interface{}) json.RawMessage {
+	if state == nil {
+		return nil
+	}
+	raw, err := json.Marshal(state)
+	if err != nil || string(raw) == "null" {
+		return nil
+	}
+	return raw
+}
+
+func parseAuditFilter(c *gin.Context) (models.AuditLogFilter, error) {
+	filter := models.AuditLogFilter{
+		Actor:      c.Query("actor"),
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+	}
+
+	if raw := c.Query("from"); raw != "" {
+		from, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, err
+		}
+		filter.From = &from
+	}
+	if raw := c.Query("to"); raw != "" {
+		to, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, err
+		}
+		filter.To = &to
+	}
+
+	return filter, nil
+}
+
+// @Summary List audit log
+// @Description List audit log entries, newest first, with optional filters (superadmin only)
+// @Tags admin
+// @Produce json
+// @Param actor query string false "Filter by actor Auth0 user id"
+// @Param action query string false "Filter by action, e.g. user.role_update"
+// @Param target_type query string false "Filter by target type (user, post)"
+// @Param target_id query string false "Filter by target id"
+// @Param from query string false "Only entries at or after this RFC3339 time"
+// @Param to query string false "Only entries before this RFC3339 time"
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 50, max 200)"
+// @Success 200 {object} models.AuditLogPage
+// @Failure 400 {object} object "Bad request"
+// @Failure 500 {object} object "Internal server error"
+// @Router /admin/audit [get]
+func ListAuditLogs(c *gin.Context) {
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to must be RFC3339 timestamps"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.Query("page_size"))
+
+	result, err := auditService.List(filter, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list audit log"})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// csvSafeRow quotes cells that spreadsheets would run as formulas. Several
+// come from clients, such as the user agent and target IDs.
+func csvSafeRow(cells ...string) []string {
+	for i, cell := range cells {
+		if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+			cells[i] = "'" + cell
+		}
+	}
+	return cells
+}
+
+// @Summary Export audit log
+// @Description Export filtered audit log entries as CSV (superadmin only)
+// @Tags admin
+// @Produce text/csv
+// @Param actor query string false "Filter by actor Auth0 user id"
+// @Param action query string false "Filter by action"
+// @Param target_type query string false "Filter by target type"
+// @Param target_id query string false "Filter by target id"
+// @Param from query string false "Only entries at or after this RFC3339 time"
+// @Param to query string false "Only entries before this RFC3339 time"
+// @Success 200 {string} string "CSV file"
+// @Failure 400 {object} object "Bad request"
+// @Failure 500 {object} object "Internal server error"
+// @Router /admin/audit/export [get]
+func ExportAuditLogs(c *gin.Context) {
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to must be RFC3339 timestamps"})
+		return
+	}
+
+	entries, err := auditService.Export(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export audit log"})
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="audit-log.csv"`)
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write([]string{"id", "created_at", "actor", "on_behalf_of", "action", "target_type", "target_id", "before", "after", "ip_address", "user_agent", "request_id"})
+	for _, entry := range entries {
+		_ = w.Write(csvSafeRow(
+			strconv.FormatInt(entry.ID, 10),
+			entry.CreatedAt.UTC().Format(time.RFC3339),
+			entry.ActorAuth0UserID,
+			entry.OnBehalfOf,
+			entry.Action,
+			entry.TargetType,
+			entry.TargetID,
+			string(entry.Before),
+			string(entry.After),
+			entry.IPAddress,
+			entry.UserAgent,
+			entry.RequestID,
+		))
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.Printf("failed to write audit export: %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/dat1010/go-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAuditService struct {
+	RecordFunc func(entry *models.AuditLog) error
+	ListFunc   func(filter models.AuditLogFilter, page, pageSize int) (*models.AuditLogPage, error)
+	ExportFunc func(filter models.AuditLogFilter) ([]models.AuditLog, error)
+}
+
+func (m *mockAuditService) Record(entry *models.AuditLog) error {
+	if m.RecordFunc != nil {
+		return m.RecordFunc(entry)
+	}
+	return nil
+}
+
+func (m *mockAuditService) List(filter models.AuditLogFilter, page, pageSize int) (*models.AuditLogPage, error) {
+	if m.ListFunc != nil {
+		return m.ListFunc(filter, page, pageSize)
+	}
+	return &models.AuditLogPage{}, nil
+}
+
+func (m *mockAuditService) Export(filter models.AuditLogFilter) ([]models.AuditLog, error) {
+	if m.ExportFunc != nil {
+		return m.ExportFunc(filter)
+	}
+	return nil, nil
+}
+
+func TestCreatePost_RecordsAudit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var recorded *models.AuditLog
+	auditService = &mockAuditService{
+		RecordFunc: func(entry *models.AuditLog) error {
+			recorded = entry
+			return nil
+		},
+	}
+	defer func() { auditService = nil }()
+
+	postService = &mockPostService{
+		CreatePostFunc: func(req *models.CreatePostRequest, auth0UserID string) (*models.Post, error) {
+			return &models.Post{ID: "post-1", Title: req.Title, Content: req.Content, Auth0UserID: auth0UserID}, nil
+		},
+	}
+
+	r := gin.Default()
+	r.POST("/posts", func(c *gin.Context) {
+		c.Set("user", validator.RegisteredClaims{Subject: "auth0|testuser"})
+		c.Set("request_id", "req-123")
+		CreatePost(c)
+	})
+
+	jsonBody, _ := json.Marshal(models.CreatePostRequest{Title: "Title", Content: "Content"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/posts", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "audit-test")
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	if assert.NotNil(t, recorded) {
+		assert.Equal(t, "auth0|testuser", recorded.ActorAuth0UserID)
+		assert.Equal(t, models.AuditActionPostCreate, recorded.Action)
+		assert.Equal(t, models.AuditTargetPost, recorded.TargetType)
+		assert.Equal(t, "post-1", recorded.TargetID)
+		assert.Equal(t, "audit-test", recorded.UserAgent)
+		assert.Equal(t, "req-123", recorded.RequestID)
+		assert.Nil(t, recorded.Before)
+		assert.Contains(t, string(recorded.After), `"title":"Title"`)
+	}
+}
+
+func TestListAuditLogs_InvalidTimeRange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	auditService = &mockAuditService{}
+	defer func() { auditService = nil }()
+
+	r := gin.Default()
+	r.GET("/admin/audit", ListAuditLogs)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/admin/audit?from=yesterday", http.NoBody)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestListAuditLogs_PassesFilters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var gotFilter models.AuditLogFilter
+	var gotPage, gotPageSize int
+	auditService = &mockAuditService{
+		ListFunc: func(filter models.AuditLogFilter, page, pageSize int) (*models.AuditLogPage, error) {
+			gotFilter, gotPage, gotPageSize = filter, page, pageSize
+			return &models.AuditLogPage{Items: []models.AuditLog{}, Total: 0, Page: page, PageSize: pageSize}, nil
+		},
+	}
+	defer func() { auditService = nil }()
+
+	r := gin.Default()
+	r.GET("/admin/audit", ListAuditLogs)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/admin/audit?action=user.delete&actor=auth0|admin&from=2024-01-01T00:00:00Z&page=2&page_size=10", http.NoBody)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "user.delete", gotFilter.Action)
+	assert.Equal(t, "auth0|admin", gotFilter.Actor)
+	assert.NotNil(t, gotFilter.From)
+	assert.Nil(t, gotFilter.To)
+	assert.Equal(t, 2, gotPage)
+	assert.Equal(t, 10, gotPageSize)
+}
+
+func TestExportAuditLogs_WritesCSV(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	auditService = &mockAuditService{
+		ExportFunc: func(filter models.AuditLogFilter) ([]models.AuditLog, error) {
+			return []models.AuditLog{{
+				ID:               7,
+				ActorAuth0UserID: "auth0|admin",
+				Action:           models.AuditActionUserRoleUpdate,
+				TargetType:       models.AuditTargetUser,
+				TargetID:         "auth0|member",
+				Before:           json.RawMessage(`{"role":"member"}`),
+				After:            json.RawMessage(`{"role":"superadmin"}`),
+			}}, nil
+		},
+	}
+	defer func() { auditService = nil }()
+
+	r := gin.Default()
+	r.GET("/admin/audit/export", ExportAuditLogs)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/admin/audit/export", http.NoBody)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv"))
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[1], "user.role_update")
+	assert.Contains(t, lines[1], `"{""role"":""member""}"`)
+}
+
+func TestExportAuditLogs_NeutralizesFormulas(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	auditService = &mockAuditService{
+		ExportFunc: func(filter models.AuditLogFilter) ([]models.AuditLog, error) {
+			return []models.AuditLog{{
+				ID:         8,
+				Action:     models.AuditActionUserDelete,
+				TargetType: models.AuditTargetUser,
+				TargetID:   "=HYPERLINK(\"http://evil\")",
+				UserAgent:  "@SUM(A1)",
+				IPAddress:  "-1+1",
+			}}, nil
+		},
+	}
+	defer func() { auditService = nil }()
+
+	r := gin.Default()
+	r.GET("/admin/audit/export", ExportAuditLogs)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/admin/audit/export", http.NoBody)
+	r.ServeHTTP(w, req)
+
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	assert.NoError(t, err)
+	if assert.Len(t, rows, 2) {
+		assert.Equal(t, `'=HYPERLINK("http://evil")`, rows[1][6])
+		assert.Equal(t, "'-1+1", rows[1][9])
+		assert.Equal(t, "'@SUM(A1)", rows[1][10])
+		assert.Equal(t, models.AuditActionUserDelete, rows[1][4])
+	}
+}
@@ -2,13 +2,15 @@ package controllers
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
-	"os"
 	"strings"
 
+	"github.com/dat1010/go-api/config"
+	"github.com/dat1010/go-api/models"
 	"github.com/dat1010/go-api/utils"
 	"github.com/gin-gonic/gin"
 )
@@ -24,26 +26,44 @@ type TokenResponse struct {
 const (
 	accessTokenCookie  = "access_token"
 	refreshTokenCookie = "refresh_token"
+	inviteTokenCookie  = "invite_token"
 )
 
-func cookieDomain(c *gin.Context) string {
-	domain := strings.TrimSpace(os.Getenv("AUTH0_COOKIE_DOMAIN"))
-	if domain != "" {
-		return domain
-	}
-	return ""
+var authConfig config.Auth0Config
+
+func SetAuthConfig(cfg config.Auth0Config) {
+	authConfig = cfg
+}
+
+// idTokenClaims holds the subset of OIDC ID token claims the API relies on.
+type idTokenClaims struct {
+	Subject       string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
 }
 
-func cookieSecure() bool {
-	secure := strings.TrimSpace(os.Getenv("AUTH0_COOKIE_SECURE"))
-	if secure == "" {
-		return true
+// parseIDToken decodes the claims of an ID token received directly from the
+// Auth0 token endpoint. The signature is not checked: the token came over a
+// TLS back-channel from the issuer, which OIDC Core 3.1.3.7 accepts in lieu
+// of signature validation. Never use this for tokens supplied by clients.
+func parseIDToken(idToken string) (idTokenClaims, error) {
+	var claims idTokenClaims
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return claims, fmt.Errorf("malformed id token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return claims, fmt.Errorf("failed to decode id token payload: %w", err)
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return claims, fmt.Errorf("failed to parse id token claims: %w", err)
 	}
-	return strings.EqualFold(secure, "true") || secure == "1"
+	return claims, nil
 }
 
 func cookieSameSite(c *gin.Context) {
-	switch strings.ToLower(strings.TrimSpace(os.Getenv("AUTH0_COOKIE_SAMESITE"))) {
+	switch strings.ToLower(authConfig.Cookie.SameSite) {
 	case "strict":
 		c.SetSameSite(http.SameSiteStrictMode)
 	case "none":
@@ -55,7 +75,7 @@ func cookieSameSite(c *gin.Context) {
 
 func setTokenCookie(c *gin.Context, name, value string, maxAge int) {
 	cookieSameSite(c)
-	c.SetCookie(name, value, maxAge, "/", cookieDomain(c), cookieSecure(), true)
+	c.SetCookie(name, value, maxAge, "/", authConfig.Cookie.Domain, authConfig.Cookie.Secure, true)
 }
 
 // @Summary Redirect to Auth0 login page
@@ -65,10 +85,10 @@ func setTokenCookie(c *gin.Context, name, value string, maxAge int) {
 // @Success 307 {string} string "Redirect to Auth0"
 // @Router /login [get]
 func Login(c *gin.Context) {
-	domain := os.Getenv("AUTH0_DOMAIN")
-	clientID := os.Getenv("AUTH0_CLIENT_ID")
-	redirectURI := os.Getenv("AUTH0_CALLBACK_URL")
-	audience := os.Getenv("AUTH0_AUDIENCE")
+	domain := authConfig.Domain
+	clientID := authConfig.ClientID
+	redirectURI := authConfig.CallbackURL
+	audience := authConfig.Audience
 	// TODO: generate & verify a real state in production
 	state := "example-state"
 
@@ -96,16 +116,13 @@ func Login(c *gin.Context) {
 // @Router /callback [get]
 func Callback(c *gin.Context) {
 	code := c.Query("code")
-	domain := os.Getenv("AUTH0_DOMAIN")
-	clientID := os.Getenv("AUTH0_CLIENT_ID")
-	clientSecret := os.Getenv("AUTH0_CLIENT_SECRET")
-	redirectURI := os.Getenv("AUTH0_CALLBACK_URL")
-	scheme := os.Getenv("AUTH0_SCHEME")
-	if scheme == "" {
-		scheme = "https"
-	}
+	domain := authConfig.Domain
+	clientID := authConfig.ClientID
+	clientSecret := authConfig.ClientSecret
+	redirectURI := authConfig.CallbackURL
+	scheme := authConfig.Scheme
 	if clientID == "" || clientSecret == "" || domain == "" || redirectURI == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "auth env vars not set"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "auth is not configured"})
 		return
 	}
 
@@ -154,6 +171,11 @@ func Callback(c *gin.Context) {
 		setTokenCookie(c, refreshTokenCookie, tr.RefreshToken, 60*60*24*30)
 	}
 
+	if claims, err := parseIDToken(tr.IDToken); err == nil {
+		recordAuditAs(c, claims.Subject, models.AuditActionLogin, models.AuditTargetUser, claims.Subject, nil, nil)
+		redeemPendingInvitation(c, claims)
+	}
+
 	// Redirect to frontend
 	c.Redirect(http.StatusTemporaryRedirect, "https://nofeed.zone")
 }
@@ -165,17 +187,17 @@ func Callback(c *gin.Context) {
 // @Success 307 {string} string "Redirect to Auth0 logout"
 // @Router /logout [get]
 func Logout(c *gin.Context) {
-	domain := os.Getenv("AUTH0_DOMAIN")
-	clientID := os.Getenv("AUTH0_CLIENT_ID")
-	returnTo := os.Getenv("AUTH0_LOGOUT_RETURN_URL")
+	domain := authConfig.Domain
+	clientID := authConfig.ClientID
+	returnTo := authConfig.LogoutReturnURL
 
 	// Clear the authentication cookie
 	setTokenCookie(c, accessTokenCookie, "", -1)
 	setTokenCookie(c, refreshTokenCookie, "", -1)
 
-	// Validate required env vars
+	// Validate required settings
 	if domain == "" || clientID == "" || returnTo == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "logout env vars not set"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "logout is not configured"})
 		return
 	}
 
@@ -214,12 +236,18 @@ func CheckAuth(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"authenticated": true,
 		"user_id":       auth0UserID,
 		"role":          role,
 		"message":       "User is authenticated",
-	})
+	}
+	if session, ok := utils.GetImpersonation(c); ok {
+		response["impersonated_by"] = session.ActorAuth0UserID
+		response["impersonation_expires_at"] = session.ExpiresAt
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 // @Summary Refresh access token
@@ -237,15 +265,12 @@ func Refresh(c *gin.Context) {
 		return
 	}
 
-	domain := os.Getenv("AUTH0_DOMAIN")
-	clientID := os.Getenv("AUTH0_CLIENT_ID")
-	clientSecret := os.Getenv("AUTH0_CLIENT_SECRET")
-	scheme := os.Getenv("AUTH0_SCHEME")
-	if scheme == "" {
-		scheme = "https"
-	}
+	domain := authConfig.Domain
+	clientID := authConfig.ClientID
+	clientSecret := authConfig.ClientSecret
+	scheme := authConfig.Scheme
 	if clientID == "" || clientSecret == "" || domain == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "auth env vars not set"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "auth is not configured"})
 		return
 	}
 
@@ -3,19 +3,22 @@ package controllers
 import (
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"strings"
 	"testing"
 
 	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/dat1010/go-api/config"
 	"github.com/gin-gonic/gin"
 )
 
 func TestLoginRedirect(t *testing.T) {
-	os.Setenv("AUTH0_DOMAIN", "dev-abcd1234.us.auth0.com")
-	os.Setenv("AUTH0_CLIENT_ID", "myclientid")
-	os.Setenv("AUTH0_CALLBACK_URL", "http://localhost:8080/api/callback")
-	os.Setenv("AUTH0_AUDIENCE", "https://api.example.com")
+	SetAuthConfig(config.Auth0Config{
+		Domain:      "dev-abcd1234.us.auth0.com",
+		ClientID:    "myclientid",
+		CallbackURL: "http://localhost:8080/api/callback",
+		Audience:    "https://api.example.com",
+	})
+	defer SetAuthConfig(config.Auth0Config{})
 
 	w := httptest.NewRecorder()
 	req := httptest.NewRequest("GET", "/login", nil)
@@ -45,12 +48,14 @@ func TestCallbackReturnsToken(t *testing.T) {
 
 	// point domain at our test server (strip scheme)
 	host := strings.TrimPrefix(ts.URL, "http://")
-	os.Setenv("AUTH0_DOMAIN", host)
-	os.Setenv("AUTH0_CLIENT_ID", "id")
-	os.Setenv("AUTH0_CLIENT_SECRET", "secret")
-	os.Setenv("AUTH0_CALLBACK_URL", "http://localhost:8080/api/callback")
-	os.Setenv("AUTH0_SCHEME", "http")
-	os.Setenv("AUTH0_COOKIE_DOMAIN", "")
+	SetAuthConfig(config.Auth0Config{
+		Domain:       host,
+		ClientID:     "id",
+		ClientSecret: "secret",
+		CallbackURL:  "http://localhost:8080/api/callback",
+		Scheme:       "http",
+	})
+	defer SetAuthConfig(config.Auth0Config{})
 
 	req := httptest.NewRequest("GET", "/callback?code=foo", nil)
 	w := httptest.NewRecorder()
@@ -78,11 +83,13 @@ func TestRefreshReturnsToken(t *testing.T) {
 
 	// point domain at our test server (strip scheme)
 	host := strings.TrimPrefix(ts.URL, "http://")
-	os.Setenv("AUTH0_DOMAIN", host)
-	os.Setenv("AUTH0_CLIENT_ID", "id")
-	os.Setenv("AUTH0_CLIENT_SECRET", "secret")
-	os.Setenv("AUTH0_SCHEME", "http")
-	os.Setenv("AUTH0_COOKIE_DOMAIN", "")
+	SetAuthConfig(config.Auth0Config{
+		Domain:       host,
+		ClientID:     "id",
+		ClientSecret: "secret",
+		Scheme:       "http",
+	})
+	defer SetAuthConfig(config.Auth0Config{})
 
 	req := httptest.NewRequest("POST", "/refresh", nil)
 	req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "R"})
@@ -2,12 +2,29 @@ package controllers
 
 import (
 	"net/http"
+	"sync"
+	"time"
 
+	"github.com/dat1010/go-api/notify"
 	"github.com/gin-gonic/gin"
 )
 
-type discordWebhookPayload struct {
-	Content string `json:"content"`
+// discordPingInterval is how often the public ping may post to the webhook.
+const discordPingInterval = 10 * time.Second
+
+var (
+	discordNotifier   notify.Notifier
+	discordWebhookURL string
+
+	discordPingMu   sync.Mutex
+	lastDiscordPing time.Time
+)
+
+// SetDiscordWebhook sets the operator webhook PingDiscord posts to; an empty
+// URL disables the ping.
+func SetDiscordWebhook(notifier notify.Notifier, webhookURL string) {
+	discordNotifier = notifier
+	discordWebhookURL = webhookURL
 }
 
 // DiscordPingResponse represents a successful ping response.
@@ -18,32 +35,37 @@ type DiscordPingResponse struct {
 
 // PingDiscord godoc
 // @Summary      Ping Discord webhook
-// @Description  Send a test notification to Discord for user tannerd
+// @Description  Send a test notification to the operator's Discord webhook, at most once every 10 seconds
 // @Tags         notifications
 // @Produce      json
 // @Success      200  {object}  DiscordPingResponse
-// @Failure      500  {object}  map[string]string
+// @Failure      429  {object}  map[string]string
+// @Failure      502  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
 // @Router       /discord-ping [get]
 func PingDiscord(c *gin.Context) {
-	html := `<!doctype html>
-<html lang="en">
-<head>
-  <meta charset="UTF-8">
-  <title>Ping Sent</title>
-  <style>
-    body { font-family: Arial, sans-serif; background: #f7f7f7; color: #222; padding: 40px; }
-    .card { max-width: 420px; margin: 0 auto; background: #fff; border-radius: 8px; padding: 24px; box-shadow: 0 4px 12px rgba(0,0,0,0.08); }
-    h1 { margin-top: 0; font-size: 22px; }
-    p { margin: 12px 0 0; line-height: 1.5; }
-  </style>
-</head>
-<body>
-  <div class="card">
-    <h1>Ping Sent</h1>
-    <p>This feature is no longer available.</p>
-    <p>This feature is no longer available.</p>
-  </div>
-</body>
-</html>`
-	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(html))
+	if discordNotifier == nil || discordWebhookURL == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Discord webhook is not configured"})
+		return
+	}
+
+	discordPingMu.Lock()
+	if time.Since(lastDiscordPing) < discordPingInterval {
+		discordPingMu.Unlock()
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "a ping was sent moments ago, try again shortly"})
+		return
+	}
+	lastDiscordPing = time.Now()
+	discordPingMu.Unlock()
+
+	err := discordNotifier.Notify(c.Request.Context(), discordWebhookURL, notify.Message{
+		Kind:  "ping",
+		Title: "Ping",
+		Text:  "Ping from the API at " + time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, DiscordPingResponse{Message: "Ping sent", DiscordStatus: "delivered"})
 }
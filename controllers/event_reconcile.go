@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+var eventReconciler services.EventReconciler
+
+func SetEventReconciler(r services.EventReconciler) {
+	eventReconciler = r
+}
+
+// @Summary Reconcile events with the scheduler
+// @Description Compare stored events with the rules the scheduler backend holds and report drift: missing rules, mismatched rules and orphaned rules with no event. With no body nothing is changed (superadmin only).
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param body body models.ReconcileRequest false "Repairs to apply"
+// @Success 200 {object} models.DriftReport
+// @Failure 400 {object} object "Bad request"
+// @Failure 500 {object} object "Internal server error"
+// @Router /admin/events/reconcile [post]
+func ReconcileEvents(c *gin.Context) {
+	var req models.ReconcileRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	report, err := eventReconciler.Reconcile(c.Request.Context(), req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidReconcileRequest) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reconcile events"})
+		return
+	}
+
+	if req.Recreate || req.Orphans != models.OrphanReport {
+		recordAudit(c, models.AuditActionEventsReconcile, models.AuditTargetEvent, "", req, report.Drift)
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/services"
+	"github.com/dat1010/go-api/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// maxRunCallbackBody bounds callback bodies; larger error or output text is truncated anyway.
+const maxRunCallbackBody = 64 << 10
+
+var eventRunService services.EventRunService
+
+func SetEventRunService(s services.EventRunService) {
+	eventRunService = s
+}
+
+// @Summary Report an event run
+// @Description Called by event targets to report that a run started, succeeded or failed. The body must be signed: X-Event-Timestamp carries Unix seconds and X-Event-Signature the hex HMAC-SHA256 of "<timestamp>.<body>" under the shared callback secret.
+// @Tags events
+// @Accept json
+// @Param X-Event-Timestamp header string true "Unix time the callback was signed"
+// @Param X-Event-Signature header string true "Hex HMAC-SHA256 signature"
+// @Param body body models.EventRunReport true "Run report"
+// @Success 204 "Recorded"
+// @Failure 400 {object} object "Bad request"
+// @Failure 401 {object} object "Invalid signature"
+// @Failure 404 {object} object "Event not found"
+// @Failure 503 {object} object "Callbacks not configured"
+// @Router /callbacks/event-runs [post]
+func ReportEventRun(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxRunCallbackBody+1))
+	if err != nil || len(body) > maxRunCallbackBody {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	if err := eventRunService.VerifyCallback(body, c.GetHeader("X-Event-Timestamp"), c.GetHeader("X-Event-Signature")); err != nil {
+		respondEventRunError(c, err)
+		return
+	}
+
+	var report models.EventRunReport
+	if err := json.Unmarshal(body, &report); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+	if err := eventRunService.RecordRun(&report); err != nil {
+		respondEventRunError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary List event runs
+// @Description List the reported runs of one of the authenticated user's events, newest first
+// @Tags events
+// @Produce json
+// @Param name path string true "Event name"
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Success 200 {object} models.EventRunPage
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "Event not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /events/{name}/runs [get]
+func ListEventRuns(c *gin.Context) {
+	auth0UserID, ok := utils.GetAuth0UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.Query("page_size"))
+
+	runs, err := eventRunService.ListRuns(auth0UserID, c.Param("name"), page, pageSize)
+	if err != nil {
+		respondEventRunError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, runs)
+}
+
+func respondEventRunError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidRunReport):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidRunSignature):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrEventNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrRunCallbacksDisabled):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process event runs"})
+	}
+}
@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockEventRunService struct {
+	services.EventRunService
+	VerifyCallbackFunc func(body []byte, timestamp, signature string) error
+	RecordRunFunc      func(report *models.EventRunReport) error
+}
+
+func (m *mockEventRunService) VerifyCallback(body []byte, timestamp, signature string) error {
+	return m.VerifyCallbackFunc(body, timestamp, signature)
+}
+
+func (m *mockEventRunService) RecordRun(report *models.EventRunReport) error {
+	return m.RecordRunFunc(report)
+}
+
+func reportEventRunRequest(body, signature string) *httptest.ResponseRecorder {
+	r := gin.Default()
+	r.POST("/callbacks/event-runs", ReportEventRun)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/callbacks/event-runs", bytes.NewBufferString(body))
+	req.Header.Set("X-Event-Timestamp", strconv.FormatInt(time.Now().Unix(), 10))
+	req.Header.Set("X-Event-Signature", signature)
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestReportEventRun_Recorded(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var got *models.EventRunReport
+	eventRunService = &mockEventRunService{
+		VerifyCallbackFunc: func(body []byte, timestamp, signature string) error { return nil },
+		RecordRunFunc: func(report *models.EventRunReport) error {
+			got = report
+			return nil
+		},
+	}
+	defer func() { eventRunService = nil }()
+
+	w := reportEventRunRequest(`{"rule_name":"go-api-x-1","run_id":"run-1","status":"succeeded","output":"ok"}`, "sig")
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	if assert.NotNil(t, got) {
+		assert.Equal(t, "run-1", got.RunID)
+		assert.Equal(t, "ok", got.Output)
+	}
+}
+
+func TestReportEventRun_BadSignature(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recorded := false
+	eventRunService = &mockEventRunService{
+		VerifyCallbackFunc: func(body []byte, timestamp, signature string) error { return services.ErrInvalidRunSignature },
+		RecordRunFunc: func(report *models.EventRunReport) error {
+			recorded = true
+			return nil
+		},
+	}
+	defer func() { eventRunService = nil }()
+
+	w := reportEventRunRequest(`{"rule_name":"go-api-x-1","run_id":"run-1","status":"succeeded"}`, "forged")
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.False(t, recorded)
+}
@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/scheduler"
+	"github.com/dat1010/go-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+var eventTargetService services.EventTargetService
+
+func SetEventTargetService(s services.EventTargetService) {
+	eventTargetService = s
+}
+
+// @Summary List event targets
+// @Description List the targets scheduled events can be sent to
+// @Tags events
+// @Produce json
+// @Success 200 {array} models.EventTargetSummary
+// @Failure 500 {object} object "Internal server error"
+// @Router /event-targets [get]
+func ListEventTargets(c *gin.Context) {
+	targets, err := eventTargetService.ListTargetSummaries()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list event targets"})
+		return
+	}
+	c.JSON(http.StatusOK, targets)
+}
+
+// @Summary List event targets with their ARNs
+// @Description List registered event targets in full (superadmin only)
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.EventTarget
+// @Failure 500 {object} object "Internal server error"
+// @Router /admin/event-targets [get]
+func AdminListEventTargets(c *gin.Context) {
+	targets, err := eventTargetService.ListTargets()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list event targets"})
+		return
+	}
+	c.JSON(http.StatusOK, targets)
+}
+
+// @Summary Register event target
+// @Description Register a Lambda, SQS queue, SNS topic or API destination that events can be sent to (superadmin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param body body models.CreateEventTargetRequest true "Target payload"
+// @Success 201 {object} models.EventTarget
+// @Failure 400 {object} object "Bad request"
+// @Failure 409 {object} object "Target already exists"
+// @Failure 500 {object} object "Internal server error"
+// @Router /admin/event-targets [post]
+func CreateEventTarget(c *gin.Context) {
+	var req models.CreateEventTargetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	target, err := eventTargetService.CreateTarget(&req)
+	if err != nil {
+		respondEventTargetError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionEventTargetCreate, models.AuditTargetEventTarget, target.Name, nil, target)
+	c.JSON(http.StatusCreated, target)
+}
+
+// @Summary Delete event target
+// @Description Remove a registered target that no event uses (superadmin only)
+// @Tags admin
+// @Param name path string true "Target name"
+// @Success 204 "Deleted"
+// @Failure 404 {object} object "Target not found"
+// @Failure 409 {object} object "Target is in use"
+// @Failure 500 {object} object "Internal server error"
+// @Router /admin/event-targets/{name} [delete]
+func DeleteEventTarget(c *gin.Context) {
+	name := c.Param("name")
+	if err := eventTargetService.DeleteTarget(name); err != nil {
+		respondEventTargetError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionEventTargetDelete, models.AuditTargetEventTarget, name, nil, nil)
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary List event target schemas
+// @Description List the JSON Schemas event payloads must match for each kind of target
+// @Tags events
+// @Produce json
+// @Success 200 {array} models.EventTargetSchema
+// @Failure 500 {object} object "Internal server error"
+// @Router /event-target-schemas [get]
+func ListEventTargetSchemas(c *gin.Context) {
+	schemas, err := eventTargetService.ListSchemas()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list event target schemas"})
+		return
+	}
+	c.JSON(http.StatusOK, schemas)
+}
+
+// @Summary Register event target schema
+// @Description Set the JSON Schema payloads must match to be sent to targets of a kind (superadmin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param kind path string true "Target kind (lambda, sqs, sns or api_destination)"
+// @Param body body models.PutEventTargetSchemaRequest true "Schema"
+// @Success 200 {object} models.EventTargetSchema
+// @Failure 400 {object} object "Bad request"
+// @Failure 500 {object} object "Internal server error"
+// @Router /admin/event-target-schemas/{kind} [put]
+func PutEventTargetSchema(c *gin.Context) {
+	var req models.PutEventTargetSchemaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	schema, err := eventTargetService.PutSchema(c.Param("kind"), &req)
+	if err != nil {
+		respondEventTargetError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionEventSchemaPut, models.AuditTargetEventSchema, schema.Kind, nil, schema)
+	c.JSON(http.StatusOK, schema)
+}
+
+// @Summary Delete event target schema
+// @Description Stop checking payloads sent to targets of a kind (superadmin only)
+// @Tags admin
+// @Param kind path string true "Target kind"
+// @Success 204 "Deleted"
+// @Failure 404 {object} object "No schema for the kind"
+// @Failure 500 {object} object "Internal server error"
+// @Router /admin/event-target-schemas/{kind} [delete]
+func DeleteEventTargetSchema(c *gin.Context) {
+	kind := c.Param("kind")
+	if err := eventTargetService.DeleteSchema(kind); err != nil {
+		respondEventTargetError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionEventSchemaDelete, models.AuditTargetEventSchema, kind, nil, nil)
+	c.Status(http.StatusNoContent)
+}
+
+func respondEventTargetError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidEventTarget),
+		errors.Is(err, services.ErrInvalidEventTargetSchema):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "kinds": scheduler.TargetKinds})
+	case errors.Is(err, services.ErrEventTargetNotFound),
+		errors.Is(err, services.ErrEventTargetSchemaNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrEventTargetExists),
+		errors.Is(err, services.ErrEventTargetInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update event target"})
+	}
+}
@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockEventTargetService struct {
+	services.EventTargetService
+	CreateTargetFunc func(req *models.CreateEventTargetRequest) (*models.EventTarget, error)
+	DeleteTargetFunc func(name string) error
+}
+
+func (m *mockEventTargetService) CreateTarget(req *models.CreateEventTargetRequest) (*models.EventTarget, error) {
+	return m.CreateTargetFunc(req)
+}
+
+func (m *mockEventTargetService) DeleteTarget(name string) error {
+	return m.DeleteTargetFunc(name)
+}
+
+func TestCreateEventTarget_InvalidKind(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	eventTargetService = &mockEventTargetService{
+		CreateTargetFunc: func(req *models.CreateEventTargetRequest) (*models.EventTarget, error) {
+			return nil, fmt.Errorf("%w: kind must be one of lambda, sqs, sns, api_destination", services.ErrInvalidEventTarget)
+		},
+	}
+	defer func() { eventTargetService = nil }()
+
+	r := gin.Default()
+	r.POST("/admin/event-targets", CreateEventTarget)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/admin/event-targets",
+		bytes.NewBufferString(`{"name":"stream","kind":"kinesis","arn":"arn:aws:kinesis:us-east-1:1:stream/s"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"kinds":["lambda","sqs","sns","api_destination"]`)
+}
+
+func TestDeleteEventTarget_InUse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	eventTargetService = &mockEventTargetService{
+		DeleteTargetFunc: func(name string) error { return services.ErrEventTargetInUse },
+	}
+	defer func() { eventTargetService = nil }()
+
+	r := gin.Default()
+	r.DELETE("/admin/event-targets/:name", DeleteEventTarget)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/admin/event-targets/orders", http.NoBody)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
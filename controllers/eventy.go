@@ -1,227 +1,324 @@
 package controllers
 
 import (
-	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
-	"os"
-	"strings"
-	"time"
-
-	"github.com/auth0/go-jwt-middleware/v2/validator"
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
-	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"strconv"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/scheduler"
+	"github.com/dat1010/go-api/services"
+	"github.com/dat1010/go-api/utils"
 	"github.com/gin-gonic/gin"
 )
 
-// CreateEventRequest represents the structure for creating a new event
-type CreateEventRequest struct {
-	Name        string            `json:"name" binding:"required" example:"my-scheduled-event"`
-	Description string            `json:"description" example:"A scheduled event that runs daily"`
-	Schedule    string            `json:"schedule" binding:"required" example:"0 12 * * ? *"` // cron expression
-	Payload     map[string]string `json:"payload" example:"{\"key\":\"value\"}"`
-}
+var eventService services.EventService
 
-// Event represents the response structure
-type Event struct {
-	Name        string            `json:"name" example:"my-scheduled-event"`
-	Description string            `json:"description" example:"A scheduled event that runs daily"`
-	Schedule    string            `json:"schedule" example:"0 12 * * ? *"`
-	Payload     map[string]string `json:"payload" example:"{\"key\":\"value\"}"`
-	CreatedAt   time.Time         `json:"created_at" example:"2024-03-20T12:00:00Z"`
+// SetEventService sets the event service for the controllers
+func SetEventService(s services.EventService) {
+	eventService = s
 }
 
 // @Summary Create a new scheduled event
-// @Description Create an AWS EventBridge rule with the provided schedule and payload
+// @Description Create a scheduled event with the provided schedule and payload. Schedules may be cron(...), rate(...) or one-time at(...) expressions, optionally in an IANA time zone, within a start/end window and with a flexible time window; anything beyond recurring UTC schedules needs the EventBridge Scheduler or local backend. Requires the events:create permission and counts towards the user's active event quota.
 // @Tags events
 // @Accept json
 // @Produce json
-// @Param event body controllers.CreateEventRequest true "Event data"
-// @Success 201 {object} controllers.Event "Event created successfully"
+// @Param event body models.CreateEventRequest true "Event data"
+// @Param Idempotency-Key header string false "Retrying with the same key returns the event the first request created"
+// @Param next_runs query int false "Number of upcoming fire times to include (default 5, max 50)"
+// @Param tz query string false "IANA time zone for the upcoming fire times (default: the event's own zone)"
+// @Success 201 {object} models.Event "Event created successfully, or replayed (Idempotent-Replayed: true)"
 // @Failure 400 {object} object "Invalid request data"
+// @Failure 403 {object} object "Missing permission or active event quota reached"
+// @Failure 409 {object} object "Event name already taken"
+// @Failure 413 {object} object "Payload too large for the targets"
+// @Failure 422 {object} object "Idempotency-Key reused with a different request"
 // @Failure 500 {object} object "Internal server error"
 // @Router /events [post]
 func CreateEvent(c *gin.Context) {
-	// Get Auth0 user ID from the JWT claims
-	claims, exists := c.Get("user")
-	if !exists {
+	auth0UserID, ok := utils.GetAuth0UserID(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 
-	// Extract user ID from claims
-	registeredClaims, ok := claims.(validator.RegisteredClaims)
-	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid claims format"})
+	var req models.CreateEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Check if the user has the required Auth0 ID
-	if registeredClaims.Subject != "auth0|68164b4c821b56fdc024b2dd" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	event, replayed, err := eventService.CreateEventIdempotent(c.Request.Context(), auth0UserID, c.GetHeader("Idempotency-Key"), &req)
+	if err != nil {
+		respondEventError(c, err)
 		return
 	}
 
-	var req CreateEventRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if replayed {
+		c.Header("Idempotent-Replayed", "true")
+	}
+	respondWithEvent(c, http.StatusCreated, event)
+}
+
+// @Summary List events for the authenticated user
+// @Description Get a page of the scheduled events created by the authenticated user, newest first
+// @Tags events
+// @Produce json
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Param next_runs query int false "Number of upcoming fire times to include (default 5, max 50)"
+// @Param tz query string false "IANA time zone for the upcoming fire times (default: the event's own zone)"
+// @Success 200 {object} models.EventPage "Page of events"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 500 {object} object "Internal server error"
+// @Router /events [get]
+func ListUserEvents(c *gin.Context) {
+	auth0UserID, ok := utils.GetAuth0UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 
-	// Load AWS configuration
-	cfg, err := config.LoadDefaultConfig(c.Request.Context())
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.Query("page_size"))
+
+	result, err := eventService.ListUserEventPage(auth0UserID, page, pageSize)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("unable to load SDK config: %v", err)})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Create EventBridge client
-	client := eventbridge.NewFromConfig(cfg)
+	if !attachNextRuns(c, result.Items) {
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
 
-	// Add user ID to the payload
-	if req.Payload == nil {
-		req.Payload = make(map[string]string)
+// @Summary Get a scheduled event
+// @Description Get one of the authenticated user's scheduled events by name
+// @Tags events
+// @Produce json
+// @Param name path string true "Event name"
+// @Param next_runs query int false "Number of upcoming fire times to include (default 5, max 50)"
+// @Param tz query string false "IANA time zone for the upcoming fire times (default: the event's own zone)"
+// @Success 200 {object} models.Event "Event"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "Event not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /events/{name} [get]
+func GetEvent(c *gin.Context) {
+	auth0UserID, ok := utils.GetAuth0UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
 	}
-	req.Payload["user_id"] = registeredClaims.Subject
 
-	// Convert payload to JSON string
-	payloadJSON, err := json.Marshal(req.Payload)
+	event, err := eventService.GetEvent(auth0UserID, c.Param("name"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to marshal payload: %v", err)})
+		respondEventError(c, err)
 		return
 	}
 
-	// Create rule input
-	ruleInput := &eventbridge.PutRuleInput{
-		Name:               aws.String(req.Name),
-		Description:        aws.String(req.Description),
-		ScheduleExpression: aws.String(fmt.Sprintf("cron(%s)", req.Schedule)),
-		State:              types.RuleStateEnabled,
-	}
+	respondWithEvent(c, http.StatusOK, event)
+}
 
-	// Create the rule
-	_, err = client.PutRule(c.Request.Context(), ruleInput)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to create rule: %v", err)})
+// @Summary Update a scheduled event
+// @Description Change the description, schedule or payload of one of the authenticated user's events
+// @Tags events
+// @Accept json
+// @Produce json
+// @Param name path string true "Event name"
+// @Param event body models.UpdateEventRequest true "Fields to change"
+// @Param next_runs query int false "Number of upcoming fire times to include (default 5, max 50)"
+// @Param tz query string false "IANA time zone for the upcoming fire times (default: the event's own zone)"
+// @Success 200 {object} models.Event "Updated event"
+// @Failure 400 {object} object "Invalid request data"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "Event not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /events/{name} [put]
+func UpdateEvent(c *gin.Context) {
+	auth0UserID, ok := utils.GetAuth0UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 
-	// Create target input
-	targetInput := &eventbridge.PutTargetsInput{
-		Rule: aws.String(req.Name),
-		Targets: []types.Target{
-			{
-				Id:    aws.String(fmt.Sprintf("%s-target", req.Name)),
-				Arn:   aws.String(os.Getenv("LAMBDA_ARN")),
-				Input: aws.String(string(payloadJSON)),
-			},
-		},
+	var req models.UpdateEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	// Create the target
-	_, err = client.PutTargets(c.Request.Context(), targetInput)
+	event, err := eventService.UpdateEvent(c.Request.Context(), auth0UserID, c.Param("name"), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to create target: %v", err)})
+		respondEventError(c, err)
+		return
+	}
+
+	respondWithEvent(c, http.StatusOK, event)
+}
+
+// @Summary Pause a scheduled event
+// @Description Disable one of the authenticated user's events without deleting it
+// @Tags events
+// @Produce json
+// @Param name path string true "Event name"
+// @Param next_runs query int false "Number of upcoming fire times to include (default 5, max 50)"
+// @Param tz query string false "IANA time zone for the upcoming fire times (default: the event's own zone)"
+// @Success 200 {object} models.Event "Paused event"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "Event not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /events/{name}/pause [post]
+func PauseEvent(c *gin.Context) {
+	auth0UserID, ok := utils.GetAuth0UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 
-	// Create response
-	event := Event{
-		Name:        req.Name,
-		Description: req.Description,
-		Schedule:    req.Schedule,
-		Payload:     req.Payload,
-		CreatedAt:   time.Now(),
+	event, err := eventService.PauseEvent(c.Request.Context(), auth0UserID, c.Param("name"))
+	if err != nil {
+		respondEventError(c, err)
+		return
 	}
 
-	c.JSON(http.StatusCreated, event)
+	respondWithEvent(c, http.StatusOK, event)
 }
 
-// @Summary List events for the authenticated user
-// @Description Get all EventBridge rules created by the authenticated user
+// @Summary Resume a scheduled event
+// @Description Re-enable a paused event owned by the authenticated user
 // @Tags events
 // @Produce json
-// @Success 200 {array} controllers.Event "List of events"
+// @Param name path string true "Event name"
+// @Param next_runs query int false "Number of upcoming fire times to include (default 5, max 50)"
+// @Param tz query string false "IANA time zone for the upcoming fire times (default: the event's own zone)"
+// @Success 200 {object} models.Event "Resumed event"
 // @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "Event not found"
 // @Failure 500 {object} object "Internal server error"
-// @Router /events [get]
-func ListUserEvents(c *gin.Context) {
-	// Get Auth0 user ID from the JWT claims
-	claims, exists := c.Get("user")
-	if !exists {
+// @Failure 403 {object} object "Active event quota reached"
+// @Router /events/{name}/resume [post]
+func ResumeEvent(c *gin.Context) {
+	auth0UserID, ok := utils.GetAuth0UserID(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 
-	// Extract user ID from claims
-	registeredClaims, ok := claims.(validator.RegisteredClaims)
+	event, err := eventService.ResumeEvent(c.Request.Context(), auth0UserID, c.Param("name"))
+	if err != nil {
+		respondEventError(c, err)
+		return
+	}
+
+	respondWithEvent(c, http.StatusOK, event)
+}
+
+// @Summary Delete a scheduled event
+// @Description Remove one of the authenticated user's events and its schedule
+// @Tags events
+// @Param name path string true "Event name"
+// @Success 204 "Event deleted"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "Event not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /events/{name} [delete]
+func DeleteEvent(c *gin.Context) {
+	auth0UserID, ok := utils.GetAuth0UserID(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid claims format"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 
-	// Load AWS configuration
-	cfg, err := config.LoadDefaultConfig(c.Request.Context())
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("unable to load SDK config: %v", err)})
+	if err := eventService.DeleteEvent(c.Request.Context(), auth0UserID, c.Param("name")); err != nil {
+		respondEventError(c, err)
 		return
 	}
 
-	// Create EventBridge client
-	client := eventbridge.NewFromConfig(cfg)
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Preview a schedule expression
+// @Description Validate a cron(...), rate(...) or at(...) expression and list its next fire times in the given time zone without creating anything
+// @Tags events
+// @Accept json
+// @Produce json
+// @Param preview body models.PreviewScheduleRequest true "Expression to preview"
+// @Success 200 {object} models.SchedulePreview "Next fire times"
+// @Failure 400 {object} object "Invalid expression, with the offending fields"
+// @Router /events/preview [post]
+func PreviewEventSchedule(c *gin.Context) {
+	var req models.PreviewScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	// List all rules
-	listRulesInput := &eventbridge.ListRulesInput{}
-	result, err := client.ListRules(c.Request.Context(), listRulesInput)
+	preview, err := eventService.PreviewSchedule(&req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to list rules: %v", err)})
+		respondEventError(c, err)
 		return
 	}
 
-	var userEvents []Event
+	c.JSON(http.StatusOK, preview)
+}
 
-	// For each rule, get its targets to check the payload
-	for _, rule := range result.Rules {
-		// Get targets for the rule
-		listTargetsInput := &eventbridge.ListTargetsByRuleInput{
-			Rule: rule.Name,
-		}
-		targets, err := client.ListTargetsByRule(c.Request.Context(), listTargetsInput)
-		if err != nil {
-			continue // Skip this rule if we can't get its targets
-		}
+func respondWithEvent(c *gin.Context, status int, event *models.Event) {
+	events := []models.Event{*event}
+	if !attachNextRuns(c, events) {
+		return
+	}
+	c.JSON(status, events[0])
+}
 
-		// Check each target's input for the user ID
-		for _, target := range targets.Targets {
-			if target.Input != nil {
-				var payload map[string]string
-				if err := json.Unmarshal([]byte(*target.Input), &payload); err != nil {
-					continue
-				}
-
-				// If the payload contains the user's ID, add this event to the list
-				if userID, exists := payload["user_id"]; exists && userID == registeredClaims.Subject {
-					// Extract schedule expression from the rule
-					schedule := *rule.ScheduleExpression
-					// Remove "cron(" and ")" from the schedule expression
-					schedule = strings.TrimPrefix(schedule, "cron(")
-					schedule = strings.TrimSuffix(schedule, ")")
-
-					event := Event{
-						Name:        *rule.Name,
-						Description: *rule.Description,
-						Schedule:    schedule,
-						Payload:     payload,
-						CreatedAt:   time.Now(),
-					}
-					userEvents = append(userEvents, event)
-					break // Found a matching target, no need to check other targets for this rule
-				}
-			}
+// attachNextRuns applies the next_runs and tz query parameters, responding
+// with 400 and returning false when they are invalid.
+func attachNextRuns(c *gin.Context, events []models.Event) bool {
+	count := 0
+	if raw := c.Query("next_runs"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid next_runs"})
+			return false
 		}
+		count = value
+	}
+	if err := eventService.AttachNextRuns(events, count, c.Query("tz")); err != nil {
+		respondEventError(c, err)
+		return false
 	}
+	return true
+}
 
-	c.JSON(http.StatusOK, userEvents)
+func respondEventError(c *gin.Context, err error) {
+	var exprErr *scheduler.ExpressionError
+	switch {
+	case errors.As(err, &exprErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "fields": exprErr.Fields})
+	case errors.Is(err, scheduler.ErrUnsupported),
+		errors.Is(err, scheduler.ErrInvalidExpression),
+		errors.Is(err, scheduler.ErrInvalidTarget),
+		errors.Is(err, services.ErrUnknownEventTarget),
+		errors.Is(err, services.ErrInvalidPayload),
+		errors.Is(err, services.ErrPayloadRejected),
+		errors.Is(err, services.ErrInvalidIdempotencyKey),
+		errors.Is(err, services.ErrInvalidTimeZone),
+		errors.Is(err, services.ErrInvalidRunCount):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrIdempotencyKeyReused):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, scheduler.ErrInputTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrEventQuotaExceeded):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrEventNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrEventExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
 }
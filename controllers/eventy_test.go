@@ -0,0 +1,378 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/scheduler"
+	"github.com/dat1010/go-api/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockEventService struct {
+	services.EventService
+	CreateEventFunc    func(ctx context.Context, ownerID string, req *models.CreateEventRequest) (*models.Event, error)
+	IdempotentFunc     func(ctx context.Context, ownerID, key string, req *models.CreateEventRequest) (*models.Event, bool, error)
+	ListUserEventsFunc func(ownerID string) ([]models.Event, error)
+	ListPageFunc       func(ownerID string, page, pageSize int) (*models.EventPage, error)
+	GetEventFunc       func(ownerID, name string) (*models.Event, error)
+	PauseEventFunc     func(ctx context.Context, ownerID, name string) (*models.Event, error)
+	DeleteEventFunc    func(ctx context.Context, ownerID, name string) error
+	AttachNextRunsFunc func(events []models.Event, count int, timeZone string) error
+}
+
+func (m *mockEventService) AttachNextRuns(events []models.Event, count int, timeZone string) error {
+	if m.AttachNextRunsFunc != nil {
+		return m.AttachNextRunsFunc(events, count, timeZone)
+	}
+	return nil
+}
+
+func (m *mockEventService) GetEvent(ownerID, name string) (*models.Event, error) {
+	return m.GetEventFunc(ownerID, name)
+}
+
+func (m *mockEventService) PauseEvent(ctx context.Context, ownerID, name string) (*models.Event, error) {
+	return m.PauseEventFunc(ctx, ownerID, name)
+}
+
+func (m *mockEventService) DeleteEvent(ctx context.Context, ownerID, name string) error {
+	return m.DeleteEventFunc(ctx, ownerID, name)
+}
+
+func (m *mockEventService) CreateEvent(ctx context.Context, ownerID string, req *models.CreateEventRequest) (*models.Event, error) {
+	return m.CreateEventFunc(ctx, ownerID, req)
+}
+
+func (m *mockEventService) CreateEventIdempotent(ctx context.Context, ownerID, key string, req *models.CreateEventRequest) (*models.Event, bool, error) {
+	if m.IdempotentFunc != nil {
+		return m.IdempotentFunc(ctx, ownerID, key, req)
+	}
+	event, err := m.CreateEventFunc(ctx, ownerID, req)
+	return event, false, err
+}
+
+func (m *mockEventService) ListUserEvents(ownerID string) ([]models.Event, error) {
+	if m.ListUserEventsFunc != nil {
+		return m.ListUserEventsFunc(ownerID)
+	}
+	return []models.Event{}, nil
+}
+
+func (m *mockEventService) ListUserEventPage(ownerID string, page, pageSize int) (*models.EventPage, error) {
+	if m.ListPageFunc != nil {
+		return m.ListPageFunc(ownerID, page, pageSize)
+	}
+	return &models.EventPage{Items: []models.Event{}, Page: page, PageSize: pageSize}, nil
+}
+
+const eventCreatorID = "auth0|event-creator"
+
+func createEventRequest(t *testing.T, body models.CreateEventRequest) *httptest.ResponseRecorder {
+	t.Helper()
+	r := gin.Default()
+	r.POST("/events", func(c *gin.Context) {
+		c.Set("user", validator.RegisteredClaims{Subject: eventCreatorID})
+		CreateEvent(c)
+	})
+
+	jsonBody, _ := json.Marshal(body)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/events", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateEvent_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var gotOwner string
+	eventService = &mockEventService{
+		CreateEventFunc: func(ctx context.Context, ownerID string, req *models.CreateEventRequest) (*models.Event, error) {
+			gotOwner = ownerID
+			return &models.Event{
+				ID:       "event-1",
+				OwnerID:  ownerID,
+				Name:     req.Name,
+				Schedule: req.Schedule,
+				Payload:  models.JSONObject(req.Payload),
+				State:    models.EventStateEnabled,
+			}, nil
+		},
+	}
+	defer func() { eventService = nil }()
+
+	w := createEventRequest(t, models.CreateEventRequest{
+		Name:     "daily",
+		Schedule: "0 12 * * ? *",
+		Payload:  json.RawMessage(`{"order":{"id":7,"items":["a"]}}`),
+	})
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, eventCreatorID, gotOwner)
+	var resp models.Event
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "event-1", resp.ID)
+	assert.JSONEq(t, `{"order":{"id":7,"items":["a"]}}`, string(resp.Payload))
+	assert.Equal(t, models.EventStateEnabled, resp.State)
+}
+
+func TestCreateEvent_InvalidExpression(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	eventService = &mockEventService{
+		CreateEventFunc: func(ctx context.Context, ownerID string, req *models.CreateEventRequest) (*models.Event, error) {
+			return nil, fmt.Errorf("%w: expected 6 fields, got 5", scheduler.ErrInvalidExpression)
+		},
+	}
+	defer func() { eventService = nil }()
+
+	w := createEventRequest(t, models.CreateEventRequest{Name: "daily", Schedule: "0 12 * * ?"})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCreateEvent_IdempotencyKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var gotKey string
+	eventService = &mockEventService{
+		IdempotentFunc: func(ctx context.Context, ownerID, key string, req *models.CreateEventRequest) (*models.Event, bool, error) {
+			gotKey = key
+			if req.Name == "changed" {
+				return nil, false, services.ErrIdempotencyKeyReused
+			}
+			return &models.Event{ID: "event-1", OwnerID: ownerID, Name: req.Name}, true, nil
+		},
+	}
+	defer func() { eventService = nil }()
+
+	r := gin.New()
+	r.POST("/events", func(c *gin.Context) {
+		c.Set("user", validator.RegisteredClaims{Subject: eventCreatorID})
+		CreateEvent(c)
+	})
+	send := func(name string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/events", bytes.NewBufferString(`{"name":"`+name+`","schedule":"0 12 * * ? *"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", "retry-1")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("daily")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, "retry-1", gotKey)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, send("changed").Code)
+}
+
+func TestCreateEvent_NameTaken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	eventService = &mockEventService{
+		CreateEventFunc: func(ctx context.Context, ownerID string, req *models.CreateEventRequest) (*models.Event, error) {
+			return nil, services.ErrEventExists
+		},
+	}
+	defer func() { eventService = nil }()
+
+	w := createEventRequest(t, models.CreateEventRequest{Name: "daily", Schedule: "0 12 * * ? *"})
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestListUserEvents_OnlyOwner(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var gotOwner string
+	var gotPage, gotPageSize int
+	eventService = &mockEventService{
+		ListPageFunc: func(ownerID string, page, pageSize int) (*models.EventPage, error) {
+			gotOwner, gotPage, gotPageSize = ownerID, page, pageSize
+			return &models.EventPage{Items: []models.Event{{Name: "daily", Schedule: "0 12 * * ? *"}}, Total: 1, Page: page, PageSize: 20}, nil
+		},
+	}
+	defer func() { eventService = nil }()
+
+	r := gin.Default()
+	r.GET("/events", func(c *gin.Context) {
+		c.Set("user", validator.RegisteredClaims{Subject: "auth0|testuser"})
+		ListUserEvents(c)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/events?page=2&page_size=10", http.NoBody)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "auth0|testuser", gotOwner)
+	assert.Equal(t, 2, gotPage)
+	assert.Equal(t, 10, gotPageSize)
+	assert.Contains(t, w.Body.String(), `"items":[{`)
+	assert.Contains(t, w.Body.String(), `"schedule":"0 12 * * ? *"`)
+}
+
+func TestGetEvent_NotOwned(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	eventService = &mockEventService{
+		GetEventFunc: func(ownerID, name string) (*models.Event, error) {
+			return nil, services.ErrEventNotFound
+		},
+	}
+	defer func() { eventService = nil }()
+
+	r := gin.Default()
+	r.GET("/events/:name", func(c *gin.Context) {
+		c.Set("user", validator.RegisteredClaims{Subject: "auth0|someone-else"})
+		GetEvent(c)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/events/daily", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestPauseEvent_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var gotOwner, gotName string
+	eventService = &mockEventService{
+		PauseEventFunc: func(ctx context.Context, ownerID, name string) (*models.Event, error) {
+			gotOwner, gotName = ownerID, name
+			return &models.Event{Name: name, OwnerID: ownerID, State: models.EventStateDisabled}, nil
+		},
+	}
+	defer func() { eventService = nil }()
+
+	r := gin.Default()
+	r.POST("/events/:name/pause", func(c *gin.Context) {
+		c.Set("user", validator.RegisteredClaims{Subject: eventCreatorID})
+		PauseEvent(c)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/events/daily/pause", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, eventCreatorID, gotOwner)
+	assert.Equal(t, "daily", gotName)
+	var event models.Event
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+	assert.Equal(t, models.EventStateDisabled, event.State)
+}
+
+func TestDeleteEvent_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	eventService = &mockEventService{
+		DeleteEventFunc: func(ctx context.Context, ownerID, name string) error {
+			return nil
+		},
+	}
+	defer func() { eventService = nil }()
+
+	r := gin.Default()
+	r.DELETE("/events/:name", func(c *gin.Context) {
+		c.Set("user", validator.RegisteredClaims{Subject: eventCreatorID})
+		DeleteEvent(c)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/events/daily", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestCreateEvent_QuotaReached(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	eventService = &mockEventService{
+		CreateEventFunc: func(ctx context.Context, ownerID string, req *models.CreateEventRequest) (*models.Event, error) {
+			return nil, services.ErrEventQuotaExceeded
+		},
+	}
+	defer func() { eventService = nil }()
+
+	w := createEventRequest(t, models.CreateEventRequest{Name: "daily", Schedule: "0 12 * * ? *"})
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestPreviewEventSchedule_FieldErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	eventService = services.NewEventService(nil, nil, nil, nil, nil)
+	defer func() { eventService = nil }()
+
+	r := gin.Default()
+	r.POST("/events/preview", PreviewEventSchedule)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/events/preview", bytes.NewBufferString(`{"schedule":"cron(61 12 * * * *)"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var body struct {
+		Fields []scheduler.FieldError `json:"fields"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	if assert.Len(t, body.Fields, 2) {
+		assert.Equal(t, "minutes", body.Fields[0].Field)
+		assert.Equal(t, "day-of-week", body.Fields[1].Field)
+	}
+}
+
+func TestPreviewEventSchedule_NextRunsInTimeZone(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	eventService = services.NewEventService(nil, nil, nil, nil, nil)
+	defer func() { eventService = nil }()
+
+	r := gin.Default()
+	r.POST("/events/preview", PreviewEventSchedule)
+
+	w := httptest.NewRecorder()
+	body := `{"schedule":"rate(1 hour)","count":3,"timezone":"Asia/Tokyo"}`
+	req, _ := http.NewRequest("POST", "/events/preview", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var preview models.SchedulePreview
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &preview))
+	assert.Equal(t, "rate(1 hour)", preview.Expression)
+	assert.Equal(t, "Asia/Tokyo", preview.TimeZone)
+	assert.Len(t, preview.NextRuns, 3)
+	assert.Contains(t, w.Body.String(), "+09:00")
+}
+
+func TestListUserEvents_InvalidTimeZone(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	eventService = &mockEventService{
+		AttachNextRunsFunc: func(events []models.Event, count int, timeZone string) error {
+			return services.ErrInvalidTimeZone
+		},
+	}
+	defer func() { eventService = nil }()
+
+	r := gin.Default()
+	r.GET("/events", func(c *gin.Context) {
+		c.Set("user", validator.RegisteredClaims{Subject: eventCreatorID})
+		ListUserEvents(c)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/events?tz=Mars/Olympus", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
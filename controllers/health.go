@@ -2,7 +2,6 @@ package controllers
 
 import (
 	"net/http"
-	"os"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,10 +10,11 @@ type Health struct {
 	Version string `json:"version"`
 }
 
-var (
-	version         = os.Getenv("VERSION")
+var healthCheckData Health
+
+func SetVersion(version string) {
 	healthCheckData = Health{Version: version}
-)
+}
 
 // GetHealthCheck godoc
 // @Summary      Health Check
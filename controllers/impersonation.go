@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/services"
+	"github.com/dat1010/go-api/utils"
+	"github.com/gin-gonic/gin"
+)
+
+var impersonationService services.ImpersonationService
+
+func SetImpersonationService(s services.ImpersonationService) {
+	impersonationService = s
+}
+
+// @Summary Start impersonation
+// @Description Start a short-lived session acting as another user. Send the returned token in the X-Impersonation-Token header (a cookie is also set). Admin routes are unavailable while impersonating (superadmin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param body body models.StartImpersonationRequest true "Impersonation payload"
+// @Success 201 {object} models.StartImpersonationResponse
+// @Failure 400 {object} object "Bad request"
+// @Failure 403 {object} object "Target cannot be impersonated"
+// @Failure 404 {object} object "User not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /admin/impersonate [post]
+func StartImpersonation(c *gin.Context) {
+	var req models.StartImpersonationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	actor, ok := utils.GetActorUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	duration := time.Duration(req.DurationMinutes) * time.Minute
+	session, token, err := impersonationService.StartImpersonation(actor, req.TargetAuth0UserID, req.Reason, duration)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrImpersonationForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start impersonation"})
+		}
+		return
+	}
+
+	recordAudit(c, models.AuditActionImpersonationStart, models.AuditTargetUser, session.TargetAuth0UserID, nil, session)
+	setTokenCookie(c, utils.ImpersonationCookie, token, int(time.Until(session.ExpiresAt).Seconds()))
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, models.StartImpersonationResponse{
+		Session: session,
+		Token:   token,
+	})
+}
+
+// @Summary End impersonation
+// @Description End the current impersonation session
+// @Tags auth
+// @Produce json
+// @Success 200 {object} object "Impersonation ended"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "No active impersonation session"
+// @Failure 500 {object} object "Internal server error"
+// @Router /impersonation [delete]
+func EndImpersonation(c *gin.Context) {
+	actor, ok := utils.GetActorUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	token := utils.GetImpersonationToken(c)
+	setTokenCookie(c, utils.ImpersonationCookie, "", -1)
+	if token == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no active impersonation session"})
+		return
+	}
+
+	session, err := impersonationService.EndImpersonation(token, actor)
+	if err != nil {
+		if errors.Is(err, services.ErrImpersonationInactive) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no active impersonation session"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to end impersonation"})
+		return
+	}
+
+	recordAuditAs(c, actor, models.AuditActionImpersonationEnd, models.AuditTargetUser, session.TargetAuth0UserID, nil, nil)
+	c.JSON(http.StatusOK, gin.H{"ended": true})
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func impersonatingContext(w *httptest.ResponseRecorder, req *http.Request) *gin.Context {
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set("user", validator.RegisteredClaims{Subject: "auth0|admin"})
+	utils.SetImpersonation(c, &models.ImpersonationSession{
+		ID:                "session-1",
+		ActorAuth0UserID:  "auth0|admin",
+		TargetAuth0UserID: "auth0|member",
+		ExpiresAt:         time.Now().Add(10 * time.Minute),
+	})
+	return c
+}
+
+func TestCheckAuth_WhileImpersonating(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := impersonatingContext(w, httptest.NewRequest("GET", "/me", nil))
+
+	CheckAuth(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"user_id":"auth0|member"`)
+	assert.Contains(t, w.Body.String(), `"impersonated_by":"auth0|admin"`)
+}
+
+func TestRecordAudit_KeepsRealActorWhileImpersonating(t *testing.T) {
+	var recorded *models.AuditLog
+	auditService = &mockAuditService{
+		RecordFunc: func(entry *models.AuditLog) error {
+			recorded = entry
+			return nil
+		},
+	}
+	defer func() { auditService = nil }()
+
+	w := httptest.NewRecorder()
+	c := impersonatingContext(w, httptest.NewRequest("DELETE", "/posts/1", nil))
+
+	recordAudit(c, models.AuditActionPostDelete, models.AuditTargetPost, "1", nil, nil)
+
+	if assert.NotNil(t, recorded) {
+		assert.Equal(t, "auth0|admin", recorded.ActorAuth0UserID)
+		assert.Equal(t, "auth0|member", recorded.OnBehalfOf)
+	}
+}
+
+func TestEndImpersonation_WithoutSession(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("DELETE", "/impersonation", nil)
+	c.Set("user", validator.RegisteredClaims{Subject: "auth0|admin"})
+
+	EndImpersonation(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	cleared := false
+	for _, sc := range w.Header().Values("Set-Cookie") {
+		if strings.HasPrefix(sc, utils.ImpersonationCookie+"=;") {
+			cleared = true
+		}
+	}
+	assert.True(t, cleared)
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/services"
+	"github.com/dat1010/go-api/utils"
+	"github.com/gin-gonic/gin"
+)
+
+var invitationService services.InvitationService
+
+func SetInvitationService(s services.InvitationService) {
+	invitationService = s
+}
+
+// inviteCookieMaxAge bounds how long an accepted invite link waits for the Auth0 login to finish.
+const inviteCookieMaxAge = 15 * 60
+
+// @Summary Invite user
+// @Description Invite a user by email with a role and return a single-use invite link (superadmin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param body body models.CreateInvitationRequest true "Invitation payload"
+// @Success 201 {object} models.CreateInvitationResponse
+// @Failure 400 {object} object "Bad request"
+// @Failure 404 {object} object "Role not found"
+// @Failure 500 {object} object "Internal server error"
+// @Failure 503 {object} object "Invitations not configured"
+// @Router /admin/invitations [post]
+func CreateInvitation(c *gin.Context) {
+	var req models.CreateInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	invitedBy, _ := utils.GetAuth0UserID(c)
+	invitation, inviteURL, err := invitationService.CreateInvitation(req.Email, req.Role, invitedBy)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrRoleNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "role not found"})
+		case errors.Is(err, services.ErrInvitationsDisabled):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "invitations are not configured"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create invitation"})
+		}
+		return
+	}
+
+	recordAudit(c, models.AuditActionInviteCreate, models.AuditTargetInvitation, invitation.ID, nil, invitation)
+	c.JSON(http.StatusCreated, models.CreateInvitationResponse{
+		Invitation: invitation,
+		InviteURL:  inviteURL,
+	})
+}
+
+// @Summary List invitations
+// @Description List pending and past invitations (superadmin only)
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.Invitation
+// @Failure 500 {object} object "Internal server error"
+// @Router /admin/invitations [get]
+func ListInvitations(c *gin.Context) {
+	invitations, err := invitationService.ListInvitations()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list invitations"})
+		return
+	}
+	c.JSON(http.StatusOK, invitations)
+}
+
+// @Summary Revoke invitation
+// @Description Revoke a pending invitation (superadmin only)
+// @Tags admin
+// @Produce json
+// @Param id path string true "Invitation id"
+// @Success 200 {object} object "Invitation revoked"
+// @Failure 404 {object} object "No pending invitation"
+// @Failure 500 {object} object "Internal server error"
+// @Router /admin/invitations/{id} [delete]
+func RevokeInvitation(c *gin.Context) {
+	id := c.Param("id")
+	if err := invitationService.RevokeInvitation(id); err != nil {
+		if errors.Is(err, services.ErrInvitationUnavailable) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no pending invitation with that id"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke invitation"})
+		return
+	}
+
+	recordAudit(c, models.AuditActionInviteRevoke, models.AuditTargetInvitation, id, nil, nil)
+	c.JSON(http.StatusOK, gin.H{"revoked": true})
+}
+
+// @Summary Accept invitation
+// @Description Validate an invite link and continue to login; the invite is redeemed when the login completes
+// @Tags auth
+// @Param token query string true "Invite token"
+// @Success 307 {string} string "Redirect to login"
+// @Failure 400 {object} object "Invalid invite"
+// @Router /invitations/accept [get]
+func AcceptInvitation(c *gin.Context) {
+	token := c.Query("token")
+	if invitationService == nil || token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid invitation"})
+		return
+	}
+	if _, err := invitationService.VerifyToken(token); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid invitation"})
+		return
+	}
+
+	setTokenCookie(c, inviteTokenCookie, token, inviteCookieMaxAge)
+	c.Redirect(http.StatusTemporaryRedirect, "/api/login")
+}
+
+// redeemPendingInvitation redeems an invite accepted earlier in this browser
+// once the login has produced a verified identity.
+func redeemPendingInvitation(c *gin.Context, claims idTokenClaims) {
+	token, err := c.Cookie(inviteTokenCookie)
+	if err != nil || token == "" {
+		return
+	}
+	setTokenCookie(c, inviteTokenCookie, "", -1)
+
+	if invitationService == nil || claims.Subject == "" {
+		return
+	}
+
+	invitation, err := invitationService.RedeemInvitation(token, claims.Subject, claims.Email, claims.EmailVerified)
+	if err != nil {
+		log.Printf("failed to redeem invitation for %s: %v", claims.Subject, err)
+		return
+	}
+	recordAuditAs(c, claims.Subject, models.AuditActionInviteRedeem, models.AuditTargetInvitation, invitation.ID, nil, gin.H{"role": invitation.Role})
+}
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dat1010/go-api/config"
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockInvitationService struct {
+	VerifyTokenFunc      func(token string) (string, error)
+	RedeemInvitationFunc func(token, auth0UserID, email string, emailVerified bool) (*models.Invitation, error)
+}
+
+func (m *mockInvitationService) CreateInvitation(email, roleName, invitedBy string) (*models.Invitation, string, error) {
+	return nil, "", nil
+}
+
+func (m *mockInvitationService) ListInvitations() ([]models.Invitation, error) {
+	return nil, nil
+}
+
+func (m *mockInvitationService) RevokeInvitation(id string) error {
+	return nil
+}
+
+func (m *mockInvitationService) VerifyToken(token string) (string, error) {
+	if m.VerifyTokenFunc != nil {
+		return m.VerifyTokenFunc(token)
+	}
+	return "", nil
+}
+
+func (m *mockInvitationService) RedeemInvitation(token, auth0UserID, email string, emailVerified bool) (*models.Invitation, error) {
+	if m.RedeemInvitationFunc != nil {
+		return m.RedeemInvitationFunc(token, auth0UserID, email, emailVerified)
+	}
+	return nil, nil
+}
+
+func TestAcceptInvitation_InvalidToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	invitationService = &mockInvitationService{
+		VerifyTokenFunc: func(token string) (string, error) {
+			return "", services.ErrInvitationInvalid
+		},
+	}
+	defer func() { invitationService = nil }()
+
+	r := gin.Default()
+	r.GET("/invitations/accept", AcceptInvitation)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/invitations/accept?token=bogus", http.NoBody)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, w.Header().Values("Set-Cookie"))
+}
+
+func TestCallbackRedeemsPendingInvitation(t *testing.T) {
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"auth0|invitee","email":"Invitee@Example.com","email_verified":true}`))
+	idToken := "header." + claims + ".signature"
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"A","id_token":"` + idToken + `","expires_in":3600,"token_type":"Bearer"}`))
+	}))
+	defer ts.Close()
+
+	SetAuthConfig(config.Auth0Config{
+		Domain:       strings.TrimPrefix(ts.URL, "http://"),
+		ClientID:     "id",
+		ClientSecret: "secret",
+		CallbackURL:  "http://localhost:8080/api/callback",
+		Scheme:       "http",
+	})
+	defer SetAuthConfig(config.Auth0Config{})
+
+	var gotToken, gotUser, gotEmail string
+	var gotVerified bool
+	invitationService = &mockInvitationService{
+		RedeemInvitationFunc: func(token, auth0UserID, email string, emailVerified bool) (*models.Invitation, error) {
+			gotToken, gotUser, gotEmail, gotVerified = token, auth0UserID, email, emailVerified
+			return &models.Invitation{ID: "invite-1", Role: "superadmin"}, nil
+		},
+	}
+	defer func() { invitationService = nil }()
+
+	req := httptest.NewRequest("GET", "/callback?code=foo", nil)
+	req.AddCookie(&http.Cookie{Name: inviteTokenCookie, Value: "invite-1.sig"})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	Callback(c)
+
+	assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+	assert.Equal(t, "invite-1.sig", gotToken)
+	assert.Equal(t, "auth0|invitee", gotUser)
+	assert.Equal(t, "Invitee@Example.com", gotEmail)
+	assert.True(t, gotVerified)
+
+	cleared := false
+	for _, sc := range w.Header().Values("Set-Cookie") {
+		if strings.HasPrefix(sc, inviteTokenCookie+"=;") {
+			cleared = true
+		}
+	}
+	assert.True(t, cleared, "invite cookie should be cleared after redemption")
+}
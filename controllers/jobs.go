@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+var jobService services.JobService
+
+func SetJobService(s services.JobService) {
+	jobService = s
+}
+
+// @Summary List background jobs
+// @Description List queued, running, finished and dead-lettered background jobs, newest first (superadmin only)
+// @Tags admin
+// @Produce json
+// @Param status query string false "pending, running, succeeded or dead"
+// @Param kind query string false "Job kind, such as notify.deliver"
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Success 200 {object} models.JobPage
+// @Failure 400 {object} object "Bad request"
+// @Failure 500 {object} object "Internal server error"
+// @Router /admin/jobs [get]
+func ListJobs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.Query("page_size"))
+
+	jobs, err := jobService.ListJobs(c.Query("status"), c.Query("kind"), page, pageSize)
+	if err != nil {
+		respondJobError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, jobs)
+}
+
+// @Summary Retry a dead job
+// @Description Requeue a dead-lettered job with fresh attempts (superadmin only)
+// @Tags admin
+// @Param id path int true "Job ID"
+// @Success 204 "Requeued"
+// @Failure 400 {object} object "Bad request"
+// @Failure 404 {object} object "No dead job with that ID"
+// @Failure 500 {object} object "Internal server error"
+// @Router /admin/jobs/{id}/retry [post]
+func RetryJob(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid job ID"})
+		return
+	}
+	if err := jobService.RetryJob(id); err != nil {
+		respondJobError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditActionJobRetry, models.AuditTargetJob, c.Param("id"), nil, nil)
+	c.Status(http.StatusNoContent)
+}
+
+func respondJobError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidJobFilter):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrJobNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process jobs"})
+	}
+}
@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockJobService struct {
+	services.JobService
+	ListJobsFunc func(status, kind string, page, pageSize int) (*models.JobPage, error)
+	RetryJobFunc func(id int64) error
+}
+
+func (m *mockJobService) ListJobs(status, kind string, page, pageSize int) (*models.JobPage, error) {
+	return m.ListJobsFunc(status, kind, page, pageSize)
+}
+
+func (m *mockJobService) RetryJob(id int64) error {
+	return m.RetryJobFunc(id)
+}
+
+func jobsRequest(method, path string) *httptest.ResponseRecorder {
+	r := gin.Default()
+	r.GET("/admin/jobs", ListJobs)
+	r.POST("/admin/jobs/:id/retry", RetryJob)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, nil)
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestListJobs_PassesFilters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	jobService = &mockJobService{
+		ListJobsFunc: func(status, kind string, page, pageSize int) (*models.JobPage, error) {
+			assert.Equal(t, models.JobStatusDead, status)
+			assert.Equal(t, models.JobKindNotifyDeliver, kind)
+			assert.Equal(t, 2, page)
+			return &models.JobPage{Items: []models.Job{{ID: 7, Kind: kind, Status: status}}, Total: 1, Page: page, PageSize: 20}, nil
+		},
+	}
+	defer func() { jobService = nil }()
+
+	w := jobsRequest("GET", "/admin/jobs?status=dead&kind=notify.deliver&page=2")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"id":7`)
+}
+
+func TestRetryJob(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	jobService = &mockJobService{
+		RetryJobFunc: func(id int64) error {
+			if id != 7 {
+				return services.ErrJobNotFound
+			}
+			return nil
+		},
+	}
+	defer func() { jobService = nil }()
+
+	assert.Equal(t, http.StatusNoContent, jobsRequest("POST", "/admin/jobs/7/retry").Code)
+	assert.Equal(t, http.StatusNotFound, jobsRequest("POST", "/admin/jobs/8/retry").Code)
+	assert.Equal(t, http.StatusBadRequest, jobsRequest("POST", "/admin/jobs/abc/retry").Code)
+}
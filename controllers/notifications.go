@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/notify"
+	"github.com/dat1010/go-api/services"
+	"github.com/dat1010/go-api/utils"
+	"github.com/gin-gonic/gin"
+)
+
+var notificationService services.NotificationService
+
+func SetNotificationService(s services.NotificationService) {
+	notificationService = s
+}
+
+// @Summary List notification preferences
+// @Description List where the authenticated user receives notifications, one entry per channel
+// @Tags notifications
+// @Produce json
+// @Security Bearer
+// @Success 200 {array} models.NotificationPreference
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 500 {object} object "Internal server error"
+// @Router /notifications/preferences [get]
+func ListNotificationPreferences(c *gin.Context) {
+	auth0UserID, ok := utils.GetAuth0UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	prefs, err := notificationService.ListPreferences(auth0UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list notification preferences"})
+		return
+	}
+	c.JSON(http.StatusOK, prefs)
+}
+
+// @Summary Set a notification preference
+// @Description Choose where notifications on a channel (discord, slack, http or email) are sent and which kinds (post.created, event.failed) to receive there. Webhook channels need an https URL, on discord.com or hooks.slack.com for those channels.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param channel path string true "Channel"
+// @Param body body models.PutNotificationPreferenceRequest true "Preference"
+// @Success 200 {object} models.NotificationPreference
+// @Failure 400 {object} object "Bad request"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 503 {object} object "Channel not configured"
+// @Failure 500 {object} object "Internal server error"
+// @Router /notifications/preferences/{channel} [put]
+func PutNotificationPreference(c *gin.Context) {
+	auth0UserID, ok := utils.GetAuth0UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.PutNotificationPreferenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pref, err := notificationService.PutPreference(auth0UserID, c.Param("channel"), &req)
+	if err != nil {
+		respondNotificationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, pref)
+}
+
+// @Summary Delete a notification preference
+// @Description Stop sending notifications on a channel
+// @Tags notifications
+// @Security Bearer
+// @Param channel path string true "Channel"
+// @Success 204 "Deleted"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "Preference not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /notifications/preferences/{channel} [delete]
+func DeleteNotificationPreference(c *gin.Context) {
+	auth0UserID, ok := utils.GetAuth0UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if err := notificationService.DeletePreference(auth0UserID, c.Param("channel")); err != nil {
+		respondNotificationError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Send a test notification
+// @Description Send a test message on one of the authenticated user's channels, even if it is disabled
+// @Tags notifications
+// @Security Bearer
+// @Param channel path string true "Channel"
+// @Success 204 "Delivered"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "Preference not found"
+// @Failure 502 {object} object "Delivery failed"
+// @Failure 503 {object} object "Channel not configured"
+// @Router /notifications/preferences/{channel}/test [post]
+func TestNotificationPreference(c *gin.Context) {
+	auth0UserID, ok := utils.GetAuth0UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if err := notificationService.SendTest(c.Request.Context(), auth0UserID, c.Param("channel")); err != nil {
+		respondNotificationError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func respondNotificationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidNotificationPreference):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":    err.Error(),
+			"channels": notify.Channels,
+			"kinds":    models.NotificationKinds,
+		})
+	case errors.Is(err, services.ErrNotificationPreferenceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotificationDeliveryFailed):
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotificationChannelUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update notification preferences"})
+	}
+}
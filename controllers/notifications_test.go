@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/notify"
+	"github.com/dat1010/go-api/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockNotificationService struct {
+	services.NotificationService
+	PutPreferenceFunc func(auth0UserID, channel string, req *models.PutNotificationPreferenceRequest) (*models.NotificationPreference, error)
+}
+
+func (m *mockNotificationService) PutPreference(auth0UserID, channel string, req *models.PutNotificationPreferenceRequest) (*models.NotificationPreference, error) {
+	return m.PutPreferenceFunc(auth0UserID, channel, req)
+}
+
+type recordingNotifier struct {
+	destinations []string
+	err          error
+}
+
+func (n *recordingNotifier) Validate(destination string) error { return nil }
+
+func (n *recordingNotifier) Notify(ctx context.Context, destination string, msg notify.Message) error {
+	n.destinations = append(n.destinations, destination)
+	return n.err
+}
+
+func TestPutNotificationPreference(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	notificationService = &mockNotificationService{
+		PutPreferenceFunc: func(auth0UserID, channel string, req *models.PutNotificationPreferenceRequest) (*models.NotificationPreference, error) {
+			if channel != notify.ChannelDiscord {
+				return nil, services.ErrInvalidNotificationPreference
+			}
+			return &models.NotificationPreference{Auth0UserID: auth0UserID, Channel: channel, Destination: req.Destination, Kinds: req.Kinds, Enabled: true}, nil
+		},
+	}
+	defer func() { notificationService = nil }()
+
+	r := gin.Default()
+	r.PUT("/notifications/preferences/:channel", func(c *gin.Context) {
+		c.Set("user", validator.RegisteredClaims{Subject: "auth0|1"})
+		PutNotificationPreference(c)
+	})
+	put := func(channel string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/notifications/preferences/"+channel,
+			bytes.NewBufferString(`{"destination":"https://discord.com/api/webhooks/1/a","kinds":["post.created"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := put(notify.ChannelDiscord)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"kinds":["post.created"]`)
+
+	w = put("pager")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"channels"`)
+}
+
+func TestPingDiscord(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	defer SetDiscordWebhook(nil, "")
+	r := gin.Default()
+	r.GET("/discord-ping", PingDiscord)
+	ping := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/discord-ping", nil)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	SetDiscordWebhook(nil, "")
+	assert.Equal(t, http.StatusServiceUnavailable, ping().Code)
+
+	notifier := &recordingNotifier{}
+	SetDiscordWebhook(notifier, "https://discord.com/api/webhooks/1/a")
+	lastDiscordPing = time.Time{}
+	w := ping()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"message":"Ping sent","discord_status":"delivered"}`, w.Body.String())
+	assert.Equal(t, []string{"https://discord.com/api/webhooks/1/a"}, notifier.destinations)
+	assert.Equal(t, http.StatusTooManyRequests, ping().Code)
+
+	notifier.err = errors.New("notification endpoint returned 404 Not Found")
+	lastDiscordPing = time.Time{}
+	assert.Equal(t, http.StatusBadGateway, ping().Code)
+}
@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/services"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// postStreamKeepAlive is how often an idle stream sends a comment, so
+// proxies do not close it.
+const postStreamKeepAlive = 25 * time.Second
+
+var postStream services.PostStream
+
+func SetPostStream(s services.PostStream) {
+	postStream = s
+}
+
+// @Summary Stream post changes
+// @Description Server-Sent Events for post changes. Each event is named post.created, post.updated or post.deleted, has an id, and carries {type, post_id, post} as data (no post for deletions). Reconnect with the Last-Event-ID header to receive the events missed since; if they are no longer available a "reset" event is sent first and the client should reload posts.
+// @Tags posts
+// @Produce text/event-stream
+// @Param Last-Event-ID header string false "ID of the last event received"
+// @Success 200 {object} models.PostEvent "Event stream"
+// @Failure 503 {object} object "Streaming unavailable"
+// @Router /posts/stream [get]
+func StreamPosts(c *gin.Context) {
+	if postStream == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "post streaming is not available"})
+		return
+	}
+
+	subscription := postStream.Subscribe(c.GetHeader("Last-Event-ID"))
+	defer subscription.Close()
+
+	c.Header("Content-Type", "text/event-stream;charset=utf-8")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Stop nginx-style proxies buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	if subscription.Reset {
+		c.Render(-1, sse.Event{Event: "reset", Data: gin.H{}})
+	}
+	for _, event := range subscription.Replay {
+		renderPostEvent(c, event)
+	}
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(postStreamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-subscription.Events:
+			if !ok {
+				return
+			}
+			renderPostEvent(c, event)
+		case <-keepAlive.C:
+			_, _ = io.WriteString(c.Writer, ": keep-alive\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
+
+func renderPostEvent(c *gin.Context, event models.PostEvent) {
+	c.Render(-1, sse.Event{Id: event.ID, Event: event.Type, Data: event})
+}
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockPostStream struct {
+	SubscribeFunc func(lastEventID string) *services.PostSubscription
+}
+
+func (m *mockPostStream) Subscribe(lastEventID string) *services.PostSubscription {
+	return m.SubscribeFunc(lastEventID)
+}
+
+func (m *mockPostStream) Run(ctx context.Context) {}
+
+func TestStreamPosts_ReplaysThenStreams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	postStream = &mockPostStream{
+		SubscribeFunc: func(lastEventID string) *services.PostSubscription {
+			assert.Equal(t, "7", lastEventID)
+			events := make(chan models.PostEvent, 1)
+			events <- models.PostEvent{ID: "9", Type: models.WebhookEventPostDeleted, PostID: "p2"}
+			close(events)
+			return &services.PostSubscription{
+				Replay: []models.PostEvent{{ID: "8", Type: models.WebhookEventPostCreated, PostID: "p1", Post: &models.Post{ID: "p1", Title: "Hello"}}},
+				Events: events,
+			}
+		},
+	}
+	defer func() { postStream = nil }()
+
+	r := gin.Default()
+	r.GET("/posts/stream", StreamPosts)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/posts/stream", nil)
+	req.Header.Set("Last-Event-ID", "7")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/event-stream")
+	body := w.Body.String()
+	assert.Contains(t, body, "id:8\nevent:post.created\ndata:{\"type\":\"post.created\",\"post_id\":\"p1\",\"post\":{\"id\":\"p1\",\"title\":\"Hello\"")
+	assert.Contains(t, body, "id:9\nevent:post.deleted\ndata:{\"type\":\"post.deleted\",\"post_id\":\"p2\"}\n\n")
+	assert.NotContains(t, body, "event:reset")
+}
+
+func TestStreamPosts_TellsClientToResetWhenEventsWereMissed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	postStream = &mockPostStream{
+		SubscribeFunc: func(lastEventID string) *services.PostSubscription {
+			events := make(chan models.PostEvent)
+			close(events)
+			return &services.PostSubscription{Reset: true, Events: events}
+		},
+	}
+	defer func() { postStream = nil }()
+
+	r := gin.Default()
+	r.GET("/posts/stream", StreamPosts)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/posts/stream", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	r.ServeHTTP(w, req)
+
+	assert.Contains(t, w.Body.String(), "event:reset\ndata:{}\n\n")
+}
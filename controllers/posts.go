@@ -47,6 +47,7 @@ func CreatePost(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	recordAudit(c, models.AuditActionPostCreate, models.AuditTargetPost, post.ID, nil, post)
 
 	c.JSON(http.StatusCreated, post)
 }
@@ -105,6 +106,11 @@ func UpdatePost(c *gin.Context) {
 		return
 	}
 
+	var before *models.Post
+	if auditService != nil {
+		before, _ = postService.GetPost(id)
+	}
+
 	post, err := postService.UpdatePost(id, &req, auth0UserID)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -114,6 +120,7 @@ func UpdatePost(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	recordAudit(c, models.AuditActionPostUpdate, models.AuditTargetPost, id, before, post)
 
 	c.JSON(http.StatusOK, post)
 }
@@ -139,6 +146,11 @@ func DeletePost(c *gin.Context) {
 		return
 	}
 
+	var before *models.Post
+	if auditService != nil {
+		before, _ = postService.GetPost(id)
+	}
+
 	err := postService.DeletePost(id, auth0UserID)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -148,6 +160,7 @@ func DeletePost(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	recordAudit(c, models.AuditActionPostDelete, models.AuditTargetPost, id, before, nil)
 
 	c.Status(http.StatusNoContent)
 }
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+var roleService services.RoleService
+
+func SetRoleService(s services.RoleService) {
+	roleService = s
+}
+
+// @Summary List roles
+// @Description List roles and the permissions granted to each (superadmin only)
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.Role
+// @Failure 500 {object} object "Internal server error"
+// @Router /admin/roles [get]
+func ListRoles(c *gin.Context) {
+	roles, err := roleService.ListRoles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list roles"})
+		return
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+// @Summary Create role
+// @Description Create a role with the given permissions (superadmin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param body body models.CreateRoleRequest true "Role payload"
+// @Success 201 {object} models.Role
+// @Failure 400 {object} object "Bad request"
+// @Failure 409 {object} object "Role already exists"
+// @Failure 500 {object} object "Internal server error"
+// @Router /admin/roles [post]
+func CreateRole(c *gin.Context) {
+	var req models.CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+	if req.Permissions == nil {
+		req.Permissions = []string{}
+	}
+
+	if err := roleService.CreateRole(req.Name, req.Permissions); err != nil {
+		respondRoleError(c, err)
+		return
+	}
+	role := models.Role{Name: req.Name, Permissions: req.Permissions}
+	recordAudit(c, models.AuditActionRoleCreate, models.AuditTargetRole, req.Name, nil, role)
+	c.JSON(http.StatusCreated, role)
+}
+
+// @Summary Set role permissions
+// @Description Replace the permissions granted to a role (superadmin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param name path string true "Role name"
+// @Param body body models.SetRolePermissionsRequest true "Permissions payload"
+// @Success 200 {object} models.Role
+// @Failure 400 {object} object "Bad request"
+// @Failure 404 {object} object "Role not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /admin/roles/{name}/permissions [put]
+func SetRolePermissions(c *gin.Context) {
+	var req models.SetRolePermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+	if req.Permissions == nil {
+		req.Permissions = []string{}
+	}
+	name := c.Param("name")
+
+	before := rolePermissions(name)
+	if err := roleService.SetRolePermissions(name, req.Permissions); err != nil {
+		respondRoleError(c, err)
+		return
+	}
+	role := models.Role{Name: name, Permissions: req.Permissions}
+	recordAudit(c, models.AuditActionRolePermissionsUpdate, models.AuditTargetRole, name, before, role)
+	c.JSON(http.StatusOK, role)
+}
+
+// rolePermissions returns the role's current state for the audit log, or nil if it cannot be read.
+func rolePermissions(name string) *models.Role {
+	if auditService == nil {
+		return nil
+	}
+	roles, err := roleService.ListRoles()
+	if err != nil {
+		return nil
+	}
+	for i := range roles {
+		if roles[i].Name == name {
+			return &roles[i]
+		}
+	}
+	return nil
+}
+
+func respondRoleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrUnknownPermission):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "known_permissions": models.KnownPermissions})
+	case errors.Is(err, services.ErrRoleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "role not found"})
+	case errors.Is(err, services.ErrRoleExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update role"})
+	}
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockRoleService struct {
+	services.RoleService
+	SetRolePermissionsFunc func(name string, permissions []string) error
+}
+
+func (m *mockRoleService) SetRolePermissions(name string, permissions []string) error {
+	return m.SetRolePermissionsFunc(name, permissions)
+}
+
+func setRolePermissionsRequest(body string) *httptest.ResponseRecorder {
+	r := gin.Default()
+	r.PUT("/admin/roles/:name/permissions", SetRolePermissions)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/admin/roles/event_creator/permissions", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestSetRolePermissions_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var gotName string
+	var gotPermissions []string
+	roleService = &mockRoleService{
+		SetRolePermissionsFunc: func(name string, permissions []string) error {
+			gotName, gotPermissions = name, permissions
+			return nil
+		},
+	}
+	defer func() { roleService = nil }()
+
+	w := setRolePermissionsRequest(`{"permissions":["events:create"]}`)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "event_creator", gotName)
+	assert.Equal(t, []string{models.PermissionEventsCreate}, gotPermissions)
+}
+
+func TestSetRolePermissions_UnknownPermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	roleService = &mockRoleService{
+		SetRolePermissionsFunc: func(name string, permissions []string) error {
+			return fmt.Errorf("%w: %s", services.ErrUnknownPermission, permissions[0])
+		},
+	}
+	defer func() { roleService = nil }()
+
+	w := setRolePermissionsRequest(`{"permissions":["posts:nuke"]}`)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "posts:nuke")
+}
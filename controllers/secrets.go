@@ -2,7 +2,6 @@ package controllers
 
 import (
 	"net/http"
-	"os"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,10 +10,11 @@ type Secret struct {
 	Value string `json:"secret"`
 }
 
-var (
-	myLittleSecret = os.Getenv("MY_LITTLE_SECRET")
-	viewSecrets    = Secret{Value: myLittleSecret}
-)
+var viewSecrets Secret
+
+func SetSecret(value string) {
+	viewSecrets = Secret{Value: value}
+}
 
 func GetSecret(c *gin.Context) {
 	c.IndentedJSON(http.StatusOK, viewSecrets)
@@ -0,0 +1,363 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/services"
+	"github.com/dat1010/go-api/utils"
+	"github.com/gin-gonic/gin"
+)
+
+var webhookService services.WebhookService
+
+func SetWebhookService(s services.WebhookService) {
+	webhookService = s
+}
+
+// @Summary List your webhooks
+// @Description List the webhooks the authenticated user registered
+// @Tags webhooks
+// @Produce json
+// @Security Bearer
+// @Success 200 {array} models.Webhook
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 500 {object} object "Internal server error"
+// @Router /webhooks [get]
+func ListWebhooks(c *gin.Context) {
+	if ownerID, ok := webhookOwner(c); ok {
+		listWebhooks(c, ownerID)
+	}
+}
+
+// @Summary Register a webhook
+// @Description Send post events (post.created, post.updated, post.deleted) to an https URL. Each delivery is signed: X-Webhook-Signature is "sha256=" and the hex HMAC-SHA256 of "<X-Webhook-Timestamp>.<body>" keyed with the secret, which is only returned here. Failed deliveries are retried with backoff, and a webhook whose deliveries keep failing is disabled. Users may register up to 10 webhooks.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param body body models.CreateWebhookRequest true "Webhook"
+// @Success 201 {object} models.CreatedWebhook
+// @Failure 400 {object} object "Bad request"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 409 {object} object "Webhook limit reached"
+// @Failure 500 {object} object "Internal server error"
+// @Router /webhooks [post]
+func CreateWebhook(c *gin.Context) {
+	if ownerID, ok := webhookOwner(c); ok {
+		createWebhook(c, ownerID)
+	}
+}
+
+// @Summary Get a webhook
+// @Tags webhooks
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} models.Webhook
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "Webhook not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /webhooks/{id} [get]
+func GetWebhook(c *gin.Context) {
+	if ownerID, ok := webhookOwner(c); ok {
+		getWebhook(c, ownerID)
+	}
+}
+
+// @Summary Update a webhook
+// @Description Change a webhook's URL, event types, description or enabled state. Enabling a disabled webhook clears its failures.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Webhook ID"
+// @Param body body models.UpdateWebhookRequest true "Changes"
+// @Success 200 {object} models.Webhook
+// @Failure 400 {object} object "Bad request"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "Webhook not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /webhooks/{id} [patch]
+func UpdateWebhook(c *gin.Context) {
+	if ownerID, ok := webhookOwner(c); ok {
+		updateWebhook(c, ownerID)
+	}
+}
+
+// @Summary Delete a webhook
+// @Description Delete a webhook and its delivery log
+// @Tags webhooks
+// @Security Bearer
+// @Param id path string true "Webhook ID"
+// @Success 204 "Deleted"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "Webhook not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /webhooks/{id} [delete]
+func DeleteWebhook(c *gin.Context) {
+	if ownerID, ok := webhookOwner(c); ok {
+		deleteWebhook(c, ownerID)
+	}
+}
+
+// @Summary List webhook deliveries
+// @Description List a webhook's deliveries with their latest attempt, newest first
+// @Tags webhooks
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Webhook ID"
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Success 200 {object} models.WebhookDeliveryPage
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "Webhook not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /webhooks/{id}/deliveries [get]
+func ListWebhookDeliveries(c *gin.Context) {
+	if ownerID, ok := webhookOwner(c); ok {
+		listWebhookDeliveries(c, ownerID)
+	}
+}
+
+// @Summary Redeliver a webhook event
+// @Description Queue the delivery's event to be sent again, as a new delivery with the same event ID
+// @Tags webhooks
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Webhook ID"
+// @Param delivery_id path string true "Delivery ID"
+// @Success 202 {object} models.WebhookDelivery
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "Webhook or delivery not found"
+// @Failure 409 {object} object "Webhook is disabled"
+// @Failure 500 {object} object "Internal server error"
+// @Router /webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func RedeliverWebhook(c *gin.Context) {
+	if ownerID, ok := webhookOwner(c); ok {
+		redeliverWebhook(c, ownerID)
+	}
+}
+
+// @Summary List admin webhooks
+// @Description List the system webhooks, which may also receive user events (superadmin only)
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.Webhook
+// @Failure 500 {object} object "Internal server error"
+// @Router /admin/webhooks [get]
+func AdminListWebhooks(c *gin.Context) {
+	listWebhooks(c, "")
+}
+
+// @Summary Register an admin webhook
+// @Description Send post and user events (user.created, user.role_updated, user.suspended, user.banned, user.reinstated, user.deleted) to an https URL, signed like user webhooks (superadmin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param body body models.CreateWebhookRequest true "Webhook"
+// @Success 201 {object} models.CreatedWebhook
+// @Failure 400 {object} object "Bad request"
+// @Failure 500 {object} object "Internal server error"
+// @Router /admin/webhooks [post]
+func AdminCreateWebhook(c *gin.Context) {
+	createWebhook(c, "")
+}
+
+// @Summary Get an admin webhook
+// @Tags admin
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} models.Webhook
+// @Failure 404 {object} object "Webhook not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /admin/webhooks/{id} [get]
+func AdminGetWebhook(c *gin.Context) {
+	getWebhook(c, "")
+}
+
+// @Summary Update an admin webhook
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param body body models.UpdateWebhookRequest true "Changes"
+// @Success 200 {object} models.Webhook
+// @Failure 400 {object} object "Bad request"
+// @Failure 404 {object} object "Webhook not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /admin/webhooks/{id} [patch]
+func AdminUpdateWebhook(c *gin.Context) {
+	updateWebhook(c, "")
+}
+
+// @Summary Delete an admin webhook
+// @Tags admin
+// @Param id path string true "Webhook ID"
+// @Success 204 "Deleted"
+// @Failure 404 {object} object "Webhook not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /admin/webhooks/{id} [delete]
+func AdminDeleteWebhook(c *gin.Context) {
+	deleteWebhook(c, "")
+}
+
+// @Summary List admin webhook deliveries
+// @Tags admin
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Success 200 {object} models.WebhookDeliveryPage
+// @Failure 404 {object} object "Webhook not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /admin/webhooks/{id}/deliveries [get]
+func AdminListWebhookDeliveries(c *gin.Context) {
+	listWebhookDeliveries(c, "")
+}
+
+// @Summary Redeliver an admin webhook event
+// @Tags admin
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param delivery_id path string true "Delivery ID"
+// @Success 202 {object} models.WebhookDelivery
+// @Failure 404 {object} object "Webhook or delivery not found"
+// @Failure 409 {object} object "Webhook is disabled"
+// @Failure 500 {object} object "Internal server error"
+// @Router /admin/webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func AdminRedeliverWebhook(c *gin.Context) {
+	redeliverWebhook(c, "")
+}
+
+// webhookOwner returns the authenticated user, answering 401 without one.
+func webhookOwner(c *gin.Context) (string, bool) {
+	auth0UserID, ok := utils.GetAuth0UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	}
+	return auth0UserID, ok
+}
+
+// The helpers below serve a user's webhooks, or the admin webhooks when
+// ownerID is empty. Changes to admin webhooks are audited.
+
+func listWebhooks(c *gin.Context, ownerID string) {
+	webhooks, err := webhookService.ListWebhooks(ownerID)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, webhooks)
+}
+
+func createWebhook(c *gin.Context, ownerID string) {
+	var req models.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := webhookService.CreateWebhook(ownerID, &req)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+	if ownerID == "" {
+		recordAudit(c, models.AuditActionWebhookCreate, models.AuditTargetWebhook, created.ID, nil, created.Webhook)
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+func getWebhook(c *gin.Context, ownerID string) {
+	webhook, err := webhookService.GetWebhook(ownerID, c.Param("id"))
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, webhook)
+}
+
+func updateWebhook(c *gin.Context, ownerID string) {
+	var req models.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var before *models.Webhook
+	if ownerID == "" {
+		before, _ = webhookService.GetWebhook(ownerID, c.Param("id"))
+	}
+	webhook, err := webhookService.UpdateWebhook(ownerID, c.Param("id"), &req)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+	if ownerID == "" {
+		recordAudit(c, models.AuditActionWebhookUpdate, models.AuditTargetWebhook, webhook.ID, before, webhook)
+	}
+	c.JSON(http.StatusOK, webhook)
+}
+
+func deleteWebhook(c *gin.Context, ownerID string) {
+	var before *models.Webhook
+	if ownerID == "" {
+		before, _ = webhookService.GetWebhook(ownerID, c.Param("id"))
+	}
+	if err := webhookService.DeleteWebhook(ownerID, c.Param("id")); err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+	if ownerID == "" {
+		recordAudit(c, models.AuditActionWebhookDelete, models.AuditTargetWebhook, c.Param("id"), before, nil)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func listWebhookDeliveries(c *gin.Context, ownerID string) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.Query("page_size"))
+
+	deliveries, err := webhookService.ListDeliveries(ownerID, c.Param("id"), page, pageSize)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+func redeliverWebhook(c *gin.Context, ownerID string) {
+	delivery, err := webhookService.Redeliver(ownerID, c.Param("id"), c.Param("delivery_id"))
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+	if ownerID == "" {
+		recordAudit(c, models.AuditActionWebhookRedeliver, models.AuditTargetWebhook, c.Param("id"), nil, gin.H{
+			"delivery_id":   delivery.ID,
+			"redelivery_of": c.Param("delivery_id"),
+		})
+	}
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+func respondWebhookError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidWebhook):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrWebhookNotFound), errors.Is(err, services.ErrWebhookDeliveryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrWebhookLimitReached):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+			"limit": services.MaxWebhooksPerUser,
+		})
+	case errors.Is(err, services.ErrWebhookDisabled):
+		c.JSON(http.StatusConflict, gin.H{"error": "webhook is disabled; enable it before redelivering"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process webhooks"})
+	}
+}
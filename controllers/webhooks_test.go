@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockWebhookService struct {
+	services.WebhookService
+	CreateWebhookFunc func(ownerID string, req *models.CreateWebhookRequest) (*models.CreatedWebhook, error)
+	RedeliverFunc     func(ownerID, webhookID, deliveryID string) (*models.WebhookDelivery, error)
+}
+
+func (m *mockWebhookService) CreateWebhook(ownerID string, req *models.CreateWebhookRequest) (*models.CreatedWebhook, error) {
+	return m.CreateWebhookFunc(ownerID, req)
+}
+
+func (m *mockWebhookService) Redeliver(ownerID, webhookID, deliveryID string) (*models.WebhookDelivery, error) {
+	return m.RedeliverFunc(ownerID, webhookID, deliveryID)
+}
+
+func webhooksRouter() *gin.Engine {
+	r := gin.Default()
+	user := r.Group("", func(c *gin.Context) {
+		c.Set("user", validator.RegisteredClaims{Subject: "auth0|1"})
+	})
+	user.POST("/webhooks", CreateWebhook)
+	user.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", RedeliverWebhook)
+	r.POST("/admin/webhooks", AdminCreateWebhook)
+	return r
+}
+
+func TestCreateWebhook_ScopesToCaller(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var owners []string
+	webhookService = &mockWebhookService{
+		CreateWebhookFunc: func(ownerID string, req *models.CreateWebhookRequest) (*models.CreatedWebhook, error) {
+			owners = append(owners, ownerID)
+			if req.URL == "http://example.com" {
+				return nil, services.ErrInvalidWebhook
+			}
+			return &models.CreatedWebhook{Webhook: models.Webhook{ID: "w1", URL: req.URL}, Secret: "whsec_abc"}, nil
+		},
+	}
+	defer func() { webhookService = nil }()
+	router := webhooksRouter()
+	post := func(path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post("/webhooks", `{"url":"https://example.com","event_types":["post.created"]}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"secret":"whsec_abc"`)
+
+	assert.Equal(t, http.StatusCreated, post("/admin/webhooks", `{"url":"https://example.com","event_types":["user.banned"]}`).Code)
+	assert.Equal(t, []string{"auth0|1", ""}, owners)
+
+	assert.Equal(t, http.StatusBadRequest, post("/webhooks", `{"url":"http://example.com","event_types":["post.created"]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post("/webhooks", `{"event_types":["post.created"]}`).Code)
+}
+
+func TestRedeliverWebhook(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	webhookService = &mockWebhookService{
+		RedeliverFunc: func(ownerID, webhookID, deliveryID string) (*models.WebhookDelivery, error) {
+			assert.Equal(t, "auth0|1", ownerID)
+			switch webhookID {
+			case "disabled":
+				return nil, services.ErrWebhookDisabled
+			case "missing":
+				return nil, services.ErrWebhookNotFound
+			}
+			return &models.WebhookDelivery{ID: "d2", WebhookID: webhookID, RedeliveryOf: &deliveryID, Status: models.WebhookDeliveryPending}, nil
+		},
+	}
+	defer func() { webhookService = nil }()
+	router := webhooksRouter()
+	redeliver := func(webhookID string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/webhooks/"+webhookID+"/deliveries/d1/redeliver", nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := redeliver("w1")
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Contains(t, w.Body.String(), `"redelivery_of":"d1"`)
+	assert.Equal(t, http.StatusConflict, redeliver("disabled").Code)
+	assert.Equal(t, http.StatusNotFound, redeliver("missing").Code)
+}
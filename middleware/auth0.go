@@ -5,13 +5,13 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
-	"os"
 	"strings"
 	"time"
 
 	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
 	"github.com/auth0/go-jwt-middleware/v2/jwks"
 	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/dat1010/go-api/config"
 	"github.com/gin-gonic/gin"
 )
 
@@ -37,15 +37,16 @@ func (r *responseRecorder) WriteHeader(statusCode int) {
 	r.ResponseWriter.WriteHeader(statusCode)
 }
 
-func Auth0() gin.HandlerFunc {
-	domain := os.Getenv("AUTH0_DOMAIN")
-	audience := os.Getenv("AUTH0_AUDIENCE")
+// Auth0 accepts access tokens issued by cfg.Domain for cfg.Audience.
+func Auth0(cfg config.Auth0Config) gin.HandlerFunc {
+	domain := cfg.Domain
+	audience := cfg.Audience
 
 	if domain == "" {
-		panic("AUTH0_DOMAIN environment variable not set")
+		panic("Auth0 domain not configured")
 	}
 	if audience == "" {
-		panic("AUTH0_AUDIENCE environment variable not set")
+		panic("Auth0 audience not configured")
 	}
 
 	// Set up the key provider
@@ -1,24 +1,19 @@
 package middleware
 
 import (
-	"os"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
-func CORS() gin.HandlerFunc {
-	allowedOrigins := []string{"https://nofeed.zone"}
-	if os.Getenv("ENVIRONMENT") == "development" {
-		allowedOrigins = append(allowedOrigins, "http://localhost:3000")
-	}
-
+// CORS lets browsers on allowedOrigins call the API with credentials.
+func CORS(allowedOrigins []string) gin.HandlerFunc {
 	return cors.New(cors.Config{
 		AllowOrigins:     allowedOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization", "X-Impersonation-Token", "Idempotency-Key", "Last-Event-ID"},
+		ExposeHeaders:    []string{"Content-Length", "X-Impersonating", "X-Request-ID", "Idempotent-Replayed"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	})
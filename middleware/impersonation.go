@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/dat1010/go-api/repositories"
+	"github.com/dat1010/go-api/services"
+	"github.com/dat1010/go-api/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// Impersonation switches the request to the impersonated user when a
+// superadmin sends a valid impersonation token. Must run after Auth0.
+func Impersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := utils.GetImpersonationToken(c)
+		if token == "" {
+			c.Next()
+			return
+		}
+
+		db, ok := c.Get("db")
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "db not available"})
+			return
+		}
+		sqlxDB, ok := db.(*sqlx.DB)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "invalid db"})
+			return
+		}
+
+		actor, ok := utils.GetActorUserID(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		impersonationService := services.NewImpersonationService(
+			repositories.NewImpersonationRepository(sqlxDB),
+			services.NewUserService(repositories.NewUserRepository(sqlxDB)),
+		)
+
+		session, err := impersonationService.ResolveSession(token, actor)
+		if err != nil {
+			if err == services.ErrImpersonationInactive {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check impersonation session"})
+			return
+		}
+
+		utils.SetImpersonation(c, session)
+		c.Header(utils.ImpersonatingHeader, session.TargetAuth0UserID)
+		c.Next()
+	}
+}
+
+// DenyImpersonation blocks requests carrying an impersonation token, keeping
+// admin and account routes out of reach of an impersonated session.
+func DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if utils.GetImpersonationToken(c) != "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "this route is unavailable while impersonating"})
+			return
+		}
+		c.Next()
+	}
+}
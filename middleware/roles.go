@@ -32,7 +32,7 @@ func EnsureUserRole(defaultRole string) gin.HandlerFunc {
 		userRepo := repositories.NewUserRepository(sqlxDB)
 		userService := services.NewUserService(userRepo)
 
-		if err := userService.EnsureUserWithDefaultRole(auth0UserID, defaultRole); err != nil {
+		if _, err := userService.EnsureUserWithDefaultRole(auth0UserID, defaultRole); err != nil {
 			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to ensure user"})
 			return
 		}
@@ -76,3 +76,40 @@ func RequireRole(roleName string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// RequirePermission rejects users whose role has not been granted the permission.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		db, ok := c.Get("db")
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "db not available"})
+			return
+		}
+		sqlxDB, ok := db.(*sqlx.DB)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "invalid db"})
+			return
+		}
+
+		auth0UserID, ok := utils.GetAuth0UserID(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userRepo := repositories.NewUserRepository(sqlxDB)
+		userService := services.NewUserService(userRepo)
+
+		isAllowed, err := userService.HasPermission(auth0UserID, permission)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check permission"})
+			return
+		}
+		if !isAllowed {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden", "missing_permission": permission})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/repositories"
+	"github.com/dat1010/go-api/services"
+	"github.com/dat1010/go-api/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// RequireActiveUser rejects suspended and banned users with a 403 explaining why.
+func RequireActiveUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		db, ok := c.Get("db")
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "db not available"})
+			return
+		}
+		sqlxDB, ok := db.(*sqlx.DB)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "invalid db"})
+			return
+		}
+
+		auth0UserID, ok := utils.GetAuth0UserID(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		userRepo := repositories.NewUserRepository(sqlxDB)
+		userService := services.NewUserService(userRepo)
+
+		status, err := userService.GetUserStatus(auth0UserID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check account status"})
+			return
+		}
+
+		switch status.Status {
+		case models.UserStatusSuspended:
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":           "account suspended",
+				"status":          status.Status,
+				"reason":          status.Reason,
+				"suspended_until": status.SuspendedUntil,
+			})
+			return
+		case models.UserStatusBanned:
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":  "account banned",
+				"status": status.Status,
+				"reason": status.Reason,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
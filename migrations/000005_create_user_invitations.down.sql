@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN IF EXISTS email;
+DROP TABLE IF EXISTS user_invitations;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS user_invitations (
+    id TEXT PRIMARY KEY,
+    email TEXT NOT NULL,
+    role_id INT NOT NULL REFERENCES roles(id) ON DELETE RESTRICT,
+    invited_by TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    redeemed_at TIMESTAMPTZ,
+    redeemed_by TEXT,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_invitations_email ON user_invitations(LOWER(email));
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email TEXT;
@@ -0,0 +1,2 @@
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS on_behalf_of;
+DROP TABLE IF EXISTS impersonation_sessions;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS impersonation_sessions (
+    id TEXT PRIMARY KEY,
+    token_hash TEXT NOT NULL UNIQUE,
+    actor_auth0_user_id TEXT NOT NULL,
+    target_auth0_user_id TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    ended_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_impersonation_sessions_actor ON impersonation_sessions(actor_auth0_user_id);
+
+-- Record who an action was performed on behalf of while impersonating
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS on_behalf_of TEXT NOT NULL DEFAULT '';
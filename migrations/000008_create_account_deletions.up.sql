@@ -0,0 +1,9 @@
+-- No foreign key to users: the request must outlive the account it erases
+CREATE TABLE IF NOT EXISTS account_deletions (
+    auth0_user_id TEXT PRIMARY KEY,
+    requested_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    scheduled_for TIMESTAMPTZ NOT NULL,
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_deletions_due ON account_deletions(scheduled_for) WHERE completed_at IS NULL;
@@ -0,0 +1,15 @@
+-- Backing store for the in-process scheduler used when SCHEDULER_BACKEND=local
+CREATE TABLE IF NOT EXISTS local_schedules (
+    name TEXT PRIMARY KEY,
+    owner_id TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    expression TEXT NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    next_run_at TIMESTAMPTZ,
+    last_run_at TIMESTAMPTZ,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_local_schedules_owner ON local_schedules(owner_id);
+CREATE INDEX IF NOT EXISTS idx_local_schedules_next_run ON local_schedules(next_run_at);
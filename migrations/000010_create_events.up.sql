@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS events (
+    id TEXT PRIMARY KEY,
+    owner_id TEXT NOT NULL REFERENCES users(auth0_user_id) ON DELETE CASCADE,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    schedule TEXT NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    state TEXT NOT NULL DEFAULT 'enabled' CHECK (state IN ('enabled', 'disabled')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_owner_created ON events(owner_id, created_at DESC);
@@ -0,0 +1,9 @@
+ALTER TABLE users DROP COLUMN IF EXISTS max_active_events;
+DROP TABLE IF EXISTS role_permissions;
+UPDATE user_roles
+SET role_id = (SELECT id FROM roles WHERE name = 'member'), assigned_at = NOW()
+WHERE auth0_user_id = 'auth0|68164b4c821b56fdc024b2dd'
+  AND role_id = (SELECT id FROM roles WHERE name = 'event_creator');
+DELETE FROM roles
+WHERE name = 'event_creator'
+  AND NOT EXISTS (SELECT 1 FROM user_roles ur WHERE ur.role_id = roles.id);
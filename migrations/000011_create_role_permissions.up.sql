@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id INT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission TEXT NOT NULL,
+    granted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (role_id, permission)
+);
+
+-- NULL means the default quota applies
+ALTER TABLE users ADD COLUMN IF NOT EXISTS max_active_events INT
+    CHECK (max_active_events >= 0);
+
+INSERT INTO roles (name)
+VALUES ('event_creator')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, 'events:create' FROM roles WHERE name IN ('superadmin', 'event_creator')
+ON CONFLICT DO NOTHING;
+
+-- Event creation used to be hardcoded to this account; keep it working by
+-- making it an event creator unless it already has a broader role
+INSERT INTO user_roles (auth0_user_id, role_id)
+SELECT 'auth0|68164b4c821b56fdc024b2dd', id FROM roles
+WHERE name = 'event_creator'
+  AND EXISTS (SELECT 1 FROM users WHERE auth0_user_id = 'auth0|68164b4c821b56fdc024b2dd')
+ON CONFLICT (auth0_user_id) DO UPDATE SET role_id = EXCLUDED.role_id, assigned_at = NOW()
+WHERE user_roles.role_id = (SELECT id FROM roles WHERE name = 'member');
@@ -0,0 +1,8 @@
+ALTER TABLE local_schedules DROP COLUMN IF EXISTS end_at;
+ALTER TABLE local_schedules DROP COLUMN IF EXISTS start_at;
+ALTER TABLE local_schedules DROP COLUMN IF EXISTS timezone;
+
+ALTER TABLE events DROP COLUMN IF EXISTS flexible_window_minutes;
+ALTER TABLE events DROP COLUMN IF EXISTS end_at;
+ALTER TABLE events DROP COLUMN IF EXISTS start_at;
+ALTER TABLE events DROP COLUMN IF EXISTS timezone;
@@ -0,0 +1,9 @@
+ALTER TABLE events ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT 'UTC';
+ALTER TABLE events ADD COLUMN IF NOT EXISTS start_at TIMESTAMPTZ;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS end_at TIMESTAMPTZ;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS flexible_window_minutes INT NOT NULL DEFAULT 0
+    CHECK (flexible_window_minutes BETWEEN 0 AND 1440);
+
+ALTER TABLE local_schedules ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT 'UTC';
+ALTER TABLE local_schedules ADD COLUMN IF NOT EXISTS start_at TIMESTAMPTZ;
+ALTER TABLE local_schedules ADD COLUMN IF NOT EXISTS end_at TIMESTAMPTZ;
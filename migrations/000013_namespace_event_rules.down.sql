@@ -0,0 +1,4 @@
+ALTER TABLE events DROP CONSTRAINT IF EXISTS events_owner_name_key;
+ALTER TABLE events ADD CONSTRAINT events_name_key UNIQUE (name);
+ALTER TABLE events DROP CONSTRAINT IF EXISTS events_rule_name_key;
+ALTER TABLE events DROP COLUMN IF EXISTS rule_name;
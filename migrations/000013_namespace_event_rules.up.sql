@@ -0,0 +1,11 @@
+ALTER TABLE events ADD COLUMN IF NOT EXISTS rule_name TEXT;
+
+-- Events created before namespacing keep the rule named after them
+UPDATE events SET rule_name = name WHERE rule_name IS NULL;
+
+ALTER TABLE events ALTER COLUMN rule_name SET NOT NULL;
+ALTER TABLE events ADD CONSTRAINT events_rule_name_key UNIQUE (rule_name);
+
+-- Names are now display names, unique per owner
+ALTER TABLE events DROP CONSTRAINT IF EXISTS events_name_key;
+ALTER TABLE events ADD CONSTRAINT events_owner_name_key UNIQUE (owner_id, name);
@@ -0,0 +1,4 @@
+ALTER TABLE local_schedules DROP COLUMN IF EXISTS targets;
+ALTER TABLE events DROP COLUMN IF EXISTS targets;
+
+DROP TABLE IF EXISTS event_targets;
@@ -0,0 +1,14 @@
+-- Destinations administrators make available to scheduled events
+CREATE TABLE IF NOT EXISTS event_targets (
+    name TEXT PRIMARY KEY,
+    kind TEXT NOT NULL CHECK (kind IN ('lambda', 'sqs', 'sns', 'api_destination')),
+    arn TEXT NOT NULL,
+    role_arn TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Empty target lists deliver to the default Lambda, as before
+ALTER TABLE events ADD COLUMN IF NOT EXISTS targets JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE local_schedules ADD COLUMN IF NOT EXISTS targets JSONB NOT NULL DEFAULT '[]';
@@ -0,0 +1,15 @@
+-- Runs reported back by event targets through the signed callback
+CREATE TABLE IF NOT EXISTS event_runs (
+    event_id TEXT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    run_id TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('running', 'succeeded', 'failed')),
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    error TEXT NOT NULL DEFAULT '',
+    output TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (event_id, run_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_runs_event_created ON event_runs(event_id, created_at DESC);
@@ -0,0 +1,6 @@
+UPDATE local_schedules
+SET payload = (payload -> 'payload') || jsonb_build_object('user_id', owner_id, 'rule_name', name)
+WHERE payload ? 'payload';
+UPDATE events SET payload = payload || jsonb_build_object('user_id', owner_id);
+
+DROP TABLE IF EXISTS event_target_schemas;
@@ -0,0 +1,16 @@
+-- JSON Schemas that event payloads must match for each kind of target
+CREATE TABLE IF NOT EXISTS event_target_schemas (
+    kind TEXT PRIMARY KEY CHECK (kind IN ('lambda', 'sqs', 'sns', 'api_destination')),
+    schema JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- The owner now travels in the delivery envelope rather than in the payload
+UPDATE events SET payload = payload - 'user_id';
+UPDATE local_schedules
+SET payload = jsonb_build_object(
+    'user_id', owner_id,
+    'rule_name', name,
+    'payload', payload - 'user_id' - 'rule_name'
+);
@@ -0,0 +1,13 @@
+-- Idempotency-Key headers seen on event creation. A key lasts as long as the
+-- event it created, and request_hash lets a reused key with a different
+-- request be told apart from a retry.
+CREATE TABLE IF NOT EXISTS event_idempotency_keys (
+    owner_id TEXT NOT NULL,
+    key TEXT NOT NULL,
+    request_hash TEXT NOT NULL,
+    event_id TEXT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (owner_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_idempotency_keys_event_id ON event_idempotency_keys (event_id);
@@ -0,0 +1,13 @@
+-- Where each user wants to be notified, one destination per channel
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    auth0_user_id TEXT NOT NULL REFERENCES users(auth0_user_id) ON DELETE CASCADE,
+    channel TEXT NOT NULL CHECK (channel IN ('discord', 'slack', 'http', 'email')),
+    destination TEXT NOT NULL,
+    kinds JSONB NOT NULL DEFAULT '[]',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (auth0_user_id, channel)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_preferences_kinds ON notification_preferences USING GIN (kinds);
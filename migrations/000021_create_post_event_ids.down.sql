@@ -0,0 +1 @@
+DROP SEQUENCE IF EXISTS post_event_ids;
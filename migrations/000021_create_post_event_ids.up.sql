@@ -0,0 +1,3 @@
+-- Orders post stream events across API instances; the ID of each event is
+-- sent to clients so they can resume after reconnecting.
+CREATE SEQUENCE IF NOT EXISTS post_event_ids;
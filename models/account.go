@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// DeletedUserID replaces the author of posts kept after an account is erased.
+const DeletedUserID = "deleted-user"
+
+// How an erased account's posts are handled.
+const (
+	DeletedPostsAnonymize = "anonymize"
+	DeletedPostsDelete    = "delete"
+)
+
+type UserProfile struct {
+	Auth0UserID string    `json:"auth0_user_id" db:"auth0_user_id"`
+	Email       *string   `json:"email,omitempty" db:"email"`
+	Status      string    `json:"status" db:"status"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+type AccountDeletion struct {
+	Auth0UserID  string     `json:"auth0_user_id" db:"auth0_user_id"`
+	RequestedAt  time.Time  `json:"requested_at" db:"requested_at"`
+	ScheduledFor time.Time  `json:"scheduled_for" db:"scheduled_for"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// UserDataExport is everything the API stores about a user.
+type UserDataExport struct {
+	Profile *UserProfile `json:"profile"`
+	Role    string       `json:"role"`
+	Posts   []Post       `json:"posts"`
+}
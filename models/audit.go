@@ -0,0 +1,86 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audit actions recorded by the API.
+const (
+	AuditActionLogin                  = "auth.login"
+	AuditActionUserCreate             = "user.create"
+	AuditActionUserRoleUpdate         = "user.role_update"
+	AuditActionUserDelete             = "user.delete"
+	AuditActionUserSuspend            = "user.suspend"
+	AuditActionUserBan                = "user.ban"
+	AuditActionUserReinstate          = "user.reinstate"
+	AuditActionUserEventQuotaUpdate   = "user.event_quota_update"
+	AuditActionRoleCreate             = "role.create"
+	AuditActionRolePermissionsUpdate  = "role.permissions_update"
+	AuditActionEventTargetCreate      = "event_target.create"
+	AuditActionEventTargetDelete      = "event_target.delete"
+	AuditActionEventSchemaPut         = "event_target_schema.put"
+	AuditActionEventSchemaDelete      = "event_target_schema.delete"
+	AuditActionEventsReconcile        = "events.reconcile"
+	AuditActionJobRetry               = "job.retry"
+	AuditActionWebhookCreate          = "webhook.create"
+	AuditActionWebhookUpdate          = "webhook.update"
+	AuditActionWebhookDelete          = "webhook.delete"
+	AuditActionWebhookRedeliver       = "webhook.redeliver"
+	AuditActionPostCreate             = "post.create"
+	AuditActionPostUpdate             = "post.update"
+	AuditActionPostDelete             = "post.delete"
+	AuditActionInviteCreate           = "invitation.create"
+	AuditActionInviteRevoke           = "invitation.revoke"
+	AuditActionInviteRedeem           = "invitation.redeem"
+	AuditActionImpersonationStart     = "impersonation.start"
+	AuditActionImpersonationEnd       = "impersonation.end"
+	AuditActionAccountDeletionRequest = "account.deletion_request"
+	AuditActionAccountDeletionCancel  = "account.deletion_cancel"
+)
+
+// Audit target types.
+const (
+	AuditTargetUser        = "user"
+	AuditTargetPost        = "post"
+	AuditTargetInvitation  = "invitation"
+	AuditTargetRole        = "role"
+	AuditTargetEventTarget = "event_target"
+	AuditTargetEventSchema = "event_target_schema"
+	AuditTargetEvent       = "event"
+	AuditTargetJob         = "job"
+	AuditTargetWebhook     = "webhook"
+)
+
+type AuditLog struct {
+	ID               int64           `json:"id" db:"id"`
+	ActorAuth0UserID string          `json:"actor_auth0_user_id" db:"actor_auth0_user_id"`
+	OnBehalfOf       string          `json:"on_behalf_of,omitempty" db:"on_behalf_of"`
+	Action           string          `json:"action" db:"action"`
+	TargetType       string          `json:"target_type" db:"target_type"`
+	TargetID         string          `json:"target_id" db:"target_id"`
+	Before           json.RawMessage `json:"before,omitempty" db:"before_state" swaggertype:"object"`
+	After            json.RawMessage `json:"after,omitempty" db:"after_state" swaggertype:"object"`
+	IPAddress        string          `json:"ip_address" db:"ip_address"`
+	UserAgent        string          `json:"user_agent" db:"user_agent"`
+	RequestID        string          `json:"request_id" db:"request_id"`
+	CreatedAt        time.Time       `json:"created_at" db:"created_at"`
+}
+
+type AuditLogFilter struct {
+	Actor      string
+	Action     string
+	TargetType string
+	TargetID   string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+type AuditLogPage struct {
+	Items    []AuditLog `json:"items"`
+	Total    int        `json:"total"`
+	Page     int        `json:"page"`
+	PageSize int        `json:"page_size"`
+}
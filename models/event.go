@@ -0,0 +1,171 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Event states, mirroring EventBridge rule states.
+const (
+	EventStateEnabled  = "enabled"
+	EventStateDisabled = "disabled"
+)
+
+// JSONObject is a JSON object stored as a JSONB column. Empty means {}.
+type JSONObject json.RawMessage
+
+func (o JSONObject) MarshalJSON() ([]byte, error) {
+	if len(o) == 0 {
+		return []byte("{}"), nil
+	}
+	return o, nil
+}
+
+func (o *JSONObject) UnmarshalJSON(data []byte) error {
+	*o = append((*o)[:0], data...)
+	return nil
+}
+
+func (o JSONObject) Value() (driver.Value, error) {
+	if len(o) == 0 {
+		return "{}", nil
+	}
+	return string(o), nil
+}
+
+func (o *JSONObject) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*o = nil
+	case []byte:
+		*o = append((*o)[:0], v...)
+	case string:
+		*o = JSONObject(v)
+	default:
+		return fmt.Errorf("cannot scan %T into JSONObject", src)
+	}
+	return nil
+}
+
+// StringSet is a list of distinct strings stored as a JSONB array.
+type StringSet []string
+
+// Has reports whether value is in the set.
+func (s StringSet) Has(value string) bool {
+	for _, v := range s {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (s StringSet) Value() (driver.Value, error) {
+	if s == nil {
+		return "[]", nil
+	}
+	raw, err := json.Marshal([]string(s))
+	if err != nil {
+		return nil, err
+	}
+	return string(raw), nil
+}
+
+func (s *StringSet) Scan(src interface{}) error {
+	var raw []byte
+	switch v := src.(type) {
+	case nil:
+		*s = nil
+		return nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into StringSet", src)
+	}
+	return json.Unmarshal(raw, (*[]string)(s))
+}
+
+// CreateEventRequest represents the structure for creating a new event.
+// Name only has to be unique among the caller's own events.
+type CreateEventRequest struct {
+	Name        string `json:"name" binding:"required" example:"my-scheduled-event"`
+	Description string `json:"description" example:"A scheduled event that runs daily"`
+	Schedule    string `json:"schedule" binding:"required" example:"cron(0 12 * * ? *)"` // cron(...), rate(...), at(...) or a bare cron expression
+	// Payload is any JSON object, checked against the schemas registered for
+	// the kinds of target it is sent to
+	Payload json.RawMessage `json:"payload" swaggertype:"object"`
+	// Targets receive the event, at most 5; none means the default target
+	Targets []EventTargetBinding `json:"targets,omitempty"`
+	EventTiming
+}
+
+// EventTiming holds the optional scheduling options beyond the expression.
+// Anything other than the defaults needs the EventBridge Scheduler or local backend.
+type EventTiming struct {
+	// TimeZone is the IANA zone cron fields and at(...) times are read in; empty means UTC
+	TimeZone string     `json:"timezone,omitempty" db:"timezone" example:"America/Chicago"`
+	StartAt  *time.Time `json:"start_at,omitempty" db:"start_at" example:"2024-03-20T00:00:00Z"`
+	EndAt    *time.Time `json:"end_at,omitempty" db:"end_at" example:"2024-12-31T00:00:00Z"`
+	// FlexibleWindowMinutes lets the run happen up to this many minutes late (0-1440)
+	FlexibleWindowMinutes int `json:"flexible_window_minutes,omitempty" db:"flexible_window_minutes" example:"15"`
+}
+
+// UpdateEventRequest changes an event; omitted fields are left as they are
+type UpdateEventRequest struct {
+	Description *string `json:"description" example:"A scheduled event that runs hourly"`
+	Schedule    *string `json:"schedule" example:"0 * * * ? *"`
+	// Payload replaces the whole payload when sent
+	Payload json.RawMessage `json:"payload" swaggertype:"object"`
+	// Targets replaces the whole list when sent; an empty list restores the default target
+	Targets []EventTargetBinding `json:"targets"`
+	// Sending any timing field replaces all of them, so omitted ones are cleared
+	*EventTiming
+}
+
+// Event is a scheduled event as stored in the events table
+type Event struct {
+	ID      string `json:"id" db:"id"`
+	OwnerID string `json:"owner_id" db:"owner_id"`
+	Name    string `json:"name" db:"name" example:"my-scheduled-event"`
+	// RuleName identifies the event's rule or schedule in the backend
+	RuleName    string `json:"rule_name" db:"rule_name" example:"go-api-3f9a1c2b7d4e5f60-0b6e2f4c9a8d4e1f8c3b5a7d9e1f2a4c"`
+	Description string `json:"description" db:"description" example:"A scheduled event that runs daily"`
+	Schedule    string `json:"schedule" db:"schedule" example:"0 12 * * ? *"`
+	EventTiming
+	Payload   JSONObject          `json:"payload" db:"payload" swaggertype:"object"`
+	Targets   EventTargetBindings `json:"targets" db:"targets"`
+	State     string              `json:"state" db:"state" example:"enabled"`
+	CreatedAt time.Time           `json:"created_at" db:"created_at" example:"2024-03-20T12:00:00Z"`
+	UpdatedAt time.Time           `json:"updated_at" db:"updated_at" example:"2024-03-20T12:00:00Z"`
+	// NextRuns is filled in for responses; paused events have none.
+	NextRuns []time.Time `json:"next_runs,omitempty" db:"-"`
+}
+
+// EventPage is one page of a user's events, newest first
+type EventPage struct {
+	Items    []Event `json:"items"`
+	Total    int     `json:"total" example:"12"`
+	Page     int     `json:"page" example:"1"`
+	PageSize int     `json:"page_size" example:"20"`
+}
+
+// PreviewScheduleRequest asks for the next fire times of a schedule expression
+type PreviewScheduleRequest struct {
+	Schedule string     `json:"schedule" binding:"required" example:"cron(0 12 * * ? *)"`
+	Count    int        `json:"count" example:"5"`
+	TimeZone string     `json:"timezone" example:"America/New_York"` // evaluates and displays the runs in this zone
+	StartAt  *time.Time `json:"start_at,omitempty"`
+	EndAt    *time.Time `json:"end_at,omitempty"`
+}
+
+// SchedulePreview lists the next fire times of a valid schedule expression
+type SchedulePreview struct {
+	Schedule   string      `json:"schedule" example:"0 12 * * ? *"`
+	Expression string      `json:"expression" example:"cron(0 12 * * ? *)"`
+	TimeZone   string      `json:"timezone" example:"America/New_York"`
+	NextRuns   []time.Time `json:"next_runs"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// Kinds of drift between stored events and the scheduler backend.
+const (
+	// DriftMissing is an event whose rule or schedule no longer exists
+	DriftMissing = "missing"
+	// DriftMismatch is a rule whose definition differs from the stored event
+	DriftMismatch = "mismatch"
+	// DriftOrphan is a rule in an owner's namespace with no stored event
+	DriftOrphan = "orphan"
+)
+
+// Repairs the reconciler can apply.
+const (
+	// RepairSyncQueued queued a job that puts the rule from the stored event
+	RepairSyncQueued = "sync_queued"
+	RepairDisabled   = "disabled"
+	RepairAdopted    = "adopted"
+)
+
+// Ways to treat orphaned rules.
+const (
+	OrphanReport  = ""
+	OrphanDisable = "disable"
+	OrphanAdopt   = "adopt"
+)
+
+// ReconcileRequest selects which drift to repair; the default only reports
+type ReconcileRequest struct {
+	// Recreate queues jobs that re-create missing rules and reapply mismatched ones from the stored events
+	Recreate bool `json:"recreate" example:"true"`
+	// Orphans is "disable", "adopt" (store the rule as an event) or empty to only report
+	Orphans string `json:"orphans" example:"disable"`
+}
+
+// EventDrift is one difference found by the reconciler
+type EventDrift struct {
+	Kind      string `json:"kind" example:"mismatch"`
+	OwnerID   string `json:"owner_id" example:"auth0|123456"`
+	EventName string `json:"event_name,omitempty" example:"my-scheduled-event"`
+	RuleName  string `json:"rule_name" example:"go-api-3f9a1c2b7d4e5f60-0b6e2f4c9a8d4e1f8c3b5a7d9e1f2a4c"`
+	// Fields lists what differs for mismatches
+	Fields      []string `json:"fields,omitempty" example:"expression,state"`
+	Repair      string   `json:"repair,omitempty" example:"sync_queued"`
+	RepairError string   `json:"repair_error,omitempty"`
+}
+
+// DriftReport is the outcome of one reconciliation pass
+type DriftReport struct {
+	CheckedAt time.Time    `json:"checked_at"`
+	Owners    int          `json:"owners" example:"12"`
+	Events    int          `json:"events" example:"40"`
+	Rules     int          `json:"rules" example:"41"`
+	Drift     []EventDrift `json:"drift"`
+	// Failures lists owners whose rules could not be listed
+	Failures []string `json:"failures,omitempty"`
+}
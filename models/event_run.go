@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Event run states. A run is running from its start report until it
+// reports success or failure.
+const (
+	EventRunStatusRunning   = "running"
+	EventRunStatusSucceeded = "succeeded"
+	EventRunStatusFailed    = "failed"
+)
+
+// Statuses a target may report for a run.
+const (
+	EventRunReportStarted   = "started"
+	EventRunReportSucceeded = "succeeded"
+	EventRunReportFailed    = "failed"
+)
+
+// EventRun is one execution of a scheduled event, as reported by its target
+type EventRun struct {
+	EventID    string     `json:"-" db:"event_id"`
+	RunID      string     `json:"run_id" db:"run_id" example:"c0ffee00-1234-5678-9abc-def012345678"`
+	Status     string     `json:"status" db:"status" example:"succeeded"`
+	StartedAt  *time.Time `json:"started_at,omitempty" db:"started_at" example:"2024-03-20T12:00:00Z"`
+	FinishedAt *time.Time `json:"finished_at,omitempty" db:"finished_at" example:"2024-03-20T12:00:02Z"`
+	// DurationMs is known once both the start and the finish were reported
+	DurationMs *int64    `json:"duration_ms,omitempty" db:"duration_ms" example:"2150"`
+	Error      string    `json:"error,omitempty" db:"error"`
+	Output     string    `json:"output,omitempty" db:"output"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// EventRunPage is one page of an event's runs, newest first
+type EventRunPage struct {
+	Items    []EventRun `json:"items"`
+	Total    int        `json:"total" example:"42"`
+	Page     int        `json:"page" example:"1"`
+	PageSize int        `json:"page_size" example:"20"`
+}
+
+// EventRunReport is what a target sends to the run callback. RuleName is the
+// rule_name stamped into every delivered payload.
+type EventRunReport struct {
+	RuleName string `json:"rule_name" example:"go-api-3f9a1c2b7d4e5f60-0b6e2f4c9a8d4e1f8c3b5a7d9e1f2a4c"`
+	RunID    string `json:"run_id" example:"c0ffee00-1234-5678-9abc-def012345678"`
+	// Status is started, succeeded or failed
+	Status string `json:"status" example:"succeeded"`
+	// Time defaults to when the report is received
+	Time   *time.Time `json:"time,omitempty" example:"2024-03-20T12:00:02Z"`
+	Error  string     `json:"error,omitempty"`
+	Output string     `json:"output,omitempty"`
+}
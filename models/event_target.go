@@ -0,0 +1,93 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// EventTarget is a destination an administrator has made available to
+// scheduled events, such as a Lambda function, SQS queue, SNS topic or an
+// HTTP webhook behind an EventBridge API destination.
+type EventTarget struct {
+	Name string `json:"name" db:"name" example:"orders-queue"`
+	// Kind is lambda, sqs, sns or api_destination
+	Kind string `json:"kind" db:"kind" example:"sqs"`
+	Arn  string `json:"arn" db:"arn" example:"arn:aws:sqs:us-east-1:123456789012:orders"`
+	// RoleArn is the role EventBridge assumes to deliver; API destinations need one
+	RoleArn     string    `json:"role_arn,omitempty" db:"role_arn" example:"arn:aws:iam::123456789012:role/events-invoke"`
+	Description string    `json:"description" db:"description" example:"Queue consumed by the order service"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// EventTargetSummary is what users see of a target when choosing one.
+type EventTargetSummary struct {
+	Name        string `json:"name" db:"name" example:"orders-queue"`
+	Kind        string `json:"kind" db:"kind" example:"sqs"`
+	Description string `json:"description" db:"description" example:"Queue consumed by the order service"`
+}
+
+// CreateEventTargetRequest registers or replaces a target
+type CreateEventTargetRequest struct {
+	Name        string `json:"name" binding:"required" example:"orders-queue"`
+	Kind        string `json:"kind" binding:"required" example:"sqs"`
+	Arn         string `json:"arn" binding:"required" example:"arn:aws:sqs:us-east-1:123456789012:orders"`
+	RoleArn     string `json:"role_arn" example:"arn:aws:iam::123456789012:role/events-invoke"`
+	Description string `json:"description" example:"Queue consumed by the order service"`
+}
+
+// EventTargetBinding sends an event to a registered target, optionally
+// reshaping the payload for it.
+type EventTargetBinding struct {
+	Target string `json:"target" binding:"required" example:"orders-queue"`
+	// InputTemplate is JSON in which <user_id>, <rule_name> and <payload.path>
+	// placeholders take values from the delivery envelope and <time> the fire
+	// time; empty sends the envelope unchanged
+	InputTemplate string `json:"input_template,omitempty" example:"{\"order\":\"<payload.order.id>\",\"at\":\"<time>\"}"`
+}
+
+// EventTargetSchema is the JSON Schema payloads must match to be sent to
+// targets of one kind.
+type EventTargetSchema struct {
+	Kind      string     `json:"kind" db:"kind" example:"sqs"`
+	Schema    JSONObject `json:"schema" db:"schema" swaggertype:"object"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// PutEventTargetSchemaRequest registers or replaces the schema for a kind
+type PutEventTargetSchemaRequest struct {
+	Schema json.RawMessage `json:"schema" binding:"required" swaggertype:"object"`
+}
+
+// EventTargetBindings is a list of bindings stored as a JSONB column.
+type EventTargetBindings []EventTargetBinding
+
+func (b EventTargetBindings) Value() (driver.Value, error) {
+	if b == nil {
+		return "[]", nil
+	}
+	raw, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	return string(raw), nil
+}
+
+func (b *EventTargetBindings) Scan(src interface{}) error {
+	var raw []byte
+	switch v := src.(type) {
+	case nil:
+		*b = nil
+		return nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into EventTargetBindings", src)
+	}
+	return json.Unmarshal(raw, b)
+}
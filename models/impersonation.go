@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+type ImpersonationSession struct {
+	ID                string     `json:"id" db:"id"`
+	ActorAuth0UserID  string     `json:"actor_auth0_user_id" db:"actor_auth0_user_id"`
+	TargetAuth0UserID string     `json:"target_auth0_user_id" db:"target_auth0_user_id"`
+	Reason            string     `json:"reason" db:"reason"`
+	StartedAt         time.Time  `json:"started_at" db:"started_at"`
+	ExpiresAt         time.Time  `json:"expires_at" db:"expires_at"`
+	EndedAt           *time.Time `json:"ended_at,omitempty" db:"ended_at"`
+}
+
+type StartImpersonationRequest struct {
+	TargetAuth0UserID string `json:"target_auth0_user_id" binding:"required"`
+	Reason            string `json:"reason" binding:"required"`
+	DurationMinutes   int    `json:"duration_minutes" example:"15"`
+}
+
+type StartImpersonationResponse struct {
+	Session *ImpersonationSession `json:"session"`
+	Token   string                `json:"token"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+type Invitation struct {
+	ID         string     `json:"id" db:"id"`
+	Email      string     `json:"email" db:"email"`
+	Role       string     `json:"role" db:"role"`
+	InvitedBy  string     `json:"invited_by" db:"invited_by"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	RedeemedAt *time.Time `json:"redeemed_at,omitempty" db:"redeemed_at"`
+	RedeemedBy *string    `json:"redeemed_by,omitempty" db:"redeemed_by"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+type CreateInvitationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required"`
+}
+
+type CreateInvitationResponse struct {
+	Invitation *Invitation `json:"invitation"`
+	InviteURL  string      `json:"invite_url"`
+}
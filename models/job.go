@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Job states. A failed job goes back to pending until it runs out of
+// attempts, when it is dead-lettered.
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusDead      = "dead"
+)
+
+// Kinds of background job.
+const (
+	// JobKindNotifyPostCreated fans a new post out to its subscribers
+	JobKindNotifyPostCreated = "notify.post_created"
+	// JobKindNotifyEventFailed fans a failed run out to the event's owner
+	JobKindNotifyEventFailed = "notify.event_failed"
+	// JobKindNotifyDeliver sends one notification to one channel
+	JobKindNotifyDeliver = "notify.deliver"
+	// JobKindWebhookEvent fans an event out to the webhooks subscribed to it
+	JobKindWebhookEvent = "webhook.event"
+	// JobKindWebhookDeliver sends one delivery to its webhook
+	JobKindWebhookDeliver = "webhook.deliver"
+	// JobKindEventScheduleSync puts or deletes an event's schedule to match the stored event
+	JobKindEventScheduleSync = "event.schedule_sync"
+)
+
+// OutboxMessage is a job to enqueue once the change that caused it commits.
+type OutboxMessage struct {
+	Kind    string     `db:"kind"`
+	Payload JSONObject `db:"payload"`
+}
+
+// Job is a unit of background work.
+type Job struct {
+	ID          int64      `json:"id" db:"id" example:"42"`
+	Kind        string     `json:"kind" db:"kind" example:"notify.deliver"`
+	Payload     JSONObject `json:"payload" db:"payload" swaggertype:"object"`
+	Status      string     `json:"status" db:"status" example:"pending"`
+	Attempts    int        `json:"attempts" db:"attempts" example:"1"`
+	MaxAttempts int        `json:"max_attempts" db:"max_attempts" example:"8"`
+	// RunAt is when a pending job is next due
+	RunAt     time.Time  `json:"run_at" db:"run_at"`
+	LockedAt  *time.Time `json:"locked_at,omitempty" db:"locked_at"`
+	LastError string     `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// JobPage is one page of jobs, newest first
+type JobPage struct {
+	Items    []Job `json:"items"`
+	Total    int   `json:"total" example:"42"`
+	Page     int   `json:"page" example:"1"`
+	PageSize int   `json:"page_size" example:"20"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Things a user can be notified about.
+const (
+	NotificationPostCreated = "post.created"
+	NotificationEventFailed = "event.failed"
+)
+
+// NotificationKinds lists every kind a preference may subscribe to.
+var NotificationKinds = []string{NotificationPostCreated, NotificationEventFailed}
+
+// NotificationPreference is where one user receives notifications on one
+// channel, and which kinds they want there.
+type NotificationPreference struct {
+	Auth0UserID string `json:"-" db:"auth0_user_id"`
+	// Channel is discord, slack, http or email
+	Channel string `json:"channel" db:"channel" example:"discord"`
+	// Destination is the webhook URL, or the address for email
+	Destination string    `json:"destination" db:"destination" example:"https://discord.com/api/webhooks/123/abc"`
+	Kinds       StringSet `json:"kinds" db:"kinds" swaggertype:"array,string" example:"post.created,event.failed"`
+	Enabled     bool      `json:"enabled" db:"enabled" example:"true"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// PutNotificationPreferenceRequest sets the preference for one channel.
+// Enabled defaults to true.
+type PutNotificationPreferenceRequest struct {
+	Destination string   `json:"destination" binding:"required" example:"https://discord.com/api/webhooks/123/abc"`
+	Kinds       []string `json:"kinds" binding:"required" example:"post.created,event.failed"`
+	Enabled     *bool    `json:"enabled" example:"true"`
+}
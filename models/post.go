@@ -23,3 +23,14 @@ type UpdatePostRequest struct {
 	Title   string `json:"title"`
 	Content string `json:"content"`
 }
+
+// PostEvent is a change to a post, as sent on the post stream. Types are
+// named like the webhook events.
+type PostEvent struct {
+	// ID orders events across API instances; clients resume after it
+	ID     string `json:"-"`
+	Type   string `json:"type" example:"post.created"`
+	PostID string `json:"post_id"`
+	// Post is the post when the event was received; omitted for deletions
+	Post *Post `json:"post,omitempty"`
+}
@@ -0,0 +1,30 @@
+package models
+
+// Permissions that can be granted to roles.
+const (
+	PermissionEventsCreate = "events:create"
+)
+
+// KnownPermissions lists every permission a role may be granted.
+var KnownPermissions = []string{
+	PermissionEventsCreate,
+}
+
+type Role struct {
+	Name        string   `json:"name" db:"name" example:"event_creator"`
+	Permissions []string `json:"permissions" example:"events:create"`
+}
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required" example:"event_creator"`
+	Permissions []string `json:"permissions" example:"events:create"`
+}
+
+type SetRolePermissionsRequest struct {
+	Permissions []string `json:"permissions" example:"events:create"`
+}
+
+// SetEventQuotaRequest sets a user's active event limit; null restores the default.
+type SetEventQuotaRequest struct {
+	MaxActiveEvents *int `json:"max_active_events" example:"10"`
+}
@@ -2,6 +2,13 @@ package models
 
 import "time"
 
+// User account states.
+const (
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
+	UserStatusBanned    = "banned"
+)
+
 type User struct {
 	Auth0UserID string    `json:"auth0_user_id" db:"auth0_user_id"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
@@ -10,4 +17,22 @@ type User struct {
 type UserWithRole struct {
 	Auth0UserID string `json:"auth0_user_id" db:"auth0_user_id"`
 	Role        string `json:"role" db:"role"`
+	Status      string `json:"status" db:"status"`
+}
+
+type UserStatus struct {
+	Status          string     `json:"status" db:"status"`
+	Reason          string     `json:"reason,omitempty" db:"status_reason"`
+	SuspendedUntil  *time.Time `json:"suspended_until,omitempty" db:"suspended_until"`
+	StatusChangedBy string     `json:"status_changed_by,omitempty" db:"status_changed_by"`
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty" db:"status_changed_at"`
+}
+
+type SuspendUserRequest struct {
+	Reason string    `json:"reason" binding:"required"`
+	Until  time.Time `json:"until" binding:"required" example:"2024-03-27T12:00:00Z"`
+}
+
+type BanUserRequest struct {
+	Reason string `json:"reason" binding:"required"`
 }
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event types sent to webhooks.
+const (
+	WebhookEventPostCreated     = "post.created"
+	WebhookEventPostUpdated     = "post.updated"
+	WebhookEventPostDeleted     = "post.deleted"
+	WebhookEventUserCreated     = "user.created"
+	WebhookEventUserRoleUpdated = "user.role_updated"
+	WebhookEventUserSuspended   = "user.suspended"
+	WebhookEventUserBanned      = "user.banned"
+	WebhookEventUserReinstated  = "user.reinstated"
+	WebhookEventUserDeleted     = "user.deleted"
+)
+
+// WebhookEventTypes lists every type an admin webhook may subscribe to.
+var WebhookEventTypes = []string{
+	WebhookEventPostCreated, WebhookEventPostUpdated, WebhookEventPostDeleted,
+	WebhookEventUserCreated, WebhookEventUserRoleUpdated, WebhookEventUserSuspended,
+	WebhookEventUserBanned, WebhookEventUserReinstated, WebhookEventUserDeleted,
+}
+
+// UserWebhookEventTypes lists the types any user's webhook may subscribe to.
+// Posts are public; user lifecycle events are for admin webhooks only.
+var UserWebhookEventTypes = []string{WebhookEventPostCreated, WebhookEventPostUpdated, WebhookEventPostDeleted}
+
+// Webhook delivery states. A pending delivery is retried until it succeeds
+// or runs out of attempts.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// Webhook is an endpoint subscribed to event types. Admin webhooks have no owner.
+type Webhook struct {
+	ID          string    `json:"id" db:"id" example:"5f0c6d1e-8a4b-4c2d-9e3f-1a2b3c4d5e6f"`
+	OwnerID     *string   `json:"-" db:"owner_id"`
+	URL         string    `json:"url" db:"url" example:"https://example.com/hooks/posts"`
+	Description string    `json:"description" db:"description"`
+	Secret      string    `json:"-" db:"secret"`
+	EventTypes  StringSet `json:"event_types" db:"event_types" swaggertype:"array,string" example:"post.created,post.deleted"`
+	Enabled     bool      `json:"enabled" db:"enabled" example:"true"`
+	// ConsecutiveFailures counts failed attempts since the last success; the
+	// webhook is disabled when it reaches the limit
+	ConsecutiveFailures int        `json:"consecutive_failures" db:"consecutive_failures" example:"0"`
+	DisabledReason      string     `json:"disabled_reason,omitempty" db:"disabled_reason"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty" db:"disabled_at"`
+	CreatedAt           time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// CreatedWebhook is returned once, on creation, with the signing secret.
+type CreatedWebhook struct {
+	Webhook
+	Secret string `json:"secret" example:"whsec_3f9a1c2b7d4e5f60a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718"`
+}
+
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" binding:"required" example:"https://example.com/hooks/posts"`
+	EventTypes  []string `json:"event_types" binding:"required" example:"post.created,post.deleted"`
+	Description string   `json:"description" example:"Search indexer"`
+}
+
+// UpdateWebhookRequest changes only the fields given. Enabling a webhook
+// clears its failure count.
+type UpdateWebhookRequest struct {
+	URL         *string  `json:"url" example:"https://example.com/hooks/posts"`
+	EventTypes  []string `json:"event_types" example:"post.created"`
+	Description *string  `json:"description"`
+	Enabled     *bool    `json:"enabled" example:"true"`
+}
+
+// WebhookEvent is the body of every delivery.
+type WebhookEvent struct {
+	// ID is the same for every delivery of the event, redeliveries included
+	ID        string          `json:"id" example:"8c1d2e3f-4a5b-6c7d-8e9f-0a1b2c3d4e5f"`
+	Type      string          `json:"type" example:"post.created"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data" swaggertype:"object"`
+}
+
+// WebhookDelivery is one event sent to one webhook, with its latest attempt.
+type WebhookDelivery struct {
+	ID             string     `json:"id" db:"id" example:"0b6e2f4c-9a8d-4e1f-8c3b-5a7d9e1f2a4c"`
+	WebhookID      string     `json:"webhook_id" db:"webhook_id"`
+	EventID        string     `json:"event_id" db:"event_id"`
+	EventType      string     `json:"event_type" db:"event_type" example:"post.created"`
+	Payload        JSONObject `json:"payload" db:"payload" swaggertype:"object"`
+	Status         string     `json:"status" db:"status" example:"succeeded"`
+	Attempts       int        `json:"attempts" db:"attempts" example:"1"`
+	ResponseStatus *int       `json:"response_status,omitempty" db:"response_status" example:"200"`
+	ResponseBody   string     `json:"response_body,omitempty" db:"response_body"`
+	Error          string     `json:"error,omitempty" db:"error"`
+	DurationMs     *int64     `json:"duration_ms,omitempty" db:"duration_ms" example:"120"`
+	// RedeliveryOf is the delivery this one manually repeats
+	RedeliveryOf *string    `json:"redelivery_of,omitempty" db:"redelivery_of"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+	DeliveredAt  *time.Time `json:"delivered_at,omitempty" db:"delivered_at"`
+}
+
+// WebhookDeliveryPage is one page of a webhook's deliveries, newest first
+type WebhookDeliveryPage struct {
+	Items    []WebhookDelivery `json:"items"`
+	Total    int               `json:"total" example:"42"`
+	Page     int               `json:"page" example:"1"`
+	PageSize int               `json:"page_size" example:"20"`
+}
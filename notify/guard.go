@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// CheckPublicHost refuses localhost and loopback, private and link-local
+// addresses, so user-supplied destinations cannot be aimed at internal
+// services. Names that resolve to such addresses are refused by
+// NewGuardedClient when dialing.
+func CheckPublicHost(host string) error {
+	host = strings.ToLower(host)
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errors.New("must not point at this host")
+	}
+	if ip := net.ParseIP(host); ip != nil && !IsPublicIP(ip) {
+		return errors.New("must not point at a private address")
+	}
+	return nil
+}
+
+// IsPublicIP reports whether ip is reachable on the public internet.
+func IsPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
+
+// NewGuardedClient returns a client for user-supplied destinations. It does
+// not follow redirects or use a proxy, and will not connect to addresses
+// IsPublicIP refuses.
+func NewGuardedClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !IsPublicIP(ip) {
+				return fmt.Errorf("refusing to connect to %s", host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,79 @@
+// Package notify delivers short notifications to users over chat webhooks,
+// plain HTTP endpoints and email.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Channels a user can receive notifications on.
+const (
+	ChannelDiscord = "discord"
+	ChannelSlack   = "slack"
+	ChannelHTTP    = "http"
+	ChannelEmail   = "email"
+)
+
+// Channels lists every channel a notifier may be registered for.
+var Channels = []string{ChannelDiscord, ChannelSlack, ChannelHTTP, ChannelEmail}
+
+// ErrInvalidDestination is returned for destinations a notifier cannot deliver to.
+var ErrInvalidDestination = errors.New("invalid notification destination")
+
+// Message is one notification. Kind names what happened, such as
+// "post.created"; Data carries the details for machine consumers.
+type Message struct {
+	Kind  string      `json:"kind"`
+	Title string      `json:"title"`
+	Text  string      `json:"text"`
+	Data  interface{} `json:"data,omitempty"`
+}
+
+// Notifier delivers messages over one channel. A destination is a webhook
+// URL or an email address, depending on the channel.
+type Notifier interface {
+	// Validate reports whether the notifier can deliver to destination.
+	Validate(destination string) error
+	Notify(ctx context.Context, destination string, msg Message) error
+}
+
+// IsChannel reports whether channel is one of Channels.
+func IsChannel(channel string) bool {
+	for _, known := range Channels {
+		if channel == known {
+			return true
+		}
+	}
+	return false
+}
+
+// validateHTTPS accepts absolute https URLs. When hosts are given the URL
+// must be on one of them and under pathPrefix.
+func validateHTTPS(destination, pathPrefix string, hosts ...string) error {
+	u, err := url.Parse(destination)
+	if err != nil || u.Scheme != "https" || u.Host == "" || u.User != nil {
+		return fmt.Errorf("%w: must be an https URL", ErrInvalidDestination)
+	}
+	if len(hosts) == 0 {
+		return nil
+	}
+	for _, host := range hosts {
+		if strings.EqualFold(u.Hostname(), host) && strings.HasPrefix(u.Path, pathPrefix) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: must start with https://%s%s", ErrInvalidDestination, hosts[0], pathPrefix)
+}
+
+// truncate cuts s to at most n runes, marking the cut with an ellipsis.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPConfig is the mail server notifications are relayed through. Username
+// may be empty for servers that don't require authentication.
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPNotifier emails messages through an SMTP relay, upgrading the
+// connection with STARTTLS.
+type SMTPNotifier struct {
+	config SMTPConfig
+	from   *mail.Address
+}
+
+// NewSMTPNotifier checks the sender address up front. Port defaults to 587.
+func NewSMTPNotifier(config SMTPConfig) (*SMTPNotifier, error) {
+	if config.Host == "" {
+		return nil, errors.New("SMTP notifier needs a host")
+	}
+	if config.Port == "" {
+		config.Port = "587"
+	}
+	from, err := mail.ParseAddress(config.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP sender address: %w", err)
+	}
+	return &SMTPNotifier{config: config, from: from}, nil
+}
+
+// Validate accepts a single bare address such as "user@example.com".
+func (n *SMTPNotifier) Validate(destination string) error {
+	address, err := mail.ParseAddress(destination)
+	if err != nil || address.Address != destination {
+		return fmt.Errorf("%w: must be an email address", ErrInvalidDestination)
+	}
+	return nil
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, destination string, msg Message) error {
+	if err := n.Validate(destination); err != nil {
+		return err
+	}
+
+	dialer := net.Dialer{Timeout: DefaultTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(n.config.Host, n.config.Port))
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(DefaultTimeout)
+	}
+	_ = conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, n.config.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if err := client.StartTLS(&tls.Config{ServerName: n.config.Host}); err != nil {
+		return fmt.Errorf("SMTP server refused STARTTLS: %w", err)
+	}
+	if n.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.config.Username, n.config.Password, n.config.Host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+	if err := client.Mail(n.from.Address); err != nil {
+		return fmt.Errorf("SMTP server rejected sender: %w", err)
+	}
+	if err := client.Rcpt(destination); err != nil {
+		return fmt.Errorf("SMTP server rejected recipient: %w", err)
+	}
+	body, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if _, err := body.Write(buildEmail(n.from, destination, msg, time.Now())); err != nil {
+		body.Close()
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if err := body.Close(); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return client.Quit()
+}
+
+// buildEmail renders a plain-text message. The subject is encoded, so no
+// title can inject headers.
+func buildEmail(from *mail.Address, to string, msg Message, now time.Time) []byte {
+	subject := msg.Title
+	if subject == "" {
+		subject = msg.Kind
+	}
+	text := strings.ReplaceAll(msg.Text, "\r\n", "\n")
+
+	var b strings.Builder
+	b.WriteString("From: " + from.String() + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	b.WriteString("Date: " + now.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(text, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
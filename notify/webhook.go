@@ -0,0 +1,139 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultTimeout bounds one webhook delivery when no client is given.
+const DefaultTimeout = 10 * time.Second
+
+// discordMaxContent is the longest message content Discord accepts.
+const discordMaxContent = 2000
+
+// DiscordNotifier posts messages to Discord channel webhooks.
+type DiscordNotifier struct {
+	client *http.Client
+}
+
+// NewDiscordNotifier delivers through client, or a client with
+// DefaultTimeout when client is nil.
+func NewDiscordNotifier(client *http.Client) *DiscordNotifier {
+	return &DiscordNotifier{client: clientOrDefault(client)}
+}
+
+type discordWebhookPayload struct {
+	Content string `json:"content"`
+}
+
+func (n *DiscordNotifier) Validate(destination string) error {
+	return validateHTTPS(destination, "/api/webhooks/", "discord.com", "discordapp.com")
+}
+
+func (n *DiscordNotifier) Notify(ctx context.Context, destination string, msg Message) error {
+	content := msg.Text
+	if msg.Title != "" {
+		content = "**" + msg.Title + "**\n" + msg.Text
+	}
+	return postJSON(ctx, n.client, destination, discordWebhookPayload{Content: truncate(content, discordMaxContent)})
+}
+
+// SlackNotifier posts messages to Slack incoming webhooks.
+type SlackNotifier struct {
+	client *http.Client
+}
+
+// NewSlackNotifier delivers through client, or a client with DefaultTimeout
+// when client is nil.
+func NewSlackNotifier(client *http.Client) *SlackNotifier {
+	return &SlackNotifier{client: clientOrDefault(client)}
+}
+
+type slackWebhookPayload struct {
+	Text string `json:"text"`
+}
+
+func (n *SlackNotifier) Validate(destination string) error {
+	return validateHTTPS(destination, "/services/", "hooks.slack.com")
+}
+
+func (n *SlackNotifier) Notify(ctx context.Context, destination string, msg Message) error {
+	text := msg.Text
+	if msg.Title != "" {
+		text = "*" + msg.Title + "*\n" + msg.Text
+	}
+	return postJSON(ctx, n.client, destination, slackWebhookPayload{Text: text})
+}
+
+// HTTPNotifier posts the Message itself as JSON to any public https endpoint.
+type HTTPNotifier struct {
+	client *http.Client
+}
+
+// NewHTTPNotifier delivers through client, or NewGuardedClient with
+// DefaultTimeout when client is nil.
+func NewHTTPNotifier(client *http.Client) *HTTPNotifier {
+	if client == nil {
+		client = NewGuardedClient(DefaultTimeout)
+	}
+	return &HTTPNotifier{client: client}
+}
+
+func (n *HTTPNotifier) Validate(destination string) error {
+	if err := validateHTTPS(destination, ""); err != nil {
+		return err
+	}
+	u, _ := url.Parse(destination)
+	if err := CheckPublicHost(u.Hostname()); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDestination, err)
+	}
+	return nil
+}
+
+func (n *HTTPNotifier) Notify(ctx context.Context, destination string, msg Message) error {
+	return postJSON(ctx, n.client, destination, msg)
+}
+
+func clientOrDefault(client *http.Client) *http.Client {
+	if client == nil {
+		return &http.Client{Timeout: DefaultTimeout}
+	}
+	return client
+}
+
+// postJSON posts body and treats any non-2xx response as a failure.
+func postJSON(ctx context.Context, client *http.Client, destination string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, destination, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to build notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// Drop the URL from the error, since webhook URLs carry their token
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to deliver notification: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notification endpoint returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type AccountRepository interface {
+	GetProfile(auth0UserID string) (*models.UserProfile, error)
+	ListPostsByAuthor(auth0UserID string) ([]models.Post, error)
+	ScheduleDeletion(auth0UserID string, scheduledFor time.Time) (*models.AccountDeletion, error)
+	GetPendingDeletion(auth0UserID string) (*models.AccountDeletion, error)
+	CancelDeletion(auth0UserID string) error
+	ListDueDeletions(limit int) ([]models.AccountDeletion, error)
+	EraseUser(auth0UserID, postsMode string, outbox ...models.OutboxMessage) error
+}
+
+type accountRepository struct {
+	db *sqlx.DB
+}
+
+func NewAccountRepository(db *sqlx.DB) AccountRepository {
+	return &accountRepository{db: db}
+}
+
+func (r *accountRepository) GetProfile(auth0UserID string) (*models.UserProfile, error) {
+	var profile models.UserProfile
+	err := r.db.Get(&profile, `SELECT auth0_user_id, email, status, created_at FROM users WHERE auth0_user_id = $1`, auth0UserID)
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// ListPostsByAuthor includes posts hidden from public listings.
+func (r *accountRepository) ListPostsByAuthor(auth0UserID string) ([]models.Post, error) {
+	var posts []models.Post
+	err := r.db.Select(&posts, "SELECT id, title, content, auth0_user_id, created_at, updated_at, slug FROM posts WHERE auth0_user_id = $1 ORDER BY created_at DESC", auth0UserID)
+	return posts, err
+}
+
+func (r *accountRepository) ScheduleDeletion(auth0UserID string, scheduledFor time.Time) (*models.AccountDeletion, error) {
+	var deletion models.AccountDeletion
+	err := r.db.Get(&deletion, `
+		INSERT INTO account_deletions (auth0_user_id, scheduled_for)
+		VALUES ($1, $2)
+		ON CONFLICT (auth0_user_id) DO UPDATE
+			SET requested_at = NOW(), scheduled_for = EXCLUDED.scheduled_for, completed_at = NULL
+			WHERE account_deletions.completed_at IS NOT NULL
+		RETURNING auth0_user_id, requested_at, scheduled_for, completed_at
+	`, auth0UserID, scheduledFor)
+	if errors.Is(err, sql.ErrNoRows) {
+		// A deletion is already pending; keep its original schedule
+		return r.GetPendingDeletion(auth0UserID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &deletion, nil
+}
+
+func (r *accountRepository) GetPendingDeletion(auth0UserID string) (*models.AccountDeletion, error) {
+	var deletion models.AccountDeletion
+	err := r.db.Get(&deletion, `
+		SELECT auth0_user_id, requested_at, scheduled_for, completed_at
+		FROM account_deletions
+		WHERE auth0_user_id = $1 AND completed_at IS NULL
+	`, auth0UserID)
+	if err != nil {
+		return nil, err
+	}
+	return &deletion, nil
+}
+
+// CancelDeletion returns sql.ErrNoRows when no deletion is pending.
+func (r *accountRepository) CancelDeletion(auth0UserID string) error {
+	return expectOneRow(r.db.Exec(
+		`DELETE FROM account_deletions WHERE auth0_user_id = $1 AND completed_at IS NULL`,
+		auth0UserID,
+	))
+}
+
+func (r *accountRepository) ListDueDeletions(limit int) ([]models.AccountDeletion, error) {
+	var deletions []models.AccountDeletion
+	err := r.db.Select(&deletions, `
+		SELECT auth0_user_id, requested_at, scheduled_for, completed_at
+		FROM account_deletions
+		WHERE completed_at IS NULL AND scheduled_for <= NOW()
+		ORDER BY scheduled_for
+		LIMIT $1
+	`, limit)
+	return deletions, err
+}
+
+// EraseUser removes the user and their role, deletes or anonymizes their
+// posts, marks the deletion complete and records the outbox messages, all in
+// one transaction.
+func (r *accountRepository) EraseUser(auth0UserID, postsMode string, outbox ...models.OutboxMessage) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if postsMode == models.DeletedPostsDelete {
+		_, err = tx.Exec(`DELETE FROM posts WHERE auth0_user_id = $1`, auth0UserID)
+	} else {
+		_, err = tx.Exec(`UPDATE posts SET auth0_user_id = $2 WHERE auth0_user_id = $1`, auth0UserID, models.DeletedUserID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(`DELETE FROM users WHERE auth0_user_id = $1`, auth0UserID); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(`UPDATE account_deletions SET completed_at = NOW() WHERE auth0_user_id = $1`, auth0UserID); err != nil {
+		return err
+	}
+
+	if err = insertOutbox(tx, outbox); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
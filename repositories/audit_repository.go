@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// AuditRepository is append-only: entries can be recorded and read but never changed.
+type AuditRepository interface {
+	Create(entry *models.AuditLog) error
+	List(filter models.AuditLogFilter) ([]models.AuditLog, error)
+	Count(filter models.AuditLogFilter) (int, error)
+}
+
+type auditRepository struct {
+	db *sqlx.DB
+}
+
+func NewAuditRepository(db *sqlx.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) Create(entry *models.AuditLog) error {
+	return r.db.QueryRowx(
+		`INSERT INTO audit_logs (actor_auth0_user_id, on_behalf_of, action, target_type, target_id, before_state, after_state, ip_address, user_agent, request_id)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		 RETURNING id, created_at`,
+		entry.ActorAuth0UserID, entry.OnBehalfOf, entry.Action, entry.TargetType, entry.TargetID,
+		nullableJSON(entry.Before), nullableJSON(entry.After),
+		entry.IPAddress, entry.UserAgent, entry.RequestID,
+	).Scan(&entry.ID, &entry.CreatedAt)
+}
+
+func (r *auditRepository) List(filter models.AuditLogFilter) ([]models.AuditLog, error) {
+	where, args := auditWhereClause(filter)
+	query := `SELECT id, actor_auth0_user_id, on_behalf_of, action, target_type, target_id, before_state, after_state,
+			  ip_address, user_agent, request_id, created_at
+			  FROM audit_logs` + where + ` ORDER BY created_at DESC, id DESC`
+
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	var entries []models.AuditLog
+	err := r.db.Select(&entries, query, args...)
+	return entries, err
+}
+
+func (r *auditRepository) Count(filter models.AuditLogFilter) (int, error) {
+	where, args := auditWhereClause(filter)
+	var total int
+	err := r.db.Get(&total, `SELECT COUNT(*) FROM audit_logs`+where, args...)
+	return total, err
+}
+
+func auditWhereClause(filter models.AuditLogFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Actor != "" {
+		add("actor_auth0_user_id = $%d", filter.Actor)
+	}
+	if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+	if filter.TargetType != "" {
+		add("target_type = $%d", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		add("target_id = $%d", filter.TargetID)
+	}
+	if filter.From != nil {
+		add("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		add("created_at < $%d", *filter.To)
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,214 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// NoActiveEventLimit lets Create and Update enable an event however many
+// its owner already has enabled.
+const NoActiveEventLimit = -1
+
+// ErrActiveEventLimit is returned when saving an enabled event would give its
+// owner more than maxActive enabled events.
+var ErrActiveEventLimit = errors.New("active event limit reached")
+
+type EventRepository interface {
+	Create(event *models.Event, maxActive int, outbox ...models.OutboxMessage) error
+	CreateIdempotent(event *models.Event, key, requestHash string, maxActive int, outbox ...models.OutboxMessage) error
+	GetByIdempotencyKey(ownerID, key string) (*models.Event, string, error)
+	GetByOwnerAndName(ownerID, name string) (*models.Event, error)
+	GetByRuleName(ruleName string) (*models.Event, error)
+	ListByOwner(ownerID string) ([]models.Event, error)
+	ListAll() ([]models.Event, error)
+	ListPageByOwner(ownerID string, limit, offset int) ([]models.Event, error)
+	CountByOwner(ownerID string) (int, error)
+	Update(event *models.Event, maxActive int, outbox ...models.OutboxMessage) error
+	Delete(id string, outbox ...models.OutboxMessage) error
+}
+
+type eventRepository struct {
+	db *sqlx.DB
+}
+
+func NewEventRepository(db *sqlx.DB) EventRepository {
+	return &eventRepository{db: db}
+}
+
+const eventColumns = `id, owner_id, name, rule_name, description, schedule, timezone, start_at, end_at,
+	flexible_window_minutes, payload, targets, state, created_at, updated_at`
+
+// Create inserts the event along with the outbox messages that schedule it.
+// An enabled event fails with ErrActiveEventLimit if the owner already has
+// maxActive.
+func (r *eventRepository) Create(event *models.Event, maxActive int, outbox ...models.OutboxMessage) error {
+	return r.withOutbox(outbox, func(tx *sqlx.Tx) error {
+		if err := checkActiveLimit(tx, event, maxActive); err != nil {
+			return err
+		}
+		return insertEvent(tx, event)
+	})
+}
+
+// CreateIdempotent is Create that also records the owner's idempotency key
+// for the event. A key already in use fails with a unique violation, waiting
+// first for any transaction still inserting it.
+func (r *eventRepository) CreateIdempotent(event *models.Event, key, requestHash string, maxActive int, outbox ...models.OutboxMessage) error {
+	return r.withOutbox(outbox, func(tx *sqlx.Tx) error {
+		if err := checkActiveLimit(tx, event, maxActive); err != nil {
+			return err
+		}
+		if err := insertEvent(tx, event); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`
+			INSERT INTO event_idempotency_keys (owner_id, key, request_hash, event_id)
+			VALUES ($1, $2, $3, $4)
+		`, event.OwnerID, key, requestHash, event.ID)
+		return err
+	})
+}
+
+// checkActiveLimit counts the owner's other enabled events while holding a
+// per-owner lock until the transaction ends, so concurrent creates and
+// resumes cannot both take the last slot.
+func checkActiveLimit(tx *sqlx.Tx, event *models.Event, maxActive int) error {
+	if maxActive == NoActiveEventLimit || event.State != models.EventStateEnabled {
+		return nil
+	}
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext('events:' || $1::text))`, event.OwnerID); err != nil {
+		return err
+	}
+	var active int
+	if err := tx.Get(&active, `
+		SELECT COUNT(*) FROM events WHERE owner_id = $1 AND state = $2 AND id <> $3
+	`, event.OwnerID, models.EventStateEnabled, event.ID); err != nil {
+		return err
+	}
+	if active >= maxActive {
+		return ErrActiveEventLimit
+	}
+	return nil
+}
+
+func insertEvent(tx *sqlx.Tx, event *models.Event) error {
+	return tx.QueryRowx(`
+		INSERT INTO events (id, owner_id, name, rule_name, description, schedule, timezone, start_at, end_at,
+			flexible_window_minutes, payload, targets, state)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING created_at, updated_at
+	`, event.ID, event.OwnerID, event.Name, event.RuleName, event.Description, event.Schedule, event.TimeZone, event.StartAt, event.EndAt,
+		event.FlexibleWindowMinutes, event.Payload, event.Targets, event.State,
+	).Scan(&event.CreatedAt, &event.UpdatedAt)
+}
+
+// GetByIdempotencyKey returns the event created under the owner's key and the
+// hash of the request that created it, or sql.ErrNoRows.
+func (r *eventRepository) GetByIdempotencyKey(ownerID, key string) (*models.Event, string, error) {
+	var requestHash, eventID string
+	if err := r.db.QueryRowx(`
+		SELECT request_hash, event_id FROM event_idempotency_keys WHERE owner_id = $1 AND key = $2
+	`, ownerID, key).Scan(&requestHash, &eventID); err != nil {
+		return nil, "", err
+	}
+	var event models.Event
+	if err := r.db.Get(&event, `SELECT `+eventColumns+` FROM events WHERE id = $1`, eventID); err != nil {
+		return nil, "", err
+	}
+	return &event, requestHash, nil
+}
+
+// GetByOwnerAndName returns sql.ErrNoRows unless the owner has an event with that name.
+func (r *eventRepository) GetByOwnerAndName(ownerID, name string) (*models.Event, error) {
+	var event models.Event
+	err := r.db.Get(&event, `SELECT `+eventColumns+` FROM events WHERE owner_id = $1 AND name = $2`, ownerID, name)
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// GetByRuleName returns sql.ErrNoRows unless an event owns the rule.
+func (r *eventRepository) GetByRuleName(ruleName string) (*models.Event, error) {
+	var event models.Event
+	err := r.db.Get(&event, `SELECT `+eventColumns+` FROM events WHERE rule_name = $1`, ruleName)
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Update saves the event's description, timing, payload, targets and state along with the outbox messages.
+// Like Create, an enabled event fails with ErrActiveEventLimit if the owner already has maxActive others.
+func (r *eventRepository) Update(event *models.Event, maxActive int, outbox ...models.OutboxMessage) error {
+	return r.withOutbox(outbox, func(tx *sqlx.Tx) error {
+		if err := checkActiveLimit(tx, event, maxActive); err != nil {
+			return err
+		}
+		return tx.QueryRowx(`
+			UPDATE events
+			SET description = $2, schedule = $3, timezone = $4, start_at = $5, end_at = $6,
+				flexible_window_minutes = $7, payload = $8, targets = $9, state = $10, updated_at = NOW()
+			WHERE id = $1
+			RETURNING updated_at
+		`, event.ID, event.Description, event.Schedule, event.TimeZone, event.StartAt, event.EndAt,
+			event.FlexibleWindowMinutes, event.Payload, event.Targets, event.State,
+		).Scan(&event.UpdatedAt)
+	})
+}
+
+// Delete removes the event along with the outbox messages.
+func (r *eventRepository) Delete(id string, outbox ...models.OutboxMessage) error {
+	return r.withOutbox(outbox, func(tx *sqlx.Tx) error {
+		return expectOneRow(tx.Exec(`DELETE FROM events WHERE id = $1`, id))
+	})
+}
+
+// withOutbox runs write and inserts the outbox messages in one transaction.
+func (r *eventRepository) withOutbox(outbox []models.OutboxMessage, write func(tx *sqlx.Tx) error) (err error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = write(tx); err != nil {
+		return err
+	}
+	if err = insertOutbox(tx, outbox); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *eventRepository) ListByOwner(ownerID string) ([]models.Event, error) {
+	var events []models.Event
+	err := r.db.Select(&events, `SELECT `+eventColumns+` FROM events WHERE owner_id = $1 ORDER BY created_at DESC`, ownerID)
+	return events, err
+}
+
+// ListAll returns every event grouped by owner, for reconciliation.
+func (r *eventRepository) ListAll() ([]models.Event, error) {
+	var events []models.Event
+	err := r.db.Select(&events, `SELECT `+eventColumns+` FROM events ORDER BY owner_id, created_at`)
+	return events, err
+}
+
+func (r *eventRepository) ListPageByOwner(ownerID string, limit, offset int) ([]models.Event, error) {
+	var events []models.Event
+	err := r.db.Select(&events, `SELECT `+eventColumns+` FROM events WHERE owner_id = $1
+		ORDER BY created_at DESC, id DESC LIMIT $2 OFFSET $3`, ownerID, limit, offset)
+	return events, err
+}
+
+func (r *eventRepository) CountByOwner(ownerID string) (int, error) {
+	var total int
+	err := r.db.Get(&total, `SELECT COUNT(*) FROM events WHERE owner_id = $1`, ownerID)
+	return total, err
+}
@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type EventRunRepository interface {
+	RecordStart(eventID, runID string, startedAt time.Time) error
+	RecordFinish(eventID, runID, status string, finishedAt time.Time, errorText, output string, outbox ...models.OutboxMessage) error
+	ListPageByEvent(eventID string, limit, offset int) ([]models.EventRun, error)
+	CountByEvent(eventID string) (int, error)
+}
+
+type eventRunRepository struct {
+	db *sqlx.DB
+}
+
+func NewEventRunRepository(db *sqlx.DB) EventRunRepository {
+	return &eventRunRepository{db: db}
+}
+
+// RecordStart creates the run, or fills in its start time if the finish was reported first.
+func (r *eventRunRepository) RecordStart(eventID, runID string, startedAt time.Time) error {
+	_, err := r.db.Exec(`
+		INSERT INTO event_runs (event_id, run_id, status, started_at)
+		VALUES ($1, $2, 'running', $3)
+		ON CONFLICT (event_id, run_id) DO UPDATE SET
+			started_at = EXCLUDED.started_at,
+			updated_at = NOW()
+	`, eventID, runID, startedAt)
+	return err
+}
+
+// RecordFinish completes the run, creating it if its start was never
+// reported, and records the outbox messages in the same transaction. A run
+// that already finished with the same status is left as it is, without
+// queueing the messages again, so a repeated callback notifies nobody twice.
+func (r *eventRunRepository) RecordFinish(eventID, runID, status string, finishedAt time.Time, errorText, output string, outbox ...models.OutboxMessage) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.Exec(`
+		INSERT INTO event_runs (event_id, run_id, status, finished_at, error, output)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (event_id, run_id) DO UPDATE SET
+			status = EXCLUDED.status,
+			finished_at = EXCLUDED.finished_at,
+			error = EXCLUDED.error,
+			output = EXCLUDED.output,
+			updated_at = NOW()
+		WHERE event_runs.status <> EXCLUDED.status
+	`, eventID, runID, status, finishedAt, errorText, output)
+	if err != nil {
+		return err
+	}
+	changed, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if changed == 0 {
+		return nil
+	}
+	if err := insertOutbox(tx, outbox); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *eventRunRepository) ListPageByEvent(eventID string, limit, offset int) ([]models.EventRun, error) {
+	var runs []models.EventRun
+	err := r.db.Select(&runs, `
+		SELECT event_id, run_id, status, started_at, finished_at,
+			(EXTRACT(EPOCH FROM finished_at - started_at) * 1000)::BIGINT AS duration_ms,
+			error, output, created_at, updated_at
+		FROM event_runs
+		WHERE event_id = $1
+		ORDER BY COALESCE(started_at, finished_at) DESC, run_id DESC
+		LIMIT $2 OFFSET $3
+	`, eventID, limit, offset)
+	return runs, err
+}
+
+func (r *eventRunRepository) CountByEvent(eventID string) (int, error) {
+	var total int
+	err := r.db.Get(&total, `SELECT COUNT(*) FROM event_runs WHERE event_id = $1`, eventID)
+	return total, err
+}
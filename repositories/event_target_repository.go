@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"github.com/dat1010/go-api/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type EventTargetRepository interface {
+	List() ([]models.EventTarget, error)
+	GetByNames(names []string) ([]models.EventTarget, error)
+	Create(target *models.EventTarget) error
+	IsInUse(name string) (bool, error)
+	Delete(name string) error
+	ListSchemas() ([]models.EventTargetSchema, error)
+	GetSchemas(kinds []string) ([]models.EventTargetSchema, error)
+	PutSchema(schema *models.EventTargetSchema) error
+	DeleteSchema(kind string) error
+}
+
+type eventTargetRepository struct {
+	db *sqlx.DB
+}
+
+func NewEventTargetRepository(db *sqlx.DB) EventTargetRepository {
+	return &eventTargetRepository{db: db}
+}
+
+const eventTargetColumns = `name, kind, arn, role_arn, description, created_at, updated_at`
+
+func (r *eventTargetRepository) List() ([]models.EventTarget, error) {
+	var targets []models.EventTarget
+	err := r.db.Select(&targets, `SELECT `+eventTargetColumns+` FROM event_targets ORDER BY name`)
+	return targets, err
+}
+
+// GetByNames returns the targets that exist among names; missing names are skipped.
+func (r *eventTargetRepository) GetByNames(names []string) ([]models.EventTarget, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	query, args, err := sqlx.In(`SELECT `+eventTargetColumns+` FROM event_targets WHERE name IN (?)`, names)
+	if err != nil {
+		return nil, err
+	}
+	var targets []models.EventTarget
+	err = r.db.Select(&targets, r.db.Rebind(query), args...)
+	return targets, err
+}
+
+// Create fails with a unique violation when a target with the name exists.
+func (r *eventTargetRepository) Create(target *models.EventTarget) error {
+	return r.db.QueryRowx(`
+		INSERT INTO event_targets (name, kind, arn, role_arn, description)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at, updated_at
+	`, target.Name, target.Kind, target.Arn, target.RoleArn, target.Description,
+	).Scan(&target.CreatedAt, &target.UpdatedAt)
+}
+
+// IsInUse reports whether any event sends to the target.
+func (r *eventTargetRepository) IsInUse(name string) (bool, error) {
+	var inUse bool
+	err := r.db.Get(&inUse, `
+		SELECT EXISTS (
+			SELECT 1 FROM events WHERE targets @> jsonb_build_array(jsonb_build_object('target', $1::text))
+		)
+	`, name)
+	return inUse, err
+}
+
+func (r *eventTargetRepository) Delete(name string) error {
+	return expectOneRow(r.db.Exec(`DELETE FROM event_targets WHERE name = $1`, name))
+}
+
+const eventTargetSchemaColumns = `kind, schema, created_at, updated_at`
+
+func (r *eventTargetRepository) ListSchemas() ([]models.EventTargetSchema, error) {
+	var schemas []models.EventTargetSchema
+	err := r.db.Select(&schemas, `SELECT `+eventTargetSchemaColumns+` FROM event_target_schemas ORDER BY kind`)
+	return schemas, err
+}
+
+// GetSchemas returns the schemas registered for any of kinds; kinds without one are skipped.
+func (r *eventTargetRepository) GetSchemas(kinds []string) ([]models.EventTargetSchema, error) {
+	if len(kinds) == 0 {
+		return nil, nil
+	}
+	query, args, err := sqlx.In(`SELECT `+eventTargetSchemaColumns+` FROM event_target_schemas WHERE kind IN (?) ORDER BY kind`, kinds)
+	if err != nil {
+		return nil, err
+	}
+	var schemas []models.EventTargetSchema
+	err = r.db.Select(&schemas, r.db.Rebind(query), args...)
+	return schemas, err
+}
+
+// PutSchema registers the kind's schema, replacing any earlier one.
+func (r *eventTargetRepository) PutSchema(schema *models.EventTargetSchema) error {
+	return r.db.QueryRowx(`
+		INSERT INTO event_target_schemas (kind, schema)
+		VALUES ($1, $2)
+		ON CONFLICT (kind) DO UPDATE SET schema = EXCLUDED.schema, updated_at = NOW()
+		RETURNING created_at, updated_at
+	`, schema.Kind, schema.Schema).Scan(&schema.CreatedAt, &schema.UpdatedAt)
+}
+
+func (r *eventTargetRepository) DeleteSchema(kind string) error {
+	return expectOneRow(r.db.Exec(`DELETE FROM event_target_schemas WHERE kind = $1`, kind))
+}
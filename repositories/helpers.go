@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// expectOneRow turns an update that matched no rows into sql.ErrNoRows.
+func expectOneRow(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// nullableJSON maps an empty JSON document to SQL NULL.
+func nullableJSON(raw []byte) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
+
+// IsUniqueViolation reports whether err is a Postgres unique constraint violation.
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"github.com/dat1010/go-api/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type ImpersonationRepository interface {
+	Create(session *models.ImpersonationSession, tokenHash string) error
+	GetActiveByTokenHash(tokenHash string) (*models.ImpersonationSession, error)
+	End(id string) error
+}
+
+type impersonationRepository struct {
+	db *sqlx.DB
+}
+
+func NewImpersonationRepository(db *sqlx.DB) ImpersonationRepository {
+	return &impersonationRepository{db: db}
+}
+
+func (r *impersonationRepository) Create(session *models.ImpersonationSession, tokenHash string) error {
+	return r.db.QueryRowx(
+		`INSERT INTO impersonation_sessions (id, token_hash, actor_auth0_user_id, target_auth0_user_id, reason, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 RETURNING started_at`,
+		session.ID, tokenHash, session.ActorAuth0UserID, session.TargetAuth0UserID, session.Reason, session.ExpiresAt,
+	).Scan(&session.StartedAt)
+}
+
+// GetActiveByTokenHash returns sql.ErrNoRows unless the session exists, has not ended and has not expired.
+func (r *impersonationRepository) GetActiveByTokenHash(tokenHash string) (*models.ImpersonationSession, error) {
+	var session models.ImpersonationSession
+	err := r.db.Get(&session, `
+		SELECT id, actor_auth0_user_id, target_auth0_user_id, reason, started_at, expires_at, ended_at
+		FROM impersonation_sessions
+		WHERE token_hash = $1 AND ended_at IS NULL AND expires_at > NOW()
+	`, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *impersonationRepository) End(id string) error {
+	_, err := r.db.Exec(
+		`UPDATE impersonation_sessions SET ended_at = NOW() WHERE id = $1 AND ended_at IS NULL`,
+		id,
+	)
+	return err
+}
@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"github.com/dat1010/go-api/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type InvitationRepository interface {
+	Create(invitation *models.Invitation) error
+	GetByID(id string) (*models.Invitation, error)
+	List() ([]models.Invitation, error)
+	Revoke(id string) error
+	Claim(id, auth0UserID string) error
+	Release(id string) error
+}
+
+type invitationRepository struct {
+	db *sqlx.DB
+}
+
+func NewInvitationRepository(db *sqlx.DB) InvitationRepository {
+	return &invitationRepository{db: db}
+}
+
+const invitationColumns = `i.id, i.email, r.name AS role, i.invited_by, i.expires_at,
+	i.redeemed_at, i.redeemed_by, i.revoked_at, i.created_at`
+
+// Create inserts the invitation, returning sql.ErrNoRows when the role does not exist.
+func (r *invitationRepository) Create(invitation *models.Invitation) error {
+	return r.db.QueryRowx(
+		`INSERT INTO user_invitations (id, email, role_id, invited_by, expires_at)
+		 SELECT $1, $2, id, $4, $5 FROM roles WHERE name = $3
+		 RETURNING created_at`,
+		invitation.ID, invitation.Email, invitation.Role, invitation.InvitedBy, invitation.ExpiresAt,
+	).Scan(&invitation.CreatedAt)
+}
+
+func (r *invitationRepository) GetByID(id string) (*models.Invitation, error) {
+	var invitation models.Invitation
+	err := r.db.Get(&invitation, `SELECT `+invitationColumns+`
+		FROM user_invitations i
+		JOIN roles r ON r.id = i.role_id
+		WHERE i.id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *invitationRepository) List() ([]models.Invitation, error) {
+	var invitations []models.Invitation
+	err := r.db.Select(&invitations, `SELECT `+invitationColumns+`
+		FROM user_invitations i
+		JOIN roles r ON r.id = i.role_id
+		ORDER BY i.created_at DESC`)
+	return invitations, err
+}
+
+// Revoke cancels a pending invitation, returning sql.ErrNoRows if it is not pending.
+func (r *invitationRepository) Revoke(id string) error {
+	return expectOneRow(r.db.Exec(
+		`UPDATE user_invitations SET revoked_at = NOW()
+		 WHERE id = $1 AND redeemed_at IS NULL AND revoked_at IS NULL`,
+		id,
+	))
+}
+
+// Claim atomically marks a pending, unexpired invitation as redeemed by the user.
+// It returns sql.ErrNoRows when the invitation was already used, revoked or expired.
+func (r *invitationRepository) Claim(id, auth0UserID string) error {
+	return expectOneRow(r.db.Exec(
+		`UPDATE user_invitations SET redeemed_at = NOW(), redeemed_by = $2
+		 WHERE id = $1 AND redeemed_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()`,
+		id, auth0UserID,
+	))
+}
+
+// Release undoes a Claim when the rest of the redemption could not be completed.
+func (r *invitationRepository) Release(id string) error {
+	_, err := r.db.Exec(
+		`UPDATE user_invitations SET redeemed_at = NULL, redeemed_by = NULL WHERE id = $1`,
+		id,
+	)
+	return err
+}
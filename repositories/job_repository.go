@@ -0,0 +1,166 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type JobRepository interface {
+	Enqueue(maxAttempts int, messages ...models.OutboxMessage) error
+	RelayOutbox(limit, maxAttempts int) (int, error)
+	Claim(lease time.Duration) (*models.Job, error)
+	Complete(job *models.Job) error
+	Fail(job *models.Job, errorText string, retryAt time.Time) (string, error)
+	Retry(id int64) error
+	DeleteFinished(before time.Time) (int64, error)
+	ListPage(status, kind string, limit, offset int) ([]models.Job, error)
+	Count(status, kind string) (int, error)
+}
+
+type jobRepository struct {
+	db *sqlx.DB
+}
+
+func NewJobRepository(db *sqlx.DB) JobRepository {
+	return &jobRepository{db: db}
+}
+
+const jobColumns = `id, kind, payload, status, attempts, max_attempts, run_at, locked_at, last_error, created_at, updated_at`
+
+// insertOutbox records messages in tx, so they exist exactly when the change
+// that caused them commits.
+func insertOutbox(tx *sqlx.Tx, messages []models.OutboxMessage) error {
+	for _, message := range messages {
+		if _, err := tx.Exec(`INSERT INTO outbox (kind, payload) VALUES ($1, $2)`, message.Kind, message.Payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Enqueue adds jobs directly, for work that follows from other jobs rather
+// than from a domain change. All of them are added or none.
+func (r *jobRepository) Enqueue(maxAttempts int, messages ...models.OutboxMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, message := range messages {
+		if _, err := tx.Exec(
+			`INSERT INTO jobs (kind, payload, max_attempts) VALUES ($1, $2, $3)`,
+			message.Kind, message.Payload, maxAttempts,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// RelayOutbox moves up to limit outbox messages into jobs in one statement.
+// Concurrent relays skip each other's rows.
+func (r *jobRepository) RelayOutbox(limit, maxAttempts int) (int, error) {
+	result, err := r.db.Exec(`
+		WITH moved AS (
+			DELETE FROM outbox
+			WHERE id IN (SELECT id FROM outbox ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED)
+			RETURNING id, kind, payload, created_at
+		)
+		INSERT INTO jobs (kind, payload, max_attempts, run_at, created_at)
+		SELECT kind, payload, $2, NOW(), created_at FROM moved ORDER BY id
+	`, limit, maxAttempts)
+	if err != nil {
+		return 0, err
+	}
+	moved, err := result.RowsAffected()
+	return int(moved), err
+}
+
+// Claim locks the next due job for this worker, or returns sql.ErrNoRows.
+// Jobs left running longer than lease, by a worker that died, are due again.
+func (r *jobRepository) Claim(lease time.Duration) (*models.Job, error) {
+	var job models.Job
+	err := r.db.Get(&job, `
+		UPDATE jobs
+		SET status = 'running', attempts = attempts + 1, locked_at = NOW(), updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE (status = 'pending' AND run_at <= NOW())
+				OR (status = 'running' AND locked_at < NOW() - make_interval(secs => $1))
+			ORDER BY run_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+jobColumns, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Complete marks the job succeeded, unless another worker has since
+// reclaimed it, in which case sql.ErrNoRows is returned.
+func (r *jobRepository) Complete(job *models.Job) error {
+	return expectOneRow(r.db.Exec(`
+		UPDATE jobs SET status = 'succeeded', locked_at = NULL, last_error = '', updated_at = NOW()
+		WHERE id = $1 AND status = 'running' AND locked_at = $2
+	`, job.ID, job.LockedAt))
+}
+
+// Fail schedules the job to run again at retryAt, or dead-letters it once it
+// has used all its attempts. It returns the job's new status.
+func (r *jobRepository) Fail(job *models.Job, errorText string, retryAt time.Time) (string, error) {
+	var status string
+	err := r.db.Get(&status, `
+		UPDATE jobs
+		SET status = CASE WHEN attempts >= max_attempts THEN 'dead' ELSE 'pending' END,
+			run_at = $3, last_error = $4, locked_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND status = 'running' AND locked_at = $2
+		RETURNING status
+	`, job.ID, job.LockedAt, retryAt, errorText)
+	return status, err
+}
+
+// Retry requeues a dead job with fresh attempts.
+func (r *jobRepository) Retry(id int64) error {
+	return expectOneRow(r.db.Exec(`
+		UPDATE jobs SET status = 'pending', attempts = 0, run_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'dead'
+	`, id))
+}
+
+// DeleteFinished removes jobs that succeeded before the cutoff.
+func (r *jobRepository) DeleteFinished(before time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM jobs WHERE status = 'succeeded' AND updated_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ListPage lists jobs newest first; empty status or kind matches any.
+func (r *jobRepository) ListPage(status, kind string, limit, offset int) ([]models.Job, error) {
+	var jobs []models.Job
+	err := r.db.Select(&jobs, `
+		SELECT `+jobColumns+`
+		FROM jobs
+		WHERE ($1 = '' OR status = $1) AND ($2 = '' OR kind = $2)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3 OFFSET $4
+	`, status, kind, limit, offset)
+	return jobs, err
+}
+
+func (r *jobRepository) Count(status, kind string) (int, error) {
+	var total int
+	err := r.db.Get(&total, `
+		SELECT COUNT(*) FROM jobs WHERE ($1 = '' OR status = $1) AND ($2 = '' OR kind = $2)
+	`, status, kind)
+	return total, err
+}
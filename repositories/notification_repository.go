@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"github.com/dat1010/go-api/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type NotificationRepository interface {
+	ListByUser(auth0UserID string) ([]models.NotificationPreference, error)
+	Get(auth0UserID, channel string) (*models.NotificationPreference, error)
+	Put(pref *models.NotificationPreference) error
+	Delete(auth0UserID, channel string) error
+	ListSubscribers(kind, excludeUserID string) ([]models.NotificationPreference, error)
+}
+
+type notificationRepository struct {
+	db *sqlx.DB
+}
+
+func NewNotificationRepository(db *sqlx.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+const notificationPreferenceColumns = `auth0_user_id, channel, destination, kinds, enabled, created_at, updated_at`
+
+func (r *notificationRepository) ListByUser(auth0UserID string) ([]models.NotificationPreference, error) {
+	var prefs []models.NotificationPreference
+	err := r.db.Select(&prefs, `
+		SELECT `+notificationPreferenceColumns+`
+		FROM notification_preferences
+		WHERE auth0_user_id = $1
+		ORDER BY channel
+	`, auth0UserID)
+	return prefs, err
+}
+
+func (r *notificationRepository) Get(auth0UserID, channel string) (*models.NotificationPreference, error) {
+	var pref models.NotificationPreference
+	err := r.db.Get(&pref, `
+		SELECT `+notificationPreferenceColumns+`
+		FROM notification_preferences
+		WHERE auth0_user_id = $1 AND channel = $2
+	`, auth0UserID, channel)
+	if err != nil {
+		return nil, err
+	}
+	return &pref, nil
+}
+
+// Put sets the user's preference for the channel, replacing any earlier one.
+func (r *notificationRepository) Put(pref *models.NotificationPreference) error {
+	return r.db.QueryRowx(`
+		INSERT INTO notification_preferences (auth0_user_id, channel, destination, kinds, enabled)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (auth0_user_id, channel) DO UPDATE SET
+			destination = EXCLUDED.destination,
+			kinds = EXCLUDED.kinds,
+			enabled = EXCLUDED.enabled,
+			updated_at = NOW()
+		RETURNING created_at, updated_at
+	`, pref.Auth0UserID, pref.Channel, pref.Destination, pref.Kinds, pref.Enabled,
+	).Scan(&pref.CreatedAt, &pref.UpdatedAt)
+}
+
+func (r *notificationRepository) Delete(auth0UserID, channel string) error {
+	return expectOneRow(r.db.Exec(
+		`DELETE FROM notification_preferences WHERE auth0_user_id = $1 AND channel = $2`,
+		auth0UserID, channel,
+	))
+}
+
+// ListSubscribers returns the enabled preferences subscribed to kind, skipping
+// excludeUserID's own so nobody is told about what they did themselves.
+func (r *notificationRepository) ListSubscribers(kind, excludeUserID string) ([]models.NotificationPreference, error) {
+	var prefs []models.NotificationPreference
+	err := r.db.Select(&prefs, `
+		SELECT `+notificationPreferenceColumns+`
+		FROM notification_preferences
+		WHERE enabled AND kinds @> jsonb_build_array($1::text) AND auth0_user_id <> $2
+		ORDER BY auth0_user_id, channel
+	`, kind, excludeUserID)
+	return prefs, err
+}
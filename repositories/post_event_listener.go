@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+)
+
+// PostEventsChannel is the Postgres notification channel announcing post
+// changes to every API instance. Payloads are JSON objects with the event
+// "id" from the post_event_ids sequence, its "type" and the "post_id".
+const PostEventsChannel = "post_events"
+
+// notifyPostEvent announces a post change when tx commits.
+func notifyPostEvent(tx *sqlx.Tx, eventType, postID string) error {
+	_, err := tx.Exec(`
+		SELECT pg_notify($1, json_build_object(
+			'id', nextval('post_event_ids'),
+			'type', $2::text,
+			'post_id', $3::text
+		)::text)
+	`, PostEventsChannel, eventType, postID)
+	return err
+}
+
+// PostEventListener receives the notifications sent on PostEventsChannel.
+type PostEventListener interface {
+	// Listen calls handle with each payload until ctx is done or the
+	// connection fails, and returns why it stopped.
+	Listen(ctx context.Context, handle func(payload string)) error
+}
+
+type postEventListener struct {
+	db *sqlx.DB
+}
+
+func NewPostEventListener(db *sqlx.DB) PostEventListener {
+	return &postEventListener{db: db}
+}
+
+// Listen holds one pooled connection for as long as it listens. The
+// connection is discarded afterwards rather than returned to the pool still
+// subscribed.
+func (l *postEventListener) Listen(ctx context.Context, handle func(payload string)) error {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var listenErr error
+	_ = conn.Raw(func(driverConn interface{}) error {
+		stdConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			listenErr = fmt.Errorf("post events need a pgx connection, got %T", driverConn)
+			return driver.ErrBadConn
+		}
+		pgConn := stdConn.Conn()
+		if _, listenErr = pgConn.Exec(ctx, "LISTEN "+PostEventsChannel); listenErr != nil {
+			return driver.ErrBadConn
+		}
+		for {
+			notification, err := pgConn.WaitForNotification(ctx)
+			if err != nil {
+				listenErr = err
+				return driver.ErrBadConn
+			}
+			handle(notification.Payload)
+		}
+	})
+	return listenErr
+}
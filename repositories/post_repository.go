@@ -6,14 +6,19 @@ import (
 )
 
 type PostRepository interface {
-	Create(post *models.Post) error
+	Create(post *models.Post, outbox ...models.OutboxMessage) error
 	GetByID(id string) (*models.Post, error)
-	Update(id string, updates map[string]interface{}) error
-	Delete(id, auth0UserID string) error
+	Update(id string, updates map[string]interface{}, outbox ...models.OutboxMessage) error
+	Delete(id, auth0UserID string, outbox ...models.OutboxMessage) error
 	List() ([]models.Post, error)
 	ListByAuthor(auth0UserID string) ([]models.Post, error)
 }
 
+// visiblePostsCondition hides posts whose author has been banned.
+const visiblePostsCondition = `NOT EXISTS (
+	SELECT 1 FROM users u WHERE u.auth0_user_id = posts.auth0_user_id AND u.status = 'banned'
+)`
+
 type postRepository struct {
 	db *sqlx.DB
 }
@@ -22,48 +27,95 @@ func NewPostRepository(db *sqlx.DB) PostRepository {
 	return &postRepository{db: db}
 }
 
-func (r *postRepository) Create(post *models.Post) error {
+// Create inserts the post and its outbox messages in one transaction.
+// Create, Update and Delete also announce the change on PostEventsChannel
+// once it commits.
+func (r *postRepository) Create(post *models.Post, outbox ...models.OutboxMessage) error {
 	query := `INSERT INTO posts (id, title, content, auth0_user_id, created_at, updated_at, slug)
 			  VALUES (:id, :title, :content, :auth0_user_id, :created_at, :updated_at, :slug)`
 
-	_, err := r.db.NamedExec(query, post)
-	return err
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.NamedExec(query, post); err != nil {
+		return err
+	}
+	if err := notifyPostEvent(tx, models.WebhookEventPostCreated, post.ID); err != nil {
+		return err
+	}
+	if err := insertOutbox(tx, outbox); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *postRepository) GetByID(id string) (*models.Post, error) {
 	var post models.Post
-	err := r.db.Get(&post, "SELECT id, title, content, auth0_user_id, created_at, updated_at, slug FROM posts WHERE id = $1", id)
+	err := r.db.Get(&post, "SELECT id, title, content, auth0_user_id, created_at, updated_at, slug FROM posts WHERE id = $1 AND "+visiblePostsCondition, id)
 	if err != nil {
 		return nil, err
 	}
 	return &post, nil
 }
 
-func (r *postRepository) Update(id string, updates map[string]interface{}) error {
+// Update changes the post and records its outbox messages in one transaction.
+func (r *postRepository) Update(id string, updates map[string]interface{}, outbox ...models.OutboxMessage) error {
 	query := `UPDATE posts SET 
 		title = COALESCE(:title, title),
 		content = COALESCE(:content, content),
 		updated_at = CURRENT_TIMESTAMP
 		WHERE id = :id AND auth0_user_id = :auth0_user_id`
 
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
 	updates["id"] = id
-	_, err := r.db.NamedExec(query, updates)
-	return err
+	if _, err := tx.NamedExec(query, updates); err != nil {
+		return err
+	}
+	if err := notifyPostEvent(tx, models.WebhookEventPostUpdated, id); err != nil {
+		return err
+	}
+	if err := insertOutbox(tx, outbox); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (r *postRepository) Delete(id, auth0UserID string) error {
-	_, err := r.db.Exec("DELETE FROM posts WHERE id = $1 AND auth0_user_id = $2", id, auth0UserID)
-	return err
+// Delete removes the post and records its outbox messages in one transaction.
+func (r *postRepository) Delete(id, auth0UserID string, outbox ...models.OutboxMessage) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.Exec("DELETE FROM posts WHERE id = $1 AND auth0_user_id = $2", id, auth0UserID); err != nil {
+		return err
+	}
+	if err := notifyPostEvent(tx, models.WebhookEventPostDeleted, id); err != nil {
+		return err
+	}
+	if err := insertOutbox(tx, outbox); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *postRepository) List() ([]models.Post, error) {
 	var posts []models.Post
-	err := r.db.Select(&posts, "SELECT id, title, content, auth0_user_id, created_at, updated_at, slug FROM posts ORDER BY created_at DESC")
+	err := r.db.Select(&posts, "SELECT id, title, content, auth0_user_id, created_at, updated_at, slug FROM posts WHERE "+visiblePostsCondition+" ORDER BY created_at DESC")
 	return posts, err
 }
 
 func (r *postRepository) ListByAuthor(auth0UserID string) ([]models.Post, error) {
 	var posts []models.Post
-	err := r.db.Select(&posts, "SELECT id, title, content, auth0_user_id, created_at, updated_at, slug FROM posts WHERE auth0_user_id = $1 ORDER BY created_at DESC", auth0UserID)
+	err := r.db.Select(&posts, "SELECT id, title, content, auth0_user_id, created_at, updated_at, slug FROM posts WHERE auth0_user_id = $1 AND "+visiblePostsCondition+" ORDER BY created_at DESC", auth0UserID)
 	return posts, err
 }
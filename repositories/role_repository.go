@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"github.com/dat1010/go-api/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type RoleRepository interface {
+	ListRoles() ([]models.Role, error)
+	CreateRole(name string, permissions []string) error
+	SetRolePermissions(name string, permissions []string) error
+}
+
+type roleRepository struct {
+	db *sqlx.DB
+}
+
+func NewRoleRepository(db *sqlx.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+func (r *roleRepository) ListRoles() ([]models.Role, error) {
+	var rows []struct {
+		Name       string  `db:"name"`
+		Permission *string `db:"permission"`
+	}
+	if err := r.db.Select(&rows, `
+		SELECT r.name, rp.permission
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role_id = r.id
+		ORDER BY r.id, rp.permission
+	`); err != nil {
+		return nil, err
+	}
+
+	roles := []models.Role{}
+	for _, row := range rows {
+		if len(roles) == 0 || roles[len(roles)-1].Name != row.Name {
+			roles = append(roles, models.Role{Name: row.Name, Permissions: []string{}})
+		}
+		if row.Permission != nil {
+			last := &roles[len(roles)-1]
+			last.Permissions = append(last.Permissions, *row.Permission)
+		}
+	}
+	return roles, nil
+}
+
+// CreateRole fails with a unique violation when the role already exists.
+func (r *roleRepository) CreateRole(name string, permissions []string) (err error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var roleID int
+	if err = tx.Get(&roleID, `INSERT INTO roles (name) VALUES ($1) RETURNING id`, name); err != nil {
+		return err
+	}
+	if err = grantPermissions(tx, roleID, permissions); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetRolePermissions replaces the role's permissions. It returns
+// sql.ErrNoRows when the role does not exist.
+func (r *roleRepository) SetRolePermissions(name string, permissions []string) (err error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var roleID int
+	if err = tx.Get(&roleID, `SELECT id FROM roles WHERE name = $1 FOR UPDATE`, name); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM role_permissions WHERE role_id = $1`, roleID); err != nil {
+		return err
+	}
+	if err = grantPermissions(tx, roleID, permissions); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func grantPermissions(tx *sqlx.Tx, roleID int, permissions []string) error {
+	for _, permission := range permissions {
+		if _, err := tx.Exec(`
+			INSERT INTO role_permissions (role_id, permission)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`, roleID, permission); err != nil {
+			return err
+		}
+	}
+	return nil
+}
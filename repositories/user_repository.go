@@ -2,18 +2,25 @@ package repositories
 
 import (
 	"database/sql"
+	"errors"
 
 	"github.com/dat1010/go-api/models"
 	"github.com/jmoiron/sqlx"
 )
 
 type UserRepository interface {
-	EnsureUser(auth0UserID string) error
+	EnsureUser(auth0UserID string, created ...models.OutboxMessage) error
 	GetUserRole(auth0UserID string) (string, error)
-	SetUserRole(auth0UserID, roleName string) error
+	SetUserRole(auth0UserID, roleName string, outbox ...models.OutboxMessage) error
 	ListUsersWithRoles() ([]models.UserWithRole, error)
-	DeleteUser(auth0UserID string) error
+	DeleteUser(auth0UserID string, removeRule func(ruleName string) models.OutboxMessage, outbox ...models.OutboxMessage) error
 	IsUserInRole(auth0UserID, roleName string) (bool, error)
+	HasPermission(auth0UserID, permission string) (bool, error)
+	GetEventQuota(auth0UserID string) (*int, error)
+	SetEventQuota(auth0UserID string, maxActiveEvents *int) error
+	SetUserEmail(auth0UserID, email string) error
+	GetUserStatus(auth0UserID string) (*models.UserStatus, error)
+	SetUserStatus(auth0UserID string, status *models.UserStatus, outbox ...models.OutboxMessage) error
 }
 
 type userRepository struct {
@@ -24,13 +31,34 @@ func NewUserRepository(db *sqlx.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
-func (r *userRepository) EnsureUser(auth0UserID string) error {
-	_, err := r.db.Exec(
+// EnsureUser inserts the user if they are new, recording the created outbox
+// messages only in that case.
+func (r *userRepository) EnsureUser(auth0UserID string, created ...models.OutboxMessage) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.Exec(
 		`INSERT INTO users (auth0_user_id) VALUES ($1)
 		 ON CONFLICT (auth0_user_id) DO NOTHING`,
 		auth0UserID,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if inserted == 0 {
+		return nil
+	}
+	if err := insertOutbox(tx, created); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *userRepository) GetUserRole(auth0UserID string) (string, error) {
@@ -47,7 +75,7 @@ func (r *userRepository) GetUserRole(auth0UserID string) (string, error) {
 	return role, err
 }
 
-func (r *userRepository) SetUserRole(auth0UserID, roleName string) error {
+func (r *userRepository) SetUserRole(auth0UserID, roleName string, outbox ...models.OutboxMessage) error {
 	tx, err := r.db.Beginx()
 	if err != nil {
 		return err
@@ -72,6 +100,10 @@ func (r *userRepository) SetUserRole(auth0UserID, roleName string) error {
 		return err
 	}
 
+	if err = insertOutbox(tx, outbox); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
@@ -79,7 +111,8 @@ func (r *userRepository) ListUsersWithRoles() ([]models.UserWithRole, error) {
 	var users []models.UserWithRole
 	err := r.db.Select(&users, `
 		SELECT u.auth0_user_id,
-			   COALESCE(r.name, '') AS role
+			   COALESCE(r.name, '') AS role,
+			   u.status
 		FROM users u
 		LEFT JOIN user_roles ur ON ur.auth0_user_id = u.auth0_user_id
 		LEFT JOIN roles r ON r.id = ur.role_id
@@ -88,9 +121,40 @@ func (r *userRepository) ListUsersWithRoles() ([]models.UserWithRole, error) {
 	return users, err
 }
 
-func (r *userRepository) DeleteUser(auth0UserID string) error {
-	_, err := r.db.Exec(`DELETE FROM users WHERE auth0_user_id = $1`, auth0UserID)
-	return err
+// DeleteUser records the outbox messages only if the user existed.
+// DeleteUser deletes the user along with their events, queueing
+// removeRule's message for each event's rule so the scheduler backend drops
+// it too.
+func (r *userRepository) DeleteUser(auth0UserID string, removeRule func(ruleName string) models.OutboxMessage, outbox ...models.OutboxMessage) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Locking the user keeps new events from being created for them meanwhile
+	var locked bool
+	err = tx.Get(&locked, `SELECT true FROM users WHERE auth0_user_id = $1 FOR UPDATE`, auth0UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var ruleNames []string
+	if err := tx.Select(&ruleNames, `DELETE FROM events WHERE owner_id = $1 RETURNING rule_name`, auth0UserID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM users WHERE auth0_user_id = $1`, auth0UserID); err != nil {
+		return err
+	}
+	for _, ruleName := range ruleNames {
+		outbox = append(outbox, removeRule(ruleName))
+	}
+	if err := insertOutbox(tx, outbox); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *userRepository) IsUserInRole(auth0UserID, roleName string) (bool, error) {
@@ -105,3 +169,74 @@ func (r *userRepository) IsUserInRole(auth0UserID, roleName string) (bool, error
 	`, auth0UserID, roleName)
 	return exists, err
 }
+
+func (r *userRepository) HasPermission(auth0UserID, permission string) (bool, error) {
+	var exists bool
+	err := r.db.Get(&exists, `
+		SELECT EXISTS(
+			SELECT 1
+			FROM user_roles ur
+			JOIN role_permissions rp ON rp.role_id = ur.role_id
+			WHERE ur.auth0_user_id = $1 AND rp.permission = $2
+		)
+	`, auth0UserID, permission)
+	return exists, err
+}
+
+// GetEventQuota returns nil when the user has no quota of their own.
+func (r *userRepository) GetEventQuota(auth0UserID string) (*int, error) {
+	var quota *int
+	err := r.db.Get(&quota, `SELECT max_active_events FROM users WHERE auth0_user_id = $1`, auth0UserID)
+	if err != nil {
+		return nil, err
+	}
+	return quota, nil
+}
+
+// SetEventQuota returns sql.ErrNoRows when the user does not exist.
+func (r *userRepository) SetEventQuota(auth0UserID string, maxActiveEvents *int) error {
+	return expectOneRow(r.db.Exec(
+		`UPDATE users SET max_active_events = $2 WHERE auth0_user_id = $1`,
+		auth0UserID, maxActiveEvents,
+	))
+}
+
+func (r *userRepository) SetUserEmail(auth0UserID, email string) error {
+	_, err := r.db.Exec(`UPDATE users SET email = $2 WHERE auth0_user_id = $1`, auth0UserID, email)
+	return err
+}
+
+func (r *userRepository) GetUserStatus(auth0UserID string) (*models.UserStatus, error) {
+	var status models.UserStatus
+	err := r.db.Get(&status, `
+		SELECT status, status_reason, suspended_until, status_changed_by, status_changed_at
+		FROM users
+		WHERE auth0_user_id = $1
+	`, auth0UserID)
+	if err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// SetUserStatus returns sql.ErrNoRows when the user does not exist.
+func (r *userRepository) SetUserStatus(auth0UserID string, status *models.UserStatus, outbox ...models.OutboxMessage) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := expectOneRow(tx.Exec(`
+		UPDATE users
+		SET status = $2, status_reason = $3, suspended_until = $4,
+			status_changed_by = $5, status_changed_at = NOW()
+		WHERE auth0_user_id = $1
+	`, auth0UserID, status.Status, status.Reason, status.SuspendedUntil, status.StatusChangedBy)); err != nil {
+		return err
+	}
+	if err := insertOutbox(tx, outbox); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
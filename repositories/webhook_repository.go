@@ -0,0 +1,238 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// WebhookRepository stores webhooks and their deliveries. Methods taking an
+// ownerID only see that user's webhooks; an empty ownerID means the admin
+// webhooks, which have no owner.
+type WebhookRepository interface {
+	Create(webhook *models.Webhook) error
+	Get(ownerID, id string) (*models.Webhook, error)
+	GetByID(id string) (*models.Webhook, error)
+	ListByOwner(ownerID string) ([]models.Webhook, error)
+	CountByOwner(ownerID string) (int, error)
+	Update(webhook *models.Webhook) error
+	Delete(ownerID, id string) error
+	ListSubscribed(eventType string) ([]models.Webhook, error)
+	CreateDeliveries(deliveries []models.WebhookDelivery, outbox []models.OutboxMessage) error
+	GetDelivery(webhookID, id string) (*models.WebhookDelivery, error)
+	GetDeliveryByID(id string) (*models.WebhookDelivery, error)
+	RecordAttempt(delivery *models.WebhookDelivery, disableAfter int, reason string) (bool, error)
+	ListDeliveries(webhookID string, limit, offset int) ([]models.WebhookDelivery, error)
+	CountDeliveries(webhookID string) (int, error)
+}
+
+type webhookRepository struct {
+	db *sqlx.DB
+}
+
+func NewWebhookRepository(db *sqlx.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+const webhookColumns = `id, owner_id, url, description, secret, event_types, enabled,
+	consecutive_failures, disabled_reason, disabled_at, created_at, updated_at`
+
+const webhookDeliveryColumns = `id, webhook_id, event_id, event_type, payload, status, attempts,
+	response_status, response_body, error, duration_ms, redelivery_of, created_at, updated_at, delivered_at`
+
+// ownerCondition matches rows owned by $1, or ownerless rows when $1 is NULL.
+const ownerCondition = `owner_id IS NOT DISTINCT FROM $1`
+
+// nullableOwner maps the admin scope to SQL NULL.
+func nullableOwner(ownerID string) interface{} {
+	if ownerID == "" {
+		return nil
+	}
+	return ownerID
+}
+
+func (r *webhookRepository) Create(webhook *models.Webhook) error {
+	return r.db.Get(webhook, `
+		INSERT INTO webhooks (id, owner_id, url, description, secret, event_types, enabled)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+webhookColumns,
+		webhook.ID, webhook.OwnerID, webhook.URL, webhook.Description, webhook.Secret, webhook.EventTypes, webhook.Enabled,
+	)
+}
+
+func (r *webhookRepository) Get(ownerID, id string) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := r.db.Get(&webhook, `SELECT `+webhookColumns+` FROM webhooks WHERE `+ownerCondition+` AND id = $2`, nullableOwner(ownerID), id)
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// GetByID finds a webhook whoever owns it, for delivery.
+func (r *webhookRepository) GetByID(id string) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := r.db.Get(&webhook, `SELECT `+webhookColumns+` FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *webhookRepository) ListByOwner(ownerID string) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := r.db.Select(&webhooks, `SELECT `+webhookColumns+` FROM webhooks WHERE `+ownerCondition+` ORDER BY created_at`, nullableOwner(ownerID))
+	return webhooks, err
+}
+
+func (r *webhookRepository) CountByOwner(ownerID string) (int, error) {
+	var count int
+	err := r.db.Get(&count, `SELECT COUNT(*) FROM webhooks WHERE `+ownerCondition, nullableOwner(ownerID))
+	return count, err
+}
+
+// Update saves the editable fields and the enabled state, clearing the
+// failure count when the webhook is enabled. It returns sql.ErrNoRows when
+// the webhook does not exist.
+func (r *webhookRepository) Update(webhook *models.Webhook) error {
+	return r.db.Get(webhook, `
+		UPDATE webhooks
+		SET url = $2, description = $3, event_types = $4, enabled = $5,
+			consecutive_failures = CASE WHEN $5 THEN 0 ELSE consecutive_failures END,
+			disabled_reason = CASE WHEN $5 THEN '' ELSE disabled_reason END,
+			disabled_at = CASE WHEN $5 THEN NULL ELSE disabled_at END,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+webhookColumns,
+		webhook.ID, webhook.URL, webhook.Description, webhook.EventTypes, webhook.Enabled,
+	)
+}
+
+// Delete returns sql.ErrNoRows when the owner has no such webhook.
+func (r *webhookRepository) Delete(ownerID, id string) error {
+	return expectOneRow(r.db.Exec(`DELETE FROM webhooks WHERE `+ownerCondition+` AND id = $2`, nullableOwner(ownerID), id))
+}
+
+// ListSubscribed returns the enabled webhooks subscribed to eventType.
+func (r *webhookRepository) ListSubscribed(eventType string) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := r.db.Select(&webhooks, `
+		SELECT `+webhookColumns+`
+		FROM webhooks
+		WHERE enabled AND event_types @> jsonb_build_array($1::text)
+	`, eventType)
+	return webhooks, err
+}
+
+// CreateDeliveries inserts the deliveries and the jobs that send them in one
+// transaction.
+func (r *webhookRepository) CreateDeliveries(deliveries []models.WebhookDelivery, outbox []models.OutboxMessage) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, delivery := range deliveries {
+		if _, err := tx.Exec(`
+			INSERT INTO webhook_deliveries (id, webhook_id, event_id, event_type, payload, status, redelivery_of)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, delivery.ID, delivery.WebhookID, delivery.EventID, delivery.EventType, delivery.Payload, delivery.Status, delivery.RedeliveryOf); err != nil {
+			return err
+		}
+	}
+	if err := insertOutbox(tx, outbox); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *webhookRepository) GetDelivery(webhookID, id string) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.db.Get(&delivery, `SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries WHERE webhook_id = $1 AND id = $2`, webhookID, id)
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *webhookRepository) GetDeliveryByID(id string) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.db.Get(&delivery, `SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// RecordAttempt saves the outcome of an attempt and keeps the webhook's
+// failure count in step: a success resets it, and a delivery that has given
+// up adds one unless the webhook is already disabled. The webhook is disabled
+// with reason when the count reaches disableAfter; the result reports
+// whether this attempt disabled it.
+func (r *webhookRepository) RecordAttempt(delivery *models.WebhookDelivery, disableAfter int, reason string) (bool, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.Exec(`
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, response_status = $4, response_body = $5, error = $6,
+			duration_ms = $7, delivered_at = $8, updated_at = NOW()
+		WHERE id = $1
+	`, delivery.ID, delivery.Status, delivery.Attempts, delivery.ResponseStatus, delivery.ResponseBody,
+		delivery.Error, delivery.DurationMs, delivery.DeliveredAt); err != nil {
+		return false, err
+	}
+
+	disabled := false
+	switch delivery.Status {
+	case models.WebhookDeliverySucceeded:
+		_, err = tx.Exec(`UPDATE webhooks SET consecutive_failures = 0 WHERE id = $1 AND consecutive_failures > 0`, delivery.WebhookID)
+	case models.WebhookDeliveryFailed:
+		err = tx.Get(&disabled, `
+			UPDATE webhooks w
+			SET consecutive_failures = w.consecutive_failures + 1,
+				enabled = w.enabled AND w.consecutive_failures + 1 < $2,
+				disabled_reason = CASE WHEN w.consecutive_failures + 1 >= $2 AND w.enabled THEN $3 ELSE w.disabled_reason END,
+				disabled_at = CASE WHEN w.consecutive_failures + 1 >= $2 AND w.enabled THEN NOW() ELSE w.disabled_at END,
+				updated_at = NOW()
+			FROM (SELECT id, enabled FROM webhooks WHERE id = $1 FOR UPDATE) old
+			WHERE w.id = old.id AND old.enabled
+			RETURNING old.enabled AND NOT w.enabled
+		`, delivery.WebhookID, disableAfter, reason)
+		if errors.Is(err, sql.ErrNoRows) {
+			// The webhook is already disabled, or was deleted meanwhile
+			err = nil
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+	return disabled, tx.Commit()
+}
+
+func (r *webhookRepository) ListDeliveries(webhookID string, limit, offset int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.Select(&deliveries, `
+		SELECT `+webhookDeliveryColumns+`
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY created_at DESC, id
+		LIMIT $2 OFFSET $3
+	`, webhookID, limit, offset)
+	return deliveries, err
+}
+
+func (r *webhookRepository) CountDeliveries(webhookID string) (int, error) {
+	var count int
+	err := r.db.Get(&count, `SELECT COUNT(*) FROM webhook_deliveries WHERE webhook_id = $1`, webhookID)
+	return count, err
+}
@@ -6,16 +6,19 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterPostRoutes(r *gin.RouterGroup) {
+func RegisterPostRoutes(r *gin.RouterGroup, auth gin.HandlerFunc) {
 	posts := r.Group("/posts")
 	{
 		// Public routes
 		posts.GET("", controllers.ListPosts)
+		posts.GET("/stream", controllers.StreamPosts)
 		posts.GET("/:id", controllers.GetPost)
 
 		// Protected routes
-		posts.Use(middleware.Auth0())
+		posts.Use(auth)
+		posts.Use(middleware.Impersonation())
 		posts.Use(middleware.EnsureUserRole("member"))
+		posts.Use(middleware.RequireActiveUser())
 		posts.POST("", controllers.CreatePost)
 		posts.PUT("/:id", controllers.UpdatePost)
 		posts.DELETE("/:id", controllers.DeletePost)
@@ -0,0 +1,56 @@
+package routes
+
+import (
+	"log"
+
+	"github.com/dat1010/go-api/config"
+	"github.com/dat1010/go-api/controllers"
+	"github.com/dat1010/go-api/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// NewRouter builds the API's HTTP handler for cfg. Services are set on the
+// controllers separately.
+func NewRouter(cfg *config.AppConfig, db *sqlx.DB) *gin.Engine {
+	controllers.SetAuthConfig(cfg.Auth0)
+	controllers.SetVersion(cfg.Version)
+	controllers.SetSecret(cfg.Secret)
+
+	router := gin.Default()
+
+	// Tag each request with an ID for logs and the audit trail
+	router.Use(middleware.RequestID())
+
+	// Add CORS middleware
+	router.Use(middleware.CORS(cfg.CORSOrigins()))
+
+	// Trust all proxies (for older Gin versions)
+	if err := router.SetTrustedProxies(nil); err != nil {
+		log.Printf("Warning: failed to set trusted proxies: %v", err)
+	}
+	router.ForwardedByClientIP = true
+	router.AppEngine = false
+
+	// Add middleware to force HTTPS in URLs when behind load balancer
+	router.Use(func(c *gin.Context) {
+		if c.GetHeader("X-Forwarded-Proto") == "https" {
+			c.Request.URL.Scheme = "https"
+		}
+		c.Next()
+	})
+
+	// Make db available to all routes
+	router.Use(func(c *gin.Context) {
+		c.Set("db", db)
+		c.Next()
+	})
+
+	// Register routes
+	api := router.Group("/api")
+	auth := middleware.Auth0(cfg.Auth0)
+	RegisterRoutes(api, auth)
+	RegisterPostRoutes(api, auth)
+
+	return router
+}
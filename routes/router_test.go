@@ -0,0 +1,60 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dat1010/go-api/config"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func testConfig() *config.AppConfig {
+	cfg := config.Defaults()
+	cfg.Version = "test-version"
+	cfg.Auth0.Domain = "tenant.auth0.com"
+	cfg.Auth0.Audience = "https://api.example.com"
+	return cfg
+}
+
+func TestNewRouter_ServesConfiguredVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := NewRouter(testConfig(), nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/healthcheck", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"version": "test-version"`)
+}
+
+func TestNewRouter_AllowsLocalFrontendOnlyInDevelopment(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, environment := range []string{"production", "development"} {
+		cfg := testConfig()
+		cfg.Environment = environment
+		router := NewRouter(cfg, nil)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("OPTIONS", "/api/healthcheck", nil)
+		req.Header.Set("Origin", "http://localhost:3000")
+		req.Header.Set("Access-Control-Request-Method", "GET")
+		router.ServeHTTP(w, req)
+
+		allowed := w.Header().Get("Access-Control-Allow-Origin") == "http://localhost:3000"
+		assert.Equal(t, environment == "development", allowed, environment)
+	}
+}
+
+func TestNewRouter_ProtectsRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := NewRouter(testConfig(), nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/me", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -3,37 +3,102 @@ package routes
 import (
 	"github.com/dat1010/go-api/controllers"
 	"github.com/dat1010/go-api/middleware"
+	"github.com/dat1010/go-api/models"
 	"github.com/gin-gonic/gin"
 )
 
-// RegisterRoutes registers all the API routes
-func RegisterRoutes(api *gin.RouterGroup) {
+// RegisterRoutes registers all the API routes; auth authenticates the
+// protected ones.
+func RegisterRoutes(api *gin.RouterGroup, auth gin.HandlerFunc) {
 	// Auth routes
 	api.GET("/login", controllers.Login)
 	api.GET("/callback", controllers.Callback)
 	api.GET("/logout", controllers.Logout)
 	api.POST("/refresh", controllers.Refresh)
+	api.GET("/invitations/accept", controllers.AcceptInvitation)
 
 	// Public routes
 	api.GET("/healthcheck", controllers.GetHealthCheck)
 	api.GET("/secrets", controllers.GetSecret)
 	api.GET("/discord-ping", controllers.PingDiscord)
+	// Event targets authenticate run reports with a shared-secret signature
+	api.POST("/callbacks/event-runs", controllers.ReportEventRun)
 
 	// Protected routes
 	protected := api.Group("")
-	protected.Use(middleware.Auth0())
+	protected.Use(auth)
+	protected.Use(middleware.Impersonation())
 	protected.Use(middleware.EnsureUserRole("member"))
+	protected.Use(middleware.RequireActiveUser())
 	protected.GET("/me", controllers.CheckAuth)
-	protected.POST("/events", controllers.CreateEvent)
+	// Only the account owner may export or erase it
+	protected.GET("/me/export", middleware.DenyImpersonation(), controllers.ExportMyData)
+	protected.DELETE("/me", middleware.DenyImpersonation(), controllers.DeleteMyAccount)
+	protected.GET("/me/deletion", middleware.DenyImpersonation(), controllers.GetMyAccountDeletion)
+	protected.DELETE("/me/deletion", middleware.DenyImpersonation(), controllers.CancelMyAccountDeletion)
+	protected.POST("/events", middleware.RequirePermission(models.PermissionEventsCreate), controllers.CreateEvent)
 	protected.GET("/events", controllers.ListUserEvents)
+	protected.POST("/events/preview", controllers.PreviewEventSchedule)
+	protected.GET("/events/:name", controllers.GetEvent)
+	protected.PUT("/events/:name", controllers.UpdateEvent)
+	protected.DELETE("/events/:name", controllers.DeleteEvent)
+	protected.POST("/events/:name/pause", controllers.PauseEvent)
+	protected.POST("/events/:name/resume", controllers.ResumeEvent)
+	protected.GET("/events/:name/runs", controllers.ListEventRuns)
+	protected.GET("/event-targets", controllers.ListEventTargets)
+	protected.GET("/event-target-schemas", controllers.ListEventTargetSchemas)
+	protected.GET("/notifications/preferences", controllers.ListNotificationPreferences)
+	protected.PUT("/notifications/preferences/:channel", controllers.PutNotificationPreference)
+	protected.DELETE("/notifications/preferences/:channel", controllers.DeleteNotificationPreference)
+	protected.POST("/notifications/preferences/:channel/test", controllers.TestNotificationPreference)
+	protected.GET("/webhooks", controllers.ListWebhooks)
+	protected.POST("/webhooks", controllers.CreateWebhook)
+	protected.GET("/webhooks/:id", controllers.GetWebhook)
+	protected.PATCH("/webhooks/:id", controllers.UpdateWebhook)
+	protected.DELETE("/webhooks/:id", controllers.DeleteWebhook)
+	protected.GET("/webhooks/:id/deliveries", controllers.ListWebhookDeliveries)
+	protected.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", controllers.RedeliverWebhook)
 
-	// Admin routes (superadmin only)
+	// Ending impersonation must work even when the session has expired
+	api.DELETE("/impersonation", auth, controllers.EndImpersonation)
+
+	// Admin routes (superadmin only, never while impersonating)
 	admin := api.Group("/admin")
-	admin.Use(middleware.Auth0())
+	admin.Use(auth)
+	admin.Use(middleware.DenyImpersonation())
 	admin.Use(middleware.EnsureUserRole("member"))
+	admin.Use(middleware.RequireActiveUser())
 	admin.Use(middleware.RequireRole("superadmin"))
 	admin.GET("/users", controllers.ListUsers)
 	admin.POST("/users", controllers.CreateUser)
 	admin.PATCH("/users/:id/role", controllers.UpdateUserRole)
 	admin.DELETE("/users/:id", controllers.DeleteUser)
+	admin.POST("/users/:id/suspend", controllers.SuspendUser)
+	admin.POST("/users/:id/ban", controllers.BanUser)
+	admin.POST("/users/:id/reinstate", controllers.ReinstateUser)
+	admin.PUT("/users/:id/event-quota", controllers.SetUserEventQuota)
+	admin.GET("/roles", controllers.ListRoles)
+	admin.POST("/roles", controllers.CreateRole)
+	admin.PUT("/roles/:name/permissions", controllers.SetRolePermissions)
+	admin.POST("/events/reconcile", controllers.ReconcileEvents)
+	admin.GET("/event-targets", controllers.AdminListEventTargets)
+	admin.POST("/event-targets", controllers.CreateEventTarget)
+	admin.DELETE("/event-targets/:name", controllers.DeleteEventTarget)
+	admin.PUT("/event-target-schemas/:kind", controllers.PutEventTargetSchema)
+	admin.DELETE("/event-target-schemas/:kind", controllers.DeleteEventTargetSchema)
+	admin.GET("/jobs", controllers.ListJobs)
+	admin.POST("/jobs/:id/retry", controllers.RetryJob)
+	admin.GET("/webhooks", controllers.AdminListWebhooks)
+	admin.POST("/webhooks", controllers.AdminCreateWebhook)
+	admin.GET("/webhooks/:id", controllers.AdminGetWebhook)
+	admin.PATCH("/webhooks/:id", controllers.AdminUpdateWebhook)
+	admin.DELETE("/webhooks/:id", controllers.AdminDeleteWebhook)
+	admin.GET("/webhooks/:id/deliveries", controllers.AdminListWebhookDeliveries)
+	admin.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", controllers.AdminRedeliverWebhook)
+	admin.GET("/invitations", controllers.ListInvitations)
+	admin.POST("/invitations", controllers.CreateInvitation)
+	admin.DELETE("/invitations/:id", controllers.RevokeInvitation)
+	admin.POST("/impersonate", controllers.StartImpersonation)
+	admin.GET("/audit", controllers.ListAuditLogs)
+	admin.GET("/audit/export", controllers.ExportAuditLogs)
 }
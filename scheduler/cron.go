@@ -0,0 +1,504 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidExpression is returned for schedule expressions that cannot be parsed.
+var ErrInvalidExpression = errors.New("invalid schedule expression")
+
+// FieldError describes one problem with one field of a schedule expression.
+type FieldError struct {
+	Field   string `json:"field" example:"minutes"`
+	Value   string `json:"value,omitempty" example:"61"`
+	Message string `json:"message" example:"value \"61\" out of range 0-59"`
+}
+
+// ExpressionError lists every problem found in a schedule expression. It
+// matches ErrInvalidExpression with errors.Is.
+type ExpressionError struct {
+	Expression string
+	Fields     []FieldError
+}
+
+func (e *ExpressionError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		parts[i] = field.Field + ": " + field.Message
+	}
+	return fmt.Sprintf("%v: %s", ErrInvalidExpression, strings.Join(parts, "; "))
+}
+
+func (e *ExpressionError) Unwrap() error {
+	return ErrInvalidExpression
+}
+
+func (e *ExpressionError) add(field, value, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Value: value, Message: message})
+}
+
+// Expression is a parsed cron(...), rate(...) or at(...) schedule.
+type Expression interface {
+	// Next returns the first fire time strictly after the given time, or
+	// false if the schedule never fires again.
+	Next(after time.Time) (time.Time, bool)
+	// String returns the expression in the form EventBridge accepts.
+	String() string
+}
+
+// ParseExpression parses an AWS schedule expression evaluated in UTC.
+// cron(...), rate(...) and at(...) are accepted, as is a bare six-field cron
+// expression.
+func ParseExpression(raw string) (Expression, error) {
+	return ParseExpressionIn(raw, time.UTC)
+}
+
+// ParseExpressionIn parses an expression whose cron fields and at(...) time
+// are wall-clock times in loc.
+func ParseExpressionIn(raw string, loc *time.Location) (Expression, error) {
+	expr := strings.TrimSpace(raw)
+	switch {
+	case strings.HasPrefix(expr, "rate(") && strings.HasSuffix(expr, ")"):
+		return parseRate(strings.TrimSuffix(strings.TrimPrefix(expr, "rate("), ")"))
+	case strings.HasPrefix(expr, "at(") && strings.HasSuffix(expr, ")"):
+		return parseAt(strings.TrimSuffix(strings.TrimPrefix(expr, "at("), ")"), loc)
+	case strings.HasPrefix(expr, "cron(") && strings.HasSuffix(expr, ")"):
+		return parseCronIn(strings.TrimSuffix(strings.TrimPrefix(expr, "cron("), ")"), loc)
+	default:
+		return parseCronIn(expr, loc)
+	}
+}
+
+// IsOneTime reports whether the expression is an at(...) schedule.
+func IsOneTime(raw string) bool {
+	return strings.HasPrefix(strings.TrimSpace(raw), "at(")
+}
+
+// Compile parses expression in the named IANA time zone (UTC when empty) and
+// limits it to the optional start/end window. Problems with any of the
+// inputs are reported together in one *ExpressionError.
+func Compile(expression, timeZone string, start, end *time.Time) (Expression, error) {
+	invalid := &ExpressionError{Expression: expression}
+
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		invalid.add("timezone", timeZone, "unknown IANA time zone")
+		loc = time.UTC
+	}
+	if start != nil && end != nil && !end.After(*start) {
+		invalid.add("end_at", end.Format(time.RFC3339), "must be after start_at")
+	}
+
+	expr, err := ParseExpressionIn(expression, loc)
+	if err != nil {
+		var exprErr *ExpressionError
+		if !errors.As(err, &exprErr) {
+			return nil, err
+		}
+		invalid.Fields = append(exprErr.Fields, invalid.Fields...)
+	}
+
+	if len(invalid.Fields) > 0 {
+		return nil, invalid
+	}
+	if start == nil && end == nil {
+		return expr, nil
+	}
+	return &windowedSchedule{Expression: expr, start: start, end: end}, nil
+}
+
+// windowedSchedule only fires between start and end.
+type windowedSchedule struct {
+	Expression
+	start, end *time.Time
+}
+
+func (s *windowedSchedule) Next(after time.Time) (time.Time, bool) {
+	if s.start != nil && after.Before(*s.start) {
+		after = s.start.Add(-time.Nanosecond)
+	}
+	next, ok := s.Expression.Next(after)
+	if !ok || (s.end != nil && next.After(*s.end)) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+// atLayout is the wall-clock format EventBridge Scheduler uses inside at(...).
+const atLayout = "2006-01-02T15:04:05"
+
+// atSchedule fires once.
+type atSchedule struct {
+	at time.Time
+}
+
+func parseAt(expr string, loc *time.Location) (*atSchedule, error) {
+	at, err := time.ParseInLocation(atLayout, strings.TrimSpace(expr), loc)
+	if err != nil {
+		invalid := &ExpressionError{Expression: "at(" + expr + ")"}
+		invalid.add("at", expr, "must be a date and time like 2024-03-20T09:00:00")
+		return nil, invalid
+	}
+	return &atSchedule{at: at}, nil
+}
+
+func (s *atSchedule) Next(after time.Time) (time.Time, bool) {
+	if s.at.After(after) {
+		return s.at.UTC(), true
+	}
+	return time.Time{}, false
+}
+
+func (s *atSchedule) String() string {
+	return "at(" + s.at.Format(atLayout) + ")"
+}
+
+// NextRuns returns up to n fire times after from, expressed in loc.
+func NextRuns(expr Expression, from time.Time, n int, loc *time.Location) []time.Time {
+	runs := make([]time.Time, 0, n)
+	for t := from; len(runs) < n; {
+		next, ok := expr.Next(t)
+		if !ok {
+			break
+		}
+		runs = append(runs, next.In(loc))
+		t = next
+	}
+	return runs
+}
+
+// rateSchedule fires every interval. EventBridge counts from when the rule
+// was created or last changed, so Next counts from the time it is given.
+type rateSchedule struct {
+	value    int
+	unit     string
+	interval time.Duration
+}
+
+var rateUnits = map[string]time.Duration{
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+}
+
+func parseRate(expr string) (*rateSchedule, error) {
+	invalid := &ExpressionError{Expression: "rate(" + expr + ")"}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 2 {
+		invalid.add("rate", expr, "expected a value and a unit, e.g. rate(5 minutes)")
+		return nil, invalid
+	}
+
+	value, err := strconv.Atoi(fields[0])
+	if err != nil || value <= 0 {
+		invalid.add("value", fields[0], "must be a positive whole number")
+	}
+
+	unit := fields[1]
+	interval, ok := rateUnits[strings.TrimSuffix(unit, "s")]
+	switch {
+	case !ok:
+		invalid.add("unit", unit, "must be minute(s), hour(s) or day(s)")
+	case value == 1 && strings.HasSuffix(unit, "s"):
+		invalid.add("unit", unit, "must be singular when the value is 1")
+	case value > 1 && !strings.HasSuffix(unit, "s"):
+		invalid.add("unit", unit, "must be plural when the value is greater than 1")
+	}
+
+	if len(invalid.Fields) > 0 {
+		return nil, invalid
+	}
+	return &rateSchedule{value: value, unit: unit, interval: time.Duration(value) * interval}, nil
+}
+
+func (s *rateSchedule) Next(after time.Time) (time.Time, bool) {
+	return after.UTC().Add(s.interval).Truncate(time.Minute), true
+}
+
+func (s *rateSchedule) String() string {
+	return fmt.Sprintf("rate(%d %s)", s.value, s.unit)
+}
+
+// cronField is the set of allowed values for one field of a cron expression.
+type cronField struct {
+	min, max int
+	allowed  []bool
+	any      bool
+}
+
+func (f *cronField) matches(value int) bool {
+	if f.any {
+		return true
+	}
+	if value < f.min || value > f.max {
+		return false
+	}
+	return f.allowed[value-f.min]
+}
+
+// dayMatcher reports whether a cron expression fires on the given day.
+type dayMatcher func(t time.Time) bool
+
+// cronSchedule is a parsed AWS-style six-field cron expression:
+// minutes hours day-of-month month day-of-week year, evaluated in loc.
+type cronSchedule struct {
+	fields  []string
+	loc     *time.Location
+	minutes cronField
+	hours   cronField
+	days    dayMatcher
+	months  cronField
+	years   cronField
+}
+
+var monthNames = map[string]int{
+	"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+	"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+}
+
+// Day-of-week numbering follows AWS: 1 is Sunday, 7 is Saturday.
+var dayNames = map[string]int{
+	"SUN": 1, "MON": 2, "TUE": 3, "WED": 4, "THU": 5, "FRI": 6, "SAT": 7,
+}
+
+// parseCron parses the expression inside cron(...) for evaluation in UTC.
+func parseCron(expr string) (*cronSchedule, error) {
+	return parseCronIn(expr, time.UTC)
+}
+
+// parseCronIn parses the expression inside cron(...), reporting every invalid
+// field rather than stopping at the first.
+func parseCronIn(expr string, loc *time.Location) (*cronSchedule, error) {
+	invalid := &ExpressionError{Expression: "cron(" + expr + ")"}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 6 {
+		invalid.add("cron", expr, fmt.Sprintf("expected 6 fields (minutes hours day-of-month month day-of-week year), got %d", len(fields)))
+		return nil, invalid
+	}
+
+	schedule := &cronSchedule{fields: fields, loc: loc}
+	parse := func(name string, raw string, min, max int, names map[string]int) cronField {
+		field, err := parseCronField(raw, min, max, names)
+		if err != nil {
+			invalid.add(name, raw, err.Error())
+		}
+		return field
+	}
+	schedule.minutes = parse("minutes", fields[0], 0, 59, nil)
+	schedule.hours = parse("hours", fields[1], 0, 23, nil)
+	schedule.months = parse("month", fields[3], 1, 12, monthNames)
+	schedule.years = parse("year", fields[5], 1970, 2199, nil)
+
+	domQuestion := fields[2] == "?"
+	dowQuestion := fields[4] == "?"
+	switch {
+	case domQuestion == dowQuestion:
+		invalid.add("day-of-week", fields[4], "exactly one of day-of-month and day-of-week must be ?")
+	case dowQuestion:
+		days, err := parseDayOfMonth(fields[2])
+		if err != nil {
+			invalid.add("day-of-month", fields[2], err.Error())
+		}
+		schedule.days = days
+	default:
+		days, err := parseDayOfWeek(fields[4])
+		if err != nil {
+			invalid.add("day-of-week", fields[4], err.Error())
+		}
+		schedule.days = days
+	}
+
+	if len(invalid.Fields) > 0 {
+		return nil, invalid
+	}
+	return schedule, nil
+}
+
+func parseCronField(raw string, min, max int, names map[string]int) (cronField, error) {
+	field := cronField{min: min, max: max, allowed: make([]bool, max-min+1)}
+	if raw == "*" || raw == "?" {
+		field.any = true
+		return field, nil
+	}
+
+	for _, part := range strings.Split(raw, ",") {
+		rangePart, step := part, 1
+		if before, after, ok := strings.Cut(part, "/"); ok {
+			rangePart = before
+			value, err := strconv.Atoi(after)
+			if err != nil || value <= 0 {
+				return field, fmt.Errorf("invalid step %q", after)
+			}
+			step = value
+		}
+
+		start, end := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			lo, hi, _ := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = parseCronValue(lo, names); err != nil {
+				return field, err
+			}
+			if end, err = parseCronValue(hi, names); err != nil {
+				return field, err
+			}
+		default:
+			value, err := parseCronValue(rangePart, names)
+			if err != nil {
+				return field, err
+			}
+			start = value
+			if step == 1 {
+				end = value
+			}
+		}
+
+		if start < min || end > max || start > end {
+			return field, fmt.Errorf("value %q out of range %d-%d", part, min, max)
+		}
+		for v := start; v <= end; v += step {
+			field.allowed[v-min] = true
+		}
+	}
+
+	return field, nil
+}
+
+func parseCronValue(raw string, names map[string]int) (int, error) {
+	if value, ok := names[strings.ToUpper(raw)]; ok {
+		return value, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", raw)
+	}
+	return value, nil
+}
+
+// parseDayOfMonth handles L (last day of the month) and nW (the weekday
+// nearest day n) on top of the usual lists, ranges and steps.
+func parseDayOfMonth(raw string) (dayMatcher, error) {
+	switch {
+	case raw == "L":
+		return func(t time.Time) bool { return t.Day() == daysInMonth(t) }, nil
+	case strings.HasSuffix(raw, "W"):
+		day, err := strconv.Atoi(strings.TrimSuffix(raw, "W"))
+		if err != nil || day < 1 || day > 31 {
+			return nil, fmt.Errorf("invalid nearest-weekday value %q", raw)
+		}
+		return func(t time.Time) bool { return t.Day() == nearestWeekday(t, day) }, nil
+	}
+
+	field, err := parseCronField(raw, 1, 31, nil)
+	if err != nil {
+		return nil, err
+	}
+	return func(t time.Time) bool { return field.matches(t.Day()) }, nil
+}
+
+// parseDayOfWeek handles L (Saturday), nL (the last day n of the month) and
+// n#k (the kth day n of the month) on top of the usual lists and ranges.
+func parseDayOfWeek(raw string) (dayMatcher, error) {
+	switch {
+	case raw == "L":
+		return func(t time.Time) bool { return t.Weekday() == time.Saturday }, nil
+	case strings.Contains(raw, "#"):
+		dayPart, nthPart, _ := strings.Cut(raw, "#")
+		day, err := parseCronValue(dayPart, dayNames)
+		if err != nil || day < 1 || day > 7 {
+			return nil, fmt.Errorf("invalid day %q", dayPart)
+		}
+		nth, err := strconv.Atoi(nthPart)
+		if err != nil || nth < 1 || nth > 5 {
+			return nil, fmt.Errorf("invalid occurrence %q, must be 1-5", nthPart)
+		}
+		return func(t time.Time) bool {
+			return int(t.Weekday())+1 == day && (t.Day()-1)/7+1 == nth
+		}, nil
+	case len(raw) > 1 && strings.HasSuffix(raw, "L"):
+		day, err := parseCronValue(strings.TrimSuffix(raw, "L"), dayNames)
+		if err != nil || day < 1 || day > 7 {
+			return nil, fmt.Errorf("invalid day %q", raw)
+		}
+		return func(t time.Time) bool {
+			return int(t.Weekday())+1 == day && t.Day()+7 > daysInMonth(t)
+		}, nil
+	}
+
+	field, err := parseCronField(raw, 1, 7, dayNames)
+	if err != nil {
+		return nil, err
+	}
+	return func(t time.Time) bool { return field.matches(int(t.Weekday()) + 1) }, nil
+}
+
+func daysInMonth(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+}
+
+// nearestWeekday returns the weekday closest to day within t's month.
+func nearestWeekday(t time.Time, day int) int {
+	last := daysInMonth(t)
+	if day > last {
+		day = last
+	}
+	target := time.Date(t.Year(), t.Month(), day, 0, 0, 0, 0, t.Location())
+	switch target.Weekday() {
+	case time.Saturday:
+		if day == 1 {
+			return 3
+		}
+		return day - 1
+	case time.Sunday:
+		if day == last {
+			return day - 2
+		}
+		return day + 1
+	}
+	return day
+}
+
+// Next returns the first fire time strictly after the given time, in UTC.
+func (s *cronSchedule) Next(after time.Time) (time.Time, bool) {
+	loc := s.loc
+	t := after.In(loc)
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc).Add(time.Minute)
+
+	for t.Year() <= s.years.max {
+		if !s.years.matches(t.Year()) {
+			t = time.Date(t.Year()+1, time.January, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.months.matches(int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.days(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.hours.matches(t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if !s.minutes.matches(t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t.UTC(), true
+	}
+
+	return time.Time{}, false
+}
+
+func (s *cronSchedule) String() string {
+	return "cron(" + strings.Join(s.fields, " ") + ")"
+}
@@ -0,0 +1,126 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"0 12 * * ?",     // too few fields
+		"0 12 * * * *",   // neither day field is ?
+		"0 12 ? * ? *",   // both day fields are ?
+		"61 12 * * ? *",  // minute out of range
+		"0 12 * FOO ? *", // unknown month name
+		"0/0 12 * * ? *", // zero step
+	} {
+		_, err := parseCron(expr)
+		assert.True(t, errors.Is(err, ErrInvalidExpression), "expected %q to be invalid, got %v", expr, err)
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	start := time.Date(2024, time.March, 20, 12, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"0 12 * * ? *", time.Date(2024, time.March, 21, 12, 0, 0, 0, time.UTC)},
+		{"0/15 * * * ? *", time.Date(2024, time.March, 20, 12, 45, 0, 0, time.UTC)},
+		{"0 9 ? * MON-FRI *", time.Date(2024, time.March, 21, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 JAN ? 2025", time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"30 8 ? * 1 *", time.Date(2024, time.March, 24, 8, 30, 0, 0, time.UTC)}, // Sunday
+	}
+
+	for _, tc := range cases {
+		cron, err := parseCron(tc.expr)
+		if !assert.NoError(t, err, tc.expr) {
+			continue
+		}
+		next, ok := cron.Next(start)
+		assert.True(t, ok, tc.expr)
+		assert.Equal(t, tc.want, next, tc.expr)
+	}
+}
+
+func TestCronNext_NeverFires(t *testing.T) {
+	cron, err := parseCron("0 0 1 JAN ? 2020")
+	assert.NoError(t, err)
+	_, ok := cron.Next(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+	assert.False(t, ok)
+}
+
+func TestParseCron_ReportsEveryBadField(t *testing.T) {
+	_, err := ParseExpression("cron(61 25 * FOO ? *)")
+
+	var exprErr *ExpressionError
+	if assert.True(t, errors.As(err, &exprErr)) {
+		var fields []string
+		for _, field := range exprErr.Fields {
+			fields = append(fields, field.Field)
+		}
+		assert.Equal(t, []string{"minutes", "hours", "month"}, fields)
+	}
+	assert.ErrorIs(t, err, ErrInvalidExpression)
+}
+
+func TestCronNext_SpecialDays(t *testing.T) {
+	start := time.Date(2024, time.March, 20, 12, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"0 0 L * ? *", time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 0 ? * 6L *", time.Date(2024, time.March, 29, 0, 0, 0, 0, time.UTC)},   // last Friday
+		{"0 0 ? * MON#2 *", time.Date(2024, time.April, 8, 0, 0, 0, 0, time.UTC)}, // second Monday
+		{"0 0 15W * ? *", time.Date(2024, time.April, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1W JUN ? *", time.Date(2024, time.June, 3, 0, 0, 0, 0, time.UTC)}, // June 1st is a Saturday
+	}
+
+	for _, tc := range cases {
+		expr, err := ParseExpression(tc.expr)
+		if !assert.NoError(t, err, tc.expr) {
+			continue
+		}
+		next, ok := expr.Next(start)
+		assert.True(t, ok, tc.expr)
+		assert.Equal(t, tc.want, next, tc.expr)
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	expr, err := ParseExpression("rate(5 minutes)")
+	assert.NoError(t, err)
+	assert.Equal(t, "rate(5 minutes)", expr.String())
+
+	start := time.Date(2024, time.March, 20, 12, 30, 10, 0, time.UTC)
+	runs := NextRuns(expr, start, 3, time.UTC)
+	assert.Equal(t, []time.Time{
+		time.Date(2024, time.March, 20, 12, 35, 0, 0, time.UTC),
+		time.Date(2024, time.March, 20, 12, 40, 0, 0, time.UTC),
+		time.Date(2024, time.March, 20, 12, 45, 0, 0, time.UTC),
+	}, runs)
+
+	for _, bad := range []string{"rate(1 minutes)", "rate(5 minute)", "rate(0 hours)", "rate(5 weeks)", "rate(5)"} {
+		_, err := ParseExpression(bad)
+		assert.ErrorIs(t, err, ErrInvalidExpression, bad)
+	}
+}
+
+func TestNextRuns_InTimeZone(t *testing.T) {
+	expr, err := ParseExpression("cron(0 12 * * ? *)")
+	assert.NoError(t, err)
+	loc, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+
+	runs := NextRuns(expr, time.Date(2024, time.March, 20, 0, 0, 0, 0, time.UTC), 2, loc)
+
+	assert.Len(t, runs, 2)
+	assert.Equal(t, 8, runs[0].Hour())
+	assert.Equal(t, loc, runs[0].Location())
+}
@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"slices"
+)
+
+// Fields Diff reports as differing between a stored and an actual schedule.
+const (
+	DriftFieldExpression  = "expression"
+	DriftFieldTimeZone    = "timezone"
+	DriftFieldDescription = "description"
+	DriftFieldState       = "state"
+	DriftFieldTargets     = "targets"
+	DriftFieldPayload     = "payload"
+)
+
+// Diff compares the schedule the API expects with one read back from a
+// backend's ListByOwner and returns the fields that differ. Backends do not
+// report everything, so fields they leave empty are not compared: the default
+// target's ARN is backend configuration, and payloads are only compared for
+// schedules without targets, since templates reshape them per target.
+func Diff(expected, actual *Schedule) []string {
+	var fields []string
+	if !sameExpression(expected, actual) {
+		fields = append(fields, DriftFieldExpression)
+	}
+	if actual.TimeZone != "" && timeZoneOrUTC(actual.TimeZone) != timeZoneOrUTC(expected.TimeZone) {
+		fields = append(fields, DriftFieldTimeZone)
+	}
+	if expected.Description != actual.Description {
+		fields = append(fields, DriftFieldDescription)
+	}
+	if expected.Disabled != actual.Disabled {
+		fields = append(fields, DriftFieldState)
+	}
+	if len(expected.Targets) > 0 && !slices.Equal(targetArns(expected.Targets), targetArns(actual.Targets)) {
+		fields = append(fields, DriftFieldTargets)
+	}
+	if len(expected.Targets) == 0 && actual.Payload != nil && !samePayload(expected.Payload, actual.Payload) {
+		fields = append(fields, DriftFieldPayload)
+	}
+	return fields
+}
+
+// sameExpression compares the expressions in the form the backends store,
+// so "0 12 * * ? *" matches "cron(0 12 * * ? *)".
+func sameExpression(expected, actual *Schedule) bool {
+	want, err := ParseExpression(expected.Expression)
+	if err != nil {
+		return expected.Expression == actual.Expression
+	}
+	got, err := ParseExpression(actual.Expression)
+	if err != nil {
+		return false
+	}
+	return want.String() == got.String()
+}
+
+func timeZoneOrUTC(timeZone string) string {
+	if timeZone == "" {
+		return "UTC"
+	}
+	return timeZone
+}
+
+func targetArns(targets []ScheduleTarget) []string {
+	arns := make([]string, 0, len(targets))
+	for _, target := range targets {
+		arns = append(arns, target.Arn)
+	}
+	slices.Sort(arns)
+	return arns
+}
@@ -0,0 +1,83 @@
+package scheduler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// MaxInputBytes is the largest input EventBridge accepts for one target.
+const MaxInputBytes = 8192
+
+// ErrInputTooLarge is returned when a target's rendered input exceeds MaxInputBytes.
+var ErrInputTooLarge = errors.New("schedule input too large")
+
+// Envelope is what a target receives when it has no input template. The
+// owner and schedule name sit beside the client's payload rather than inside
+// it, so no payload can claim to come from another user.
+type Envelope struct {
+	UserID   string          `json:"user_id"`
+	RuleName string          `json:"rule_name"`
+	Payload  json.RawMessage `json:"payload"`
+}
+
+// envelopeFor wraps the schedule's payload; a missing payload is sent as {}.
+func envelopeFor(schedule *Schedule) Envelope {
+	payload := schedule.Payload
+	if len(payload) == 0 {
+		payload = json.RawMessage(`{}`)
+	}
+	return Envelope{UserID: schedule.OwnerID, RuleName: schedule.Name, Payload: payload}
+}
+
+// payloadFromInput recovers the payload from a target input read back from a
+// backend. Inputs that are not an Envelope, such as those written before
+// envelopes existed, are returned whole so they show up as drift.
+func payloadFromInput(input []byte) json.RawMessage {
+	var envelope Envelope
+	if err := json.Unmarshal(input, &envelope); err == nil && envelope.UserID != "" && len(envelope.Payload) > 0 {
+		return envelope.Payload
+	}
+	if !json.Valid(input) {
+		return nil
+	}
+	return json.RawMessage(input)
+}
+
+// checkInputSize rejects inputs EventBridge would refuse.
+func checkInputSize(targetID, input string) error {
+	if len(input) > MaxInputBytes {
+		return fmt.Errorf("%w: target %s would receive %d bytes, at most %d are allowed",
+			ErrInputTooLarge, targetID, len(input), MaxInputBytes)
+	}
+	return nil
+}
+
+// samePayload compares two JSON documents by value, so key order and
+// whitespace don't matter. Missing payloads equal {}.
+func samePayload(a, b json.RawMessage) bool {
+	var left, right interface{}
+	if err := decodeJSON(orEmptyObject(a), &left); err != nil {
+		return false
+	}
+	if err := decodeJSON(orEmptyObject(b), &right); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(left, right)
+}
+
+func orEmptyObject(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 {
+		return json.RawMessage(`{}`)
+	}
+	return raw
+}
+
+// decodeJSON keeps numbers as json.Number so large integers survive intact.
+func decodeJSON(raw []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
@@ -0,0 +1,391 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+)
+
+// EventBridgeScheduler stores each schedule as an EventBridge rule with up
+// to MaxTargets targets. Rules only run recurring expressions in UTC; use
+// SchedulerAPIScheduler for one-time or time-zone-aware schedules.
+type EventBridgeScheduler struct {
+	client    EventBridgeAPI
+	targetArn string
+}
+
+// EventBridgeAPI is the part of *eventbridge.Client the scheduler uses, so
+// tests can substitute a fake.
+type EventBridgeAPI interface {
+	PutRule(ctx context.Context, params *eventbridge.PutRuleInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutRuleOutput, error)
+	PutTargets(ctx context.Context, params *eventbridge.PutTargetsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutTargetsOutput, error)
+	RemoveTargets(ctx context.Context, params *eventbridge.RemoveTargetsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.RemoveTargetsOutput, error)
+	ListTargetsByRule(ctx context.Context, params *eventbridge.ListTargetsByRuleInput, optFns ...func(*eventbridge.Options)) (*eventbridge.ListTargetsByRuleOutput, error)
+	EnableRule(ctx context.Context, params *eventbridge.EnableRuleInput, optFns ...func(*eventbridge.Options)) (*eventbridge.EnableRuleOutput, error)
+	DisableRule(ctx context.Context, params *eventbridge.DisableRuleInput, optFns ...func(*eventbridge.Options)) (*eventbridge.DisableRuleOutput, error)
+	DeleteRule(ctx context.Context, params *eventbridge.DeleteRuleInput, optFns ...func(*eventbridge.Options)) (*eventbridge.DeleteRuleOutput, error)
+	ListRules(ctx context.Context, params *eventbridge.ListRulesInput, optFns ...func(*eventbridge.Options)) (*eventbridge.ListRulesOutput, error)
+	ListTagsForResource(ctx context.Context, params *eventbridge.ListTagsForResourceInput, optFns ...func(*eventbridge.Options)) (*eventbridge.ListTagsForResourceOutput, error)
+}
+
+// NewEventBridgeScheduler manages rules through client. Rules whose schedule
+// names no targets invoke targetArn.
+func NewEventBridgeScheduler(client EventBridgeAPI, targetArn string) *EventBridgeScheduler {
+	return &EventBridgeScheduler{client: client, targetArn: targetArn}
+}
+
+// Create puts the rule and its targets. If the targets cannot be put, the rule
+// is deleted again rather than left enabled with nothing to deliver to.
+func (s *EventBridgeScheduler) Create(ctx context.Context, schedule *Schedule) error {
+	if err := s.putRule(ctx, schedule, true); err != nil {
+		return err
+	}
+	schedule.CreatedAt = time.Now()
+	return nil
+}
+
+// Update overwrites the rule and its target; PutRule and PutTargets are upserts.
+func (s *EventBridgeScheduler) Update(ctx context.Context, schedule *Schedule) error {
+	return s.putRule(ctx, schedule, false)
+}
+
+// Check builds the rule and its targets without putting them.
+func (s *EventBridgeScheduler) Check(schedule *Schedule) error {
+	_, _, err := s.ruleInput(schedule)
+	return err
+}
+
+// ruleInput parses the schedule's expression and builds its targets,
+// rejecting schedules that need EventBridge Scheduler.
+func (s *EventBridgeScheduler) ruleInput(schedule *Schedule) (Expression, []types.Target, error) {
+	if schedule.needsSchedulerAPI() {
+		return nil, nil, fmt.Errorf("%w: EventBridge rules only run recurring UTC schedules without windows", ErrUnsupported)
+	}
+	expr, err := ParseExpression(schedule.Expression)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := schedule.validateTargets(); err != nil {
+		return nil, nil, err
+	}
+	targets, err := s.ruleTargets(schedule)
+	if err != nil {
+		return nil, nil, err
+	}
+	return expr, targets, nil
+}
+
+// putRule puts the rule and then its targets, deleting the rule when the
+// targets fail and rollback is set.
+func (s *EventBridgeScheduler) putRule(ctx context.Context, schedule *Schedule, rollback bool) error {
+	expr, targets, err := s.ruleInput(schedule)
+	if err != nil {
+		return err
+	}
+
+	state := types.RuleStateEnabled
+	if schedule.Disabled {
+		state = types.RuleStateDisabled
+	}
+
+	// Tags only apply when the rule is first created; PutRule leaves an existing rule's tags alone
+	_, err = s.client.PutRule(ctx, &eventbridge.PutRuleInput{
+		Name:               aws.String(schedule.Name),
+		Description:        aws.String(schedule.Description),
+		ScheduleExpression: aws.String(expr.String()),
+		State:              state,
+		Tags: []types.Tag{
+			{Key: aws.String(TagKeyApp), Value: aws.String(AppName)},
+			{Key: aws.String(TagKeyOwner), Value: aws.String(OwnerTag(schedule.OwnerID))},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create rule: %w", err)
+	}
+
+	put, err := s.client.PutTargets(ctx, &eventbridge.PutTargetsInput{
+		Rule:    aws.String(schedule.Name),
+		Targets: targets,
+	})
+	if err == nil && put.FailedEntryCount > 0 {
+		err = failedEntriesError(put.FailedEntries)
+	}
+	if err != nil {
+		if rollback {
+			s.rollbackRule(ctx, schedule.Name, targets)
+		}
+		return fmt.Errorf("failed to create target: %w", err)
+	}
+	return s.removeStaleTargets(ctx, schedule.Name, targets)
+}
+
+// rollbackRule deletes a rule whose targets failed, along with any of them
+// that were put. Failures are only logged: the reconciler reports rules left
+// behind as orphans.
+func (s *EventBridgeScheduler) rollbackRule(ctx context.Context, name string, targets []types.Target) {
+	ids := make([]string, 0, len(targets))
+	for _, target := range targets {
+		ids = append(ids, aws.ToString(target.Id))
+	}
+	if err := s.deleteRule(ctx, name, ids); err != nil {
+		log.Printf("eventbridge: failed to roll back rule %s: %v", name, err)
+	}
+}
+
+// failedEntriesError describes the targets PutTargets rejected.
+func failedEntriesError(entries []types.PutTargetsResultEntry) error {
+	failed := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		failed = append(failed, fmt.Sprintf("%s: %s", aws.ToString(entry.TargetId), aws.ToString(entry.ErrorMessage)))
+	}
+	return fmt.Errorf("%d targets rejected: %s", len(entries), strings.Join(failed, "; "))
+}
+
+// ruleTargets converts the schedule's targets into EventBridge targets.
+// Templates that use the fire time become input transformers reading the
+// scheduled event's time field; everything else is sent as constant input.
+func (s *EventBridgeScheduler) ruleTargets(schedule *Schedule) ([]types.Target, error) {
+	envelope := envelopeFor(schedule)
+	var targets []types.Target
+	for _, target := range schedule.targetsOr(s.targetArn) {
+		input, err := target.renderInput(envelope)
+		if err != nil {
+			return nil, err
+		}
+		ruleTarget := types.Target{
+			Id:  aws.String(target.ID),
+			Arn: aws.String(target.Arn),
+		}
+		if target.RoleArn != "" {
+			ruleTarget.RoleArn = aws.String(target.RoleArn)
+		}
+		if strings.Contains(input, TimePlaceholder) {
+			ruleTarget.InputTransformer = &types.InputTransformer{
+				InputPathsMap: map[string]string{"time": "$.time"},
+				InputTemplate: aws.String(input),
+			}
+		} else {
+			ruleTarget.Input = aws.String(input)
+		}
+		targets = append(targets, ruleTarget)
+	}
+	return targets, nil
+}
+
+// removeStaleTargets drops targets left on the rule from an earlier version
+// of the schedule, since PutTargets only adds and replaces.
+func (s *EventBridgeScheduler) removeStaleTargets(ctx context.Context, rule string, current []types.Target) error {
+	keep := make(map[string]bool, len(current))
+	for _, target := range current {
+		keep[aws.ToString(target.Id)] = true
+	}
+
+	existing, err := s.client.ListTargetsByRule(ctx, &eventbridge.ListTargetsByRuleInput{Rule: aws.String(rule)})
+	if err != nil {
+		return fmt.Errorf("failed to list targets for rule %s: %w", rule, err)
+	}
+	var stale []string
+	for _, target := range existing.Targets {
+		if id := aws.ToString(target.Id); !keep[id] {
+			stale = append(stale, id)
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	if _, err := s.client.RemoveTargets(ctx, &eventbridge.RemoveTargetsInput{
+		Rule: aws.String(rule),
+		Ids:  stale,
+	}); err != nil {
+		return fmt.Errorf("failed to remove stale targets for rule %s: %w", rule, err)
+	}
+	return nil
+}
+
+func (s *EventBridgeScheduler) Enable(ctx context.Context, name string) error {
+	if _, err := s.client.EnableRule(ctx, &eventbridge.EnableRuleInput{Name: aws.String(name)}); err != nil {
+		return wrapRuleError(err, "failed to enable rule %s", name)
+	}
+	return nil
+}
+
+func (s *EventBridgeScheduler) Disable(ctx context.Context, name string) error {
+	if _, err := s.client.DisableRule(ctx, &eventbridge.DisableRuleInput{Name: aws.String(name)}); err != nil {
+		return wrapRuleError(err, "failed to disable rule %s", name)
+	}
+	return nil
+}
+
+// wrapRuleError maps a missing rule to ErrNotFound and annotates other errors.
+func wrapRuleError(err error, format string, name string) error {
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		return ErrNotFound
+	}
+	return fmt.Errorf(format+": %w", name, err)
+}
+
+func (s *EventBridgeScheduler) ListByOwner(ctx context.Context, ownerID string) ([]Schedule, error) {
+	rules, err := s.findOwnerRules(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	schedules := make([]Schedule, 0, len(rules))
+	for _, rule := range rules {
+		schedules = append(schedules, Schedule{
+			Name:        aws.ToString(rule.rule.Name),
+			OwnerID:     ownerID,
+			Description: aws.ToString(rule.rule.Description),
+			Expression:  aws.ToString(rule.rule.ScheduleExpression),
+			Payload:     rule.payload,
+			Targets:     rule.targets,
+			Disabled:    rule.rule.State == types.RuleStateDisabled,
+			CreatedAt:   time.Now(),
+		})
+	}
+	return schedules, nil
+}
+
+func (s *EventBridgeScheduler) Delete(ctx context.Context, name string) error {
+	targets, err := s.client.ListTargetsByRule(ctx, &eventbridge.ListTargetsByRuleInput{
+		Rule: aws.String(name),
+	})
+	if err != nil {
+		return wrapRuleError(err, "failed to list targets for rule %s", name)
+	}
+
+	targetIDs := make([]string, 0, len(targets.Targets))
+	for _, target := range targets.Targets {
+		targetIDs = append(targetIDs, aws.ToString(target.Id))
+	}
+	return s.deleteRule(ctx, name, targetIDs)
+}
+
+func (s *EventBridgeScheduler) DeleteByOwner(ctx context.Context, ownerID string) error {
+	rules, err := s.findOwnerRules(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		targetIDs := make([]string, 0, len(rule.targets))
+		for _, target := range rule.targets {
+			targetIDs = append(targetIDs, target.ID)
+		}
+		if err := s.deleteRule(ctx, aws.ToString(rule.rule.Name), targetIDs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteRule removes the rule's targets, which EventBridge requires before the rule itself can go.
+func (s *EventBridgeScheduler) deleteRule(ctx context.Context, name string, targetIDs []string) error {
+	if len(targetIDs) > 0 {
+		if _, err := s.client.RemoveTargets(ctx, &eventbridge.RemoveTargetsInput{
+			Rule: aws.String(name),
+			Ids:  targetIDs,
+		}); err != nil {
+			return fmt.Errorf("failed to remove targets for rule %s: %w", name, err)
+		}
+	}
+	if _, err := s.client.DeleteRule(ctx, &eventbridge.DeleteRuleInput{
+		Name: aws.String(name),
+	}); err != nil {
+		return fmt.Errorf("failed to delete rule %s: %w", name, err)
+	}
+	return nil
+}
+
+type ownedRule struct {
+	rule    types.Rule
+	payload json.RawMessage
+	targets []ScheduleTarget
+}
+
+// findOwnerRules returns the rules named within the owner's namespace that
+// also carry the owner tag. ListRules is followed through every page, and the
+// per-rule tag and target lookups run at most lookupConcurrency at a time.
+func (s *EventBridgeScheduler) findOwnerRules(ctx context.Context, ownerID string) ([]ownedRule, error) {
+	var rules []types.Rule
+	input := &eventbridge.ListRulesInput{NamePrefix: aws.String(OwnerNamespace(ownerID))}
+	for {
+		page, err := s.client.ListRules(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list rules: %w", err)
+		}
+		rules = append(rules, page.Rules...)
+		if aws.ToString(page.NextToken) == "" {
+			break
+		}
+		input.NextToken = page.NextToken
+	}
+
+	ownerTag := OwnerTag(ownerID)
+	matches := make([]*ownedRule, len(rules))
+	forEachConcurrently(len(rules), func(i int) {
+		matches[i] = s.ownedRule(ctx, rules[i], ownerTag)
+	})
+
+	owned := make([]ownedRule, 0, len(rules))
+	for _, match := range matches {
+		if match != nil {
+			owned = append(owned, *match)
+		}
+	}
+	return owned, nil
+}
+
+// ownedRule loads the rule's targets if it is tagged with ownerTag, and
+// returns nil for rules that belong to someone else or can't be read.
+func (s *EventBridgeScheduler) ownedRule(ctx context.Context, rule types.Rule, ownerTag string) *ownedRule {
+	tags, err := s.client.ListTagsForResource(ctx, &eventbridge.ListTagsForResourceInput{
+		ResourceARN: rule.Arn,
+	})
+	if err != nil || ruleOwnerTag(tags.Tags) != ownerTag {
+		return nil
+	}
+
+	match := &ownedRule{rule: rule}
+	input := &eventbridge.ListTargetsByRuleInput{Rule: rule.Name}
+	for {
+		page, err := s.client.ListTargetsByRule(ctx, input)
+		if err != nil {
+			return nil // Skip this rule if we can't get its targets
+		}
+		for _, target := range page.Targets {
+			if match.payload == nil && target.Input != nil {
+				match.payload = payloadFromInput([]byte(*target.Input))
+			}
+			match.targets = append(match.targets, ScheduleTarget{
+				ID:      aws.ToString(target.Id),
+				Arn:     aws.ToString(target.Arn),
+				RoleArn: aws.ToString(target.RoleArn),
+			})
+		}
+		if aws.ToString(page.NextToken) == "" {
+			break
+		}
+		input.NextToken = page.NextToken
+	}
+	return match
+}
+
+// ruleOwnerTag returns the value of the rule's owner tag, if any.
+func ruleOwnerTag(tags []types.Tag) string {
+	for _, tag := range tags {
+		if aws.ToString(tag.Key) == TagKeyOwner {
+			return aws.ToString(tag.Value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,305 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsscheduler "github.com/aws/aws-sdk-go-v2/service/scheduler"
+	"github.com/aws/aws-sdk-go-v2/service/scheduler/types"
+)
+
+const defaultScheduleGroup = "default"
+
+// SchedulerAPIScheduler stores each schedule in EventBridge Scheduler, which
+// unlike EventBridge rules supports one-time at(...) schedules, IANA time
+// zones, start/end dates and flexible time windows. Schedules cannot be
+// tagged, so ownership comes from the OwnerNamespace prefix of their names.
+type SchedulerAPIScheduler struct {
+	client    SchedulerAPI
+	targetArn string
+	roleArn   string
+	group     string
+}
+
+// SchedulerAPI is the part of the EventBridge Scheduler client the scheduler
+// uses, so tests can substitute a fake.
+type SchedulerAPI interface {
+	awsscheduler.ListSchedulesAPIClient
+	CreateSchedule(ctx context.Context, params *awsscheduler.CreateScheduleInput, optFns ...func(*awsscheduler.Options)) (*awsscheduler.CreateScheduleOutput, error)
+	UpdateSchedule(ctx context.Context, params *awsscheduler.UpdateScheduleInput, optFns ...func(*awsscheduler.Options)) (*awsscheduler.UpdateScheduleOutput, error)
+	GetSchedule(ctx context.Context, params *awsscheduler.GetScheduleInput, optFns ...func(*awsscheduler.Options)) (*awsscheduler.GetScheduleOutput, error)
+	DeleteSchedule(ctx context.Context, params *awsscheduler.DeleteScheduleInput, optFns ...func(*awsscheduler.Options)) (*awsscheduler.DeleteScheduleOutput, error)
+}
+
+// NewSchedulerAPIScheduler manages schedules through client. Schedules that
+// name no target invoke targetArn, and targets without their own role are
+// invoked by assuming roleArn. Every schedule lives in the given group.
+func NewSchedulerAPIScheduler(client SchedulerAPI, targetArn, roleArn, group string) (*SchedulerAPIScheduler, error) {
+	if roleArn == "" {
+		return nil, errors.New("EventBridge Scheduler needs a role ARN to invoke targets")
+	}
+	if group == "" {
+		group = defaultScheduleGroup
+	}
+	return &SchedulerAPIScheduler{
+		client:    client,
+		targetArn: targetArn,
+		roleArn:   roleArn,
+		group:     group,
+	}, nil
+}
+
+// Create replaces an existing schedule of the same name, matching PutRule.
+func (s *SchedulerAPIScheduler) Create(ctx context.Context, schedule *Schedule) error {
+	input, err := s.scheduleInput(schedule)
+	if err != nil {
+		return err
+	}
+
+	// CreateScheduleInput and UpdateScheduleInput have identical fields
+	_, err = s.client.CreateSchedule(ctx, (*awsscheduler.CreateScheduleInput)(input))
+	var conflict *types.ConflictException
+	if errors.As(err, &conflict) {
+		_, err = s.client.UpdateSchedule(ctx, input)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create schedule: %w", err)
+	}
+	schedule.CreatedAt = time.Now()
+	return nil
+}
+
+func (s *SchedulerAPIScheduler) Update(ctx context.Context, schedule *Schedule) error {
+	input, err := s.scheduleInput(schedule)
+	if err != nil {
+		return err
+	}
+	if _, err := s.client.UpdateSchedule(ctx, input); err != nil {
+		return wrapScheduleError(err, "failed to update schedule %s", schedule.Name)
+	}
+	return nil
+}
+
+// Check builds the schedule definition without sending it.
+func (s *SchedulerAPIScheduler) Check(schedule *Schedule) error {
+	_, err := s.scheduleInput(schedule)
+	return err
+}
+
+// scheduleInput builds the full schedule definition; UpdateSchedule replaces
+// every field, so Create and Update share it.
+func (s *SchedulerAPIScheduler) scheduleInput(schedule *Schedule) (*awsscheduler.UpdateScheduleInput, error) {
+	if err := schedule.Validate(time.Now()); err != nil {
+		return nil, err
+	}
+	expr, err := schedule.compile()
+	if err != nil {
+		return nil, err
+	}
+
+	// A schedule has exactly one target, which cannot be an API destination
+	targets := schedule.targetsOr(s.targetArn)
+	if len(targets) > 1 {
+		return nil, fmt.Errorf("%w: EventBridge Scheduler delivers each schedule to one target", ErrUnsupported)
+	}
+	target := targets[0]
+	if target.Kind == TargetKindAPIDestination {
+		return nil, fmt.Errorf("%w: EventBridge Scheduler cannot deliver to API destinations", ErrUnsupported)
+	}
+	input, err := target.renderInput(envelopeFor(schedule))
+	if err != nil {
+		return nil, err
+	}
+	input = strings.ReplaceAll(input, TimePlaceholder, "<aws.scheduler.scheduled-time>")
+	roleArn := target.RoleArn
+	if roleArn == "" {
+		roleArn = s.roleArn
+	}
+
+	window := &types.FlexibleTimeWindow{Mode: types.FlexibleTimeWindowModeOff}
+	if schedule.FlexibleWindow > 0 {
+		window = &types.FlexibleTimeWindow{
+			Mode:                   types.FlexibleTimeWindowModeFlexible,
+			MaximumWindowInMinutes: aws.Int32(int32(schedule.FlexibleWindow / time.Minute)),
+		}
+	}
+
+	timeZone := schedule.TimeZone
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
+
+	state := types.ScheduleStateEnabled
+	if schedule.Disabled {
+		state = types.ScheduleStateDisabled
+	}
+
+	// The API expects the inner expression, so unwrap windowedSchedule
+	if windowed, ok := expr.(*windowedSchedule); ok {
+		expr = windowed.Expression
+	}
+
+	return &awsscheduler.UpdateScheduleInput{
+		Name:                       aws.String(schedule.Name),
+		GroupName:                  aws.String(s.group),
+		Description:                aws.String(schedule.Description),
+		ScheduleExpression:         aws.String(expr.String()),
+		ScheduleExpressionTimezone: aws.String(timeZone),
+		StartDate:                  schedule.StartAt,
+		EndDate:                    schedule.EndAt,
+		FlexibleTimeWindow:         window,
+		State:                      state,
+		Target: &types.Target{
+			Arn:     aws.String(target.Arn),
+			RoleArn: aws.String(roleArn),
+			Input:   aws.String(input),
+		},
+	}, nil
+}
+
+func (s *SchedulerAPIScheduler) Enable(ctx context.Context, name string) error {
+	return s.setState(ctx, name, types.ScheduleStateEnabled)
+}
+
+func (s *SchedulerAPIScheduler) Disable(ctx context.Context, name string) error {
+	return s.setState(ctx, name, types.ScheduleStateDisabled)
+}
+
+// setState rewrites the schedule with a new state, since EventBridge
+// Scheduler has no separate enable or disable call.
+func (s *SchedulerAPIScheduler) setState(ctx context.Context, name string, state types.ScheduleState) error {
+	current, err := s.client.GetSchedule(ctx, &awsscheduler.GetScheduleInput{
+		Name:      aws.String(name),
+		GroupName: aws.String(s.group),
+	})
+	if err != nil {
+		return wrapScheduleError(err, "failed to get schedule %s", name)
+	}
+
+	if _, err := s.client.UpdateSchedule(ctx, &awsscheduler.UpdateScheduleInput{
+		Name:                       current.Name,
+		GroupName:                  current.GroupName,
+		Description:                current.Description,
+		ScheduleExpression:         current.ScheduleExpression,
+		ScheduleExpressionTimezone: current.ScheduleExpressionTimezone,
+		StartDate:                  current.StartDate,
+		EndDate:                    current.EndDate,
+		FlexibleTimeWindow:         current.FlexibleTimeWindow,
+		ActionAfterCompletion:      current.ActionAfterCompletion,
+		KmsKeyArn:                  current.KmsKeyArn,
+		Target:                     current.Target,
+		State:                      state,
+	}); err != nil {
+		return wrapScheduleError(err, "failed to update schedule %s", name)
+	}
+	return nil
+}
+
+// wrapScheduleError maps a missing schedule to ErrNotFound and annotates other errors.
+func wrapScheduleError(err error, format string, name string) error {
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		return ErrNotFound
+	}
+	return fmt.Errorf(format+": %w", name, err)
+}
+
+func (s *SchedulerAPIScheduler) ListByOwner(ctx context.Context, ownerID string) ([]Schedule, error) {
+	owned, err := s.findOwnerSchedules(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	schedules := make([]Schedule, 0, len(owned))
+	for _, current := range owned {
+		schedule := Schedule{
+			Name:        aws.ToString(current.Name),
+			OwnerID:     ownerID,
+			Description: aws.ToString(current.Description),
+			Expression:  aws.ToString(current.ScheduleExpression),
+			TimeZone:    aws.ToString(current.ScheduleExpressionTimezone),
+			StartAt:     current.StartDate,
+			EndAt:       current.EndDate,
+			Disabled:    current.State == types.ScheduleStateDisabled,
+			CreatedAt:   aws.ToTime(current.CreationDate),
+		}
+		if window := current.FlexibleTimeWindow; window != nil && window.Mode == types.FlexibleTimeWindowModeFlexible {
+			schedule.FlexibleWindow = time.Duration(aws.ToInt32(window.MaximumWindowInMinutes)) * time.Minute
+		}
+		if current.Target != nil {
+			schedule.Targets = []ScheduleTarget{{
+				Arn:     aws.ToString(current.Target.Arn),
+				RoleArn: aws.ToString(current.Target.RoleArn),
+			}}
+			if current.Target.Input != nil {
+				schedule.Payload = payloadFromInput([]byte(*current.Target.Input))
+			}
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, nil
+}
+
+func (s *SchedulerAPIScheduler) Delete(ctx context.Context, name string) error {
+	if _, err := s.client.DeleteSchedule(ctx, &awsscheduler.DeleteScheduleInput{
+		Name:      aws.String(name),
+		GroupName: aws.String(s.group),
+	}); err != nil {
+		return wrapScheduleError(err, "failed to delete schedule %s", name)
+	}
+	return nil
+}
+
+func (s *SchedulerAPIScheduler) DeleteByOwner(ctx context.Context, ownerID string) error {
+	owned, err := s.findOwnerSchedules(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+	for _, current := range owned {
+		if err := s.Delete(ctx, aws.ToString(current.Name)); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// findOwnerSchedules returns the schedules in the group named within the
+// owner's namespace. ListSchedules omits the target, so each schedule is
+// fetched in full, at most lookupConcurrency at a time.
+func (s *SchedulerAPIScheduler) findOwnerSchedules(ctx context.Context, ownerID string) ([]*awsscheduler.GetScheduleOutput, error) {
+	var summaries []types.ScheduleSummary
+	pages := awsscheduler.NewListSchedulesPaginator(s.client, &awsscheduler.ListSchedulesInput{
+		GroupName:  aws.String(s.group),
+		NamePrefix: aws.String(OwnerNamespace(ownerID)),
+	})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list schedules: %w", err)
+		}
+		summaries = append(summaries, page.Schedules...)
+	}
+
+	fetched := make([]*awsscheduler.GetScheduleOutput, len(summaries))
+	forEachConcurrently(len(summaries), func(i int) {
+		current, err := s.client.GetSchedule(ctx, &awsscheduler.GetScheduleInput{
+			Name:      summaries[i].Name,
+			GroupName: aws.String(s.group),
+		})
+		if err == nil { // Skip schedules deleted or unreadable since listing
+			fetched[i] = current
+		}
+	})
+
+	owned := make([]*awsscheduler.GetScheduleOutput, 0, len(fetched))
+	for _, current := range fetched {
+		if current != nil {
+			owned = append(owned, current)
+		}
+	}
+	return owned, nil
+}
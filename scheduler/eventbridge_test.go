@@ -0,0 +1,163 @@
+package scheduler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/dat1010/go-api/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeEventBridge answers EventBridge API calls from canned JSON bodies keyed
+// by operation, recording each operation it sees.
+type fakeEventBridge struct {
+	mu        sync.Mutex
+	calls     []string
+	responses map[string][]string
+}
+
+func (f *fakeEventBridge) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	_, _ = io.Copy(io.Discard, r.Body)
+	operation := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "AWSEvents.")
+
+	f.mu.Lock()
+	f.calls = append(f.calls, operation)
+	body := "{}"
+	if queued := f.responses[operation]; len(queued) > 0 {
+		body = queued[0]
+		f.responses[operation] = queued[1:]
+	}
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	if strings.Contains(body, `"__type"`) {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	_, _ = io.WriteString(w, body)
+}
+
+func newFakeEventBridgeScheduler(t *testing.T, fake *fakeEventBridge) *EventBridgeScheduler {
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	client := eventbridge.New(eventbridge.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		Credentials:  aws.AnonymousCredentials{},
+		HTTPClient:   server.Client(),
+		// The real retry rules, without the real waits
+		Retryer: retry.AddWithMaxBackoffDelay(config.NewAWSRetryer(), time.Millisecond),
+	})
+	return NewEventBridgeScheduler(client, "arn:aws:lambda:default")
+}
+
+func TestEventBridgeCreate_RollsBackRuleWhenTargetsFail(t *testing.T) {
+	fake := &fakeEventBridge{responses: map[string][]string{
+		"PutTargets": {`{"FailedEntryCount":1,"FailedEntries":[{"TargetId":"rule-target","ErrorCode":"AccessDenied","ErrorMessage":"denied"}]}`},
+	}}
+	s := newFakeEventBridgeScheduler(t, fake)
+
+	err := s.Create(context.Background(), &Schedule{Name: "rule", OwnerID: "auth0|1", Expression: "rate(1 hour)"})
+
+	assert.ErrorContains(t, err, "denied")
+	assert.Equal(t, []string{"PutRule", "PutTargets", "RemoveTargets", "DeleteRule"}, fake.calls)
+}
+
+func TestEventBridgeUpdate_KeepsRuleWhenTargetsFail(t *testing.T) {
+	fake := &fakeEventBridge{responses: map[string][]string{
+		"PutTargets": {`{"__type":"ValidationException","message":"bad target"}`},
+	}}
+	s := newFakeEventBridgeScheduler(t, fake)
+
+	err := s.Update(context.Background(), &Schedule{Name: "rule", OwnerID: "auth0|1", Expression: "rate(1 hour)"})
+
+	assert.Error(t, err)
+	assert.NotContains(t, fake.calls, "DeleteRule")
+}
+
+func TestEventBridgeCheck_RejectsWithoutCallingAWS(t *testing.T) {
+	fake := &fakeEventBridge{}
+	s := newFakeEventBridgeScheduler(t, fake)
+
+	assert.NoError(t, s.Check(&Schedule{Name: "rule", OwnerID: "auth0|1", Expression: "rate(1 hour)"}))
+	err := s.Check(&Schedule{Name: "rule", OwnerID: "auth0|1", Expression: "rate(1 hour)", TimeZone: "America/Chicago"})
+
+	assert.ErrorIs(t, err, ErrUnsupported)
+	assert.Empty(t, fake.calls)
+}
+
+func TestEventBridgeCreate_RetriesThrottling(t *testing.T) {
+	fake := &fakeEventBridge{responses: map[string][]string{
+		"PutRule": {`{"__type":"ThrottlingException","message":"Rate exceeded"}`, `{"__type":"ThrottlingException","message":"Rate exceeded"}`},
+	}}
+	s := newFakeEventBridgeScheduler(t, fake)
+
+	err := s.Create(context.Background(), &Schedule{Name: "rule", OwnerID: "auth0|1", Expression: "rate(1 hour)"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"PutRule", "PutRule", "PutRule", "PutTargets", "ListTargetsByRule"}, fake.calls)
+}
+
+// memoryEventBridge keeps rules in memory, implementing just enough of
+// EventBridgeAPI to list an owner's rules.
+type memoryEventBridge struct {
+	EventBridgeAPI
+	rules   []types.Rule
+	tags    map[string][]types.Tag
+	targets map[string][]types.Target
+}
+
+func (m *memoryEventBridge) ListRules(ctx context.Context, params *eventbridge.ListRulesInput, optFns ...func(*eventbridge.Options)) (*eventbridge.ListRulesOutput, error) {
+	var rules []types.Rule
+	for _, rule := range m.rules {
+		if strings.HasPrefix(aws.ToString(rule.Name), aws.ToString(params.NamePrefix)) {
+			rules = append(rules, rule)
+		}
+	}
+	return &eventbridge.ListRulesOutput{Rules: rules}, nil
+}
+
+func (m *memoryEventBridge) ListTagsForResource(ctx context.Context, params *eventbridge.ListTagsForResourceInput, optFns ...func(*eventbridge.Options)) (*eventbridge.ListTagsForResourceOutput, error) {
+	return &eventbridge.ListTagsForResourceOutput{Tags: m.tags[aws.ToString(params.ResourceARN)]}, nil
+}
+
+func (m *memoryEventBridge) ListTargetsByRule(ctx context.Context, params *eventbridge.ListTargetsByRuleInput, optFns ...func(*eventbridge.Options)) (*eventbridge.ListTargetsByRuleOutput, error) {
+	return &eventbridge.ListTargetsByRuleOutput{Targets: m.targets[aws.ToString(params.Rule)]}, nil
+}
+
+func TestEventBridgeListByOwner_OnlyTaggedRulesInNamespace(t *testing.T) {
+	owned := RuleName("auth0|1", "a")
+	forged := RuleName("auth0|1", "b")
+	fake := &memoryEventBridge{
+		rules: []types.Rule{
+			{Name: aws.String(owned), Arn: aws.String("arn:owned"), ScheduleExpression: aws.String("rate(1 hour)")},
+			{Name: aws.String(forged), Arn: aws.String("arn:forged"), ScheduleExpression: aws.String("rate(1 hour)")},
+			{Name: aws.String(RuleName("auth0|2", "c")), Arn: aws.String("arn:other")},
+		},
+		tags: map[string][]types.Tag{
+			"arn:owned":  {{Key: aws.String(TagKeyOwner), Value: aws.String(OwnerTag("auth0|1"))}},
+			"arn:forged": {{Key: aws.String(TagKeyOwner), Value: aws.String(OwnerTag("auth0|2"))}},
+		},
+		targets: map[string][]types.Target{
+			owned: {{Id: aws.String("t"), Arn: aws.String("arn:aws:lambda:x"), Input: aws.String(`{"user_id":"auth0|1","rule_name":"r","payload":{"k":1}}`)}},
+		},
+	}
+
+	schedules, err := NewEventBridgeScheduler(fake, "arn:aws:lambda:default").ListByOwner(context.Background(), "auth0|1")
+
+	assert.NoError(t, err)
+	if assert.Len(t, schedules, 1) {
+		assert.Equal(t, owned, schedules[0].Name)
+		assert.JSONEq(t, `{"k":1}`, string(schedules[0].Payload))
+		assert.Equal(t, "arn:aws:lambda:x", schedules[0].Targets[0].Arn)
+	}
+}
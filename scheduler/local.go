@@ -0,0 +1,367 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const localBatchSize = 10
+
+// Target is invoked by the local scheduler each time a schedule fires.
+type Target interface {
+	Invoke(ctx context.Context, schedule Schedule, payload []byte) error
+}
+
+// FuncTarget invokes an in-process function.
+type FuncTarget func(ctx context.Context, schedule Schedule, payload []byte) error
+
+func (f FuncTarget) Invoke(ctx context.Context, schedule Schedule, payload []byte) error {
+	return f(ctx, schedule, payload)
+}
+
+// HTTPTarget POSTs the JSON payload to a URL, standing in for the Lambda target.
+type HTTPTarget struct {
+	URL    string
+	Client *http.Client
+}
+
+func (t HTTPTarget) Invoke(ctx context.Context, schedule Schedule, payload []byte) error {
+	client := t.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Schedule-Name", schedule.Name)
+	if len(schedule.Targets) == 1 {
+		req.Header.Set("X-Schedule-Target", schedule.Targets[0].ID)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("target returned %s", resp.Status)
+	}
+	return nil
+}
+
+// LocalScheduler keeps schedules in Postgres and fires them from the API
+// process, so events can be developed and tested without AWS.
+type LocalScheduler struct {
+	db     *sqlx.DB
+	target Target
+}
+
+func NewLocalScheduler(db *sqlx.DB, target Target) *LocalScheduler {
+	return &LocalScheduler{db: db, target: target}
+}
+
+type localScheduleRow struct {
+	Name        string     `db:"name"`
+	OwnerID     string     `db:"owner_id"`
+	Description string     `db:"description"`
+	Expression  string     `db:"expression"`
+	TimeZone    string     `db:"timezone"`
+	StartAt     *time.Time `db:"start_at"`
+	EndAt       *time.Time `db:"end_at"`
+	Payload     []byte     `db:"payload"`
+	Targets     []byte     `db:"targets"`
+	Disabled    bool       `db:"disabled"`
+	CreatedAt   time.Time  `db:"created_at"`
+}
+
+const localScheduleColumns = `name, owner_id, description, expression, timezone, start_at, end_at, payload, targets, created_at`
+
+func (r localScheduleRow) schedule() Schedule {
+	schedule := Schedule{
+		Name:        r.Name,
+		OwnerID:     r.OwnerID,
+		Description: r.Description,
+		Expression:  r.Expression,
+		TimeZone:    r.TimeZone,
+		StartAt:     r.StartAt,
+		EndAt:       r.EndAt,
+		Disabled:    r.Disabled,
+		CreatedAt:   r.CreatedAt,
+	}
+	schedule.Payload = payloadFromInput(r.Payload)
+	_ = json.Unmarshal(r.Targets, &schedule.Targets)
+	return schedule
+}
+
+// Check reports whether the schedule has a next run and valid targets.
+func (s *LocalScheduler) Check(schedule *Schedule) error {
+	if _, err := nextRun(schedule, time.Now()); err != nil {
+		return err
+	}
+	return schedule.validateTargets()
+}
+
+// Create stores the schedule. Flexible windows are ignored: local schedules
+// always fire at the start of the window. Every target, whatever its kind, is
+// delivered through the scheduler's local Target.
+func (s *LocalScheduler) Create(ctx context.Context, schedule *Schedule) error {
+	next, err := nextRun(schedule, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := schedule.validateTargets(); err != nil {
+		return err
+	}
+	if schedule.Disabled {
+		next = nil
+	}
+
+	payloadJSON, err := json.Marshal(envelopeFor(schedule))
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	targets := schedule.Targets
+	if targets == nil {
+		targets = []ScheduleTarget{}
+	}
+	targetsJSON, err := json.Marshal(targets)
+	if err != nil {
+		return fmt.Errorf("failed to marshal targets: %w", err)
+	}
+
+	// Like PutRule, creating a schedule with an existing name replaces it
+	return s.db.QueryRowxContext(ctx, `
+		INSERT INTO local_schedules (name, owner_id, description, expression, timezone, start_at, end_at, payload, targets, next_run_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (name) DO UPDATE SET
+			owner_id = EXCLUDED.owner_id,
+			description = EXCLUDED.description,
+			expression = EXCLUDED.expression,
+			timezone = EXCLUDED.timezone,
+			start_at = EXCLUDED.start_at,
+			end_at = EXCLUDED.end_at,
+			payload = EXCLUDED.payload,
+			targets = EXCLUDED.targets,
+			next_run_at = EXCLUDED.next_run_at
+		RETURNING created_at
+	`, schedule.Name, schedule.OwnerID, schedule.Description, schedule.Expression, schedule.TimeZone,
+		schedule.StartAt, schedule.EndAt, string(payloadJSON), string(targetsJSON), next,
+	).Scan(&schedule.CreatedAt)
+}
+
+func (s *LocalScheduler) Update(ctx context.Context, schedule *Schedule) error {
+	return s.Create(ctx, schedule)
+}
+
+// Enable resumes a schedule from its next fire time after now.
+func (s *LocalScheduler) Enable(ctx context.Context, name string) error {
+	var row localScheduleRow
+	if err := s.db.GetContext(ctx, &row, `SELECT `+localScheduleColumns+` FROM local_schedules WHERE name = $1`, name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
+	}
+	schedule := row.schedule()
+	next, err := nextRun(&schedule, time.Now())
+	if err != nil {
+		return err
+	}
+	return s.setNextRun(ctx, name, next)
+}
+
+func (s *LocalScheduler) Disable(ctx context.Context, name string) error {
+	return s.setNextRun(ctx, name, nil)
+}
+
+func (s *LocalScheduler) setNextRun(ctx context.Context, name string, next *time.Time) error {
+	result, err := s.db.ExecContext(ctx, `UPDATE local_schedules SET next_run_at = $2 WHERE name = $1`, name, next)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// nextRun returns the first fire time of the schedule after now.
+func nextRun(schedule *Schedule, now time.Time) (*time.Time, error) {
+	expr, err := schedule.compile()
+	if err != nil {
+		return nil, err
+	}
+	next, ok := expr.Next(now)
+	if !ok {
+		return nil, fmt.Errorf("%w: expression never fires", ErrInvalidExpression)
+	}
+	return &next, nil
+}
+
+func (s *LocalScheduler) ListByOwner(ctx context.Context, ownerID string) ([]Schedule, error) {
+	var rows []localScheduleRow
+	err := s.db.SelectContext(ctx, &rows, `
+		SELECT `+localScheduleColumns+`, next_run_at IS NULL AS disabled
+		FROM local_schedules
+		WHERE owner_id = $1
+		ORDER BY created_at DESC
+	`, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	schedules := make([]Schedule, 0, len(rows))
+	for _, row := range rows {
+		schedules = append(schedules, row.schedule())
+	}
+	return schedules, nil
+}
+
+func (s *LocalScheduler) Delete(ctx context.Context, name string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM local_schedules WHERE name = $1`, name)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *LocalScheduler) DeleteByOwner(ctx context.Context, ownerID string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM local_schedules WHERE owner_id = $1`, ownerID)
+	return err
+}
+
+// Run fires due schedules every interval until ctx is done. Rows are claimed
+// with SKIP LOCKED so several API instances can run side by side.
+func (s *LocalScheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.runDue(ctx); err != nil {
+				log.Printf("local scheduler: %v", err)
+			}
+		}
+	}
+}
+
+// runDue claims the due schedules and moves them on to their next run in a
+// short transaction, then invokes them. A schedule is never invoked twice
+// for one run, even when recording its outcome fails; a crash between the
+// commit and the invocation skips that run instead.
+func (s *LocalScheduler) runDue(ctx context.Context) error {
+	// Postgres keeps microseconds; match what it stores for last_run_at
+	now := time.Now().Truncate(time.Microsecond)
+	due, err := s.claimDue(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range due {
+		schedule := row.schedule()
+
+		lastError := ""
+		if invokeErr := s.invoke(ctx, schedule, row.Payload, now); invokeErr != nil {
+			lastError = invokeErr.Error()
+			log.Printf("local scheduler: schedule %s failed: %v", schedule.Name, invokeErr)
+		}
+
+		// Leave the outcome of any later run alone
+		if _, err := s.db.ExecContext(ctx, `
+			UPDATE local_schedules
+			SET last_error = $3
+			WHERE name = $1 AND last_run_at = $2
+		`, row.Name, now, lastError); err != nil {
+			log.Printf("local scheduler: recording outcome of %s: %v", schedule.Name, err)
+		}
+	}
+	return nil
+}
+
+// claimDue locks a batch of due schedules, records that they ran at now and
+// advances them to their next run.
+func (s *LocalScheduler) claimDue(ctx context.Context, now time.Time) (due []localScheduleRow, err error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = tx.SelectContext(ctx, &due, `
+		SELECT `+localScheduleColumns+`
+		FROM local_schedules
+		WHERE next_run_at IS NOT NULL AND next_run_at <= NOW()
+		ORDER BY next_run_at
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`, localBatchSize); err != nil {
+		return nil, err
+	}
+
+	for _, row := range due {
+		schedule := row.schedule()
+		// One-time and ended schedules have no next run and stop here
+		next, _ := nextRun(&schedule, now)
+
+		if _, err = tx.ExecContext(ctx, `
+			UPDATE local_schedules
+			SET last_run_at = $2, next_run_at = $3
+			WHERE name = $1
+		`, row.Name, now, next); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return due, nil
+}
+
+// invoke delivers the schedule to each of its targets in turn, passing the
+// target alone in schedule.Targets. Schedules without targets get the stored
+// envelope, as the default Lambda target would.
+func (s *LocalScheduler) invoke(ctx context.Context, schedule Schedule, payload []byte, firedAt time.Time) error {
+	if len(schedule.Targets) == 0 {
+		return s.target.Invoke(ctx, schedule, payload)
+	}
+
+	var failed []error
+	for _, target := range schedule.Targets {
+		input, err := target.renderInput(envelopeFor(&schedule))
+		if err == nil {
+			input = strings.ReplaceAll(input, TimePlaceholder, firedAt.UTC().Format(time.RFC3339))
+			single := schedule
+			single.Targets = []ScheduleTarget{target}
+			err = s.target.Invoke(ctx, single, []byte(input))
+		}
+		if err != nil {
+			failed = append(failed, fmt.Errorf("target %s: %w", target.ID, err))
+		}
+	}
+	return errors.Join(failed...)
+}
@@ -0,0 +1,35 @@
+package scheduler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// AppName prefixes every rule name and is the value of the app tag, so the
+// API's rules are easy to tell apart from anything else in the account.
+const AppName = "go-api"
+
+// Tag keys applied to every EventBridge rule.
+const (
+	TagKeyApp   = "app"
+	TagKeyOwner = "owner"
+)
+
+// OwnerTag identifies the owner in AWS without exposing their Auth0 ID, which
+// may also contain characters tag values do not allow.
+func OwnerTag(ownerID string) string {
+	sum := sha256.Sum256([]byte(ownerID))
+	return hex.EncodeToString(sum[:])
+}
+
+// OwnerNamespace is the rule name prefix shared by all of an owner's rules.
+func OwnerNamespace(ownerID string) string {
+	return AppName + "-" + OwnerTag(ownerID)[:16] + "-"
+}
+
+// RuleName derives a collision-free rule name from the owner and the event's
+// internal ID. It fits the 64 character limit of rules and schedules.
+func RuleName(ownerID, id string) string {
+	return OwnerNamespace(ownerID) + strings.ReplaceAll(id, "-", "")
+}
@@ -0,0 +1,198 @@
+// Package scheduler runs user-defined scheduled events on a pluggable backend:
+// AWS EventBridge rules or EventBridge Scheduler in production, or an
+// in-process runner backed by Postgres.
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Backends selectable with SCHEDULER_BACKEND.
+const (
+	BackendEventBridge          = "eventbridge"
+	BackendEventBridgeScheduler = "eventbridge-scheduler"
+	BackendLocal                = "local"
+)
+
+// MaxFlexibleWindow is the longest flexible time window EventBridge Scheduler accepts.
+const MaxFlexibleWindow = 24 * time.Hour
+
+// lookupConcurrency bounds the per-schedule AWS calls in flight while listing
+// an owner's schedules, keeping large accounts clear of API throttling.
+const lookupConcurrency = 8
+
+// ErrNotFound is returned when a schedule does not exist.
+var ErrNotFound = errors.New("schedule not found")
+
+// ErrUnsupported is returned when a backend cannot represent a schedule, such
+// as a one-time or time-zone-aware schedule on EventBridge rules.
+var ErrUnsupported = errors.New("schedule not supported by the configured backend")
+
+// Schedule is a named, recurring event owned by a user.
+type Schedule struct {
+	Name        string
+	OwnerID     string
+	Description string
+	// Expression is a cron(...) or rate(...) expression, or a bare six-field
+	// cron expression. See ParseExpression.
+	Expression string
+	// Payload is a JSON object delivered inside an Envelope; nil sends {}.
+	Payload json.RawMessage
+	// Targets receive the payload when the schedule fires; when empty the
+	// backend's default Lambda target does.
+	Targets []ScheduleTarget
+	// TimeZone is the IANA zone the expression is evaluated in; empty means UTC.
+	TimeZone string
+	// StartAt and EndAt optionally bound when the schedule may fire.
+	StartAt *time.Time
+	EndAt   *time.Time
+	// FlexibleWindow lets the backend fire up to this long after the
+	// scheduled time to spread load; zero fires on time.
+	FlexibleWindow time.Duration
+	// Disabled schedules are kept but do not fire.
+	Disabled  bool
+	CreatedAt time.Time
+}
+
+// Scheduler creates and manages scheduled events.
+type Scheduler interface {
+	// Check reports whether the backend can run the schedule, returning the
+	// error Create would without changing anything.
+	Check(schedule *Schedule) error
+	// Create puts the schedule, replacing one of the same name.
+	Create(ctx context.Context, schedule *Schedule) error
+	// Update replaces the schedule, including whether it is disabled. It
+	// never removes the schedule on failure, and may return ErrNotFound when
+	// there is no schedule to update.
+	Update(ctx context.Context, schedule *Schedule) error
+	ListByOwner(ctx context.Context, ownerID string) ([]Schedule, error)
+	Enable(ctx context.Context, name string) error
+	Disable(ctx context.Context, name string) error
+	Delete(ctx context.Context, name string) error
+	DeleteByOwner(ctx context.Context, ownerID string) error
+}
+
+// ValidateExpression reports whether the expression can be scheduled. Invalid
+// expressions return an *ExpressionError listing each bad field.
+func ValidateExpression(expression string) error {
+	_, err := ParseExpression(expression)
+	return err
+}
+
+// Validate checks the expression, time zone, window and flexible window
+// together, and that the schedule fires at least once more. Problems are
+// returned as one *ExpressionError. Once the timing is valid, the targets are
+// checked, returning errors matching ErrInvalidTarget.
+func (s *Schedule) Validate(now time.Time) error {
+	expr, err := s.compile()
+	invalid := &ExpressionError{Expression: s.Expression}
+	if err != nil {
+		if !errors.As(err, &invalid) {
+			return err
+		}
+	} else if _, ok := expr.Next(now); !ok {
+		invalid.add("schedule", s.Expression, "never fires after now")
+	}
+	if s.FlexibleWindow < 0 || s.FlexibleWindow > MaxFlexibleWindow {
+		invalid.add("flexible_window_minutes", fmt.Sprint(int(s.FlexibleWindow/time.Minute)),
+			fmt.Sprintf("must be between 0 and %d", int(MaxFlexibleWindow/time.Minute)))
+	}
+	if len(invalid.Fields) > 0 {
+		return invalid
+	}
+	return s.validateTargets()
+}
+
+// compile parses the schedule's expression with its time zone and window.
+func (s *Schedule) compile() (Expression, error) {
+	return Compile(s.Expression, s.TimeZone, s.StartAt, s.EndAt)
+}
+
+// needsSchedulerAPI reports whether the schedule uses anything beyond the UTC
+// recurring expressions EventBridge rules support.
+func (s *Schedule) needsSchedulerAPI() bool {
+	return IsOneTime(s.Expression) ||
+		(s.TimeZone != "" && s.TimeZone != "UTC") ||
+		s.StartAt != nil || s.EndAt != nil ||
+		s.FlexibleWindow > 0
+}
+
+// PayloadKeyRuleName is the envelope field naming the schedule, so targets
+// can name it when they report a run.
+const PayloadKeyRuleName = "rule_name"
+
+// Config selects and configures a scheduler backend.
+type Config struct {
+	// Backend is BackendEventBridge (the default), BackendEventBridgeScheduler or BackendLocal.
+	Backend string
+	// TargetArn is the Lambda invoked by schedules that name no targets.
+	TargetArn string
+	// RoleArn is the IAM role EventBridge Scheduler assumes to invoke the target.
+	RoleArn string
+	// GroupName is the EventBridge Scheduler schedule group; empty means "default".
+	GroupName string
+	// TargetURL receives local schedule payloads by HTTP POST; when empty they are only logged.
+	TargetURL string
+}
+
+// Clients are the AWS clients the backends use, shared with the rest of the
+// API. Only the configured backend's client is needed.
+type Clients struct {
+	EventBridge EventBridgeAPI
+	Scheduler   SchedulerAPI
+}
+
+// New builds the configured backend. The local backend needs db and must be
+// started with (*LocalScheduler).Run.
+func New(cfg Config, clients Clients, db *sqlx.DB) (Scheduler, error) {
+	switch cfg.Backend {
+	case "", BackendEventBridge:
+		if clients.EventBridge == nil {
+			return nil, errors.New("the EventBridge backend needs an EventBridge client")
+		}
+		return NewEventBridgeScheduler(clients.EventBridge, cfg.TargetArn), nil
+	case BackendEventBridgeScheduler:
+		if clients.Scheduler == nil {
+			return nil, errors.New("the EventBridge Scheduler backend needs a Scheduler client")
+		}
+		return NewSchedulerAPIScheduler(clients.Scheduler, cfg.TargetArn, cfg.RoleArn, cfg.GroupName)
+	case BackendLocal:
+		var target Target = FuncTarget(logInvocation)
+		if cfg.TargetURL != "" {
+			target = HTTPTarget{URL: cfg.TargetURL}
+		}
+		return NewLocalScheduler(db, target), nil
+	default:
+		return nil, fmt.Errorf("unknown scheduler backend %q", cfg.Backend)
+	}
+}
+
+func logInvocation(ctx context.Context, schedule Schedule, payload []byte) error {
+	log.Printf("local scheduler: fired %s for %s: %s", schedule.Name, schedule.OwnerID, payload)
+	return nil
+}
+
+// forEachConcurrently calls fn for every index below n, running at most
+// lookupConcurrency calls at once, and returns when all have finished.
+func forEachConcurrently(n int, fn func(i int)) {
+	slots := make(chan struct{}, lookupConcurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
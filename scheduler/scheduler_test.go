@@ -0,0 +1,141 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompile_TimeZone(t *testing.T) {
+	expr, err := Compile("cron(0 9 * * ? *)", "America/Chicago", nil, nil)
+	assert.NoError(t, err)
+
+	// 9am in Chicago is 14:00 UTC during daylight saving time and 15:00 UTC outside it
+	summer, _ := expr.Next(time.Date(2024, time.July, 1, 0, 0, 0, 0, time.UTC))
+	winter, _ := expr.Next(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2024, time.July, 1, 14, 0, 0, 0, time.UTC), summer)
+	assert.Equal(t, time.Date(2024, time.January, 1, 15, 0, 0, 0, time.UTC), winter)
+}
+
+func TestCompile_OneTime(t *testing.T) {
+	expr, err := Compile("at(2024-03-20T09:00:00)", "America/Chicago", nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "at(2024-03-20T09:00:00)", expr.String())
+
+	runs := NextRuns(expr, time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), 5, time.UTC)
+	assert.Equal(t, []time.Time{time.Date(2024, time.March, 20, 14, 0, 0, 0, time.UTC)}, runs)
+}
+
+func TestCompile_Window(t *testing.T) {
+	start := time.Date(2024, time.March, 22, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, time.March, 24, 0, 0, 0, 0, time.UTC)
+	expr, err := Compile("cron(0 12 * * ? *)", "", &start, &end)
+	assert.NoError(t, err)
+
+	runs := NextRuns(expr, time.Date(2024, time.March, 20, 0, 0, 0, 0, time.UTC), 5, time.UTC)
+	assert.Equal(t, []time.Time{
+		time.Date(2024, time.March, 22, 12, 0, 0, 0, time.UTC),
+		time.Date(2024, time.March, 23, 12, 0, 0, 0, time.UTC),
+	}, runs)
+}
+
+func TestCompile_ReportsTimingFields(t *testing.T) {
+	start := time.Date(2024, time.March, 22, 0, 0, 0, 0, time.UTC)
+	end := start.Add(-time.Hour)
+
+	_, err := Compile("cron(61 12 * * ? *)", "Mars/Olympus", &start, &end)
+
+	var exprErr *ExpressionError
+	if assert.True(t, errors.As(err, &exprErr)) {
+		var fields []string
+		for _, field := range exprErr.Fields {
+			fields = append(fields, field.Field)
+		}
+		assert.Equal(t, []string{"minutes", "timezone", "end_at"}, fields)
+	}
+}
+
+func TestScheduleValidate(t *testing.T) {
+	now := time.Date(2024, time.March, 20, 0, 0, 0, 0, time.UTC)
+
+	past := &Schedule{Expression: "at(2024-01-01T00:00:00)"}
+	assert.ErrorIs(t, past.Validate(now), ErrInvalidExpression)
+
+	tooFlexible := &Schedule{Expression: "rate(1 hour)", FlexibleWindow: 25 * time.Hour}
+	assert.ErrorIs(t, tooFlexible.Validate(now), ErrInvalidExpression)
+
+	valid := &Schedule{Expression: "at(2024-04-01T09:00:00)", TimeZone: "Europe/Paris", FlexibleWindow: 15 * time.Minute}
+	assert.NoError(t, valid.Validate(now))
+}
+
+func TestEventBridgeRules_RejectSchedulerAPIFeatures(t *testing.T) {
+	rules := &EventBridgeScheduler{}
+
+	for _, schedule := range []*Schedule{
+		{Name: "once", Expression: "at(2030-01-01T09:00:00)"},
+		{Name: "chicago", Expression: "cron(0 9 * * ? *)", TimeZone: "America/Chicago"},
+		{Name: "flexible", Expression: "rate(1 hour)", FlexibleWindow: time.Minute},
+	} {
+		err := rules.Create(context.Background(), schedule)
+		assert.ErrorIs(t, err, ErrUnsupported, schedule.Name)
+	}
+}
+
+func TestRuleName_NamespacedPerOwner(t *testing.T) {
+	id := "3f2b8c1e-7a4d-4e5f-9b6a-0c1d2e3f4a5b"
+
+	alice := RuleName("auth0|alice", id)
+	bob := RuleName("auth0|bob", id)
+
+	assert.NotEqual(t, alice, bob)
+	assert.True(t, len(alice) <= 64)
+	assert.Regexp(t, `^[A-Za-z0-9_.-]+$`, alice)
+	assert.Equal(t, OwnerNamespace("auth0|alice")+"3f2b8c1e7a4d4e5f9b6a0c1d2e3f4a5b", alice)
+}
+
+func TestForEachConcurrently_BoundsInFlightCalls(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, peak := 0, 0
+	seen := make([]bool, 50)
+
+	forEachConcurrently(len(seen), func(i int) {
+		mu.Lock()
+		inFlight++
+		if inFlight > peak {
+			peak = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+		seen[i] = true
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	})
+
+	assert.LessOrEqual(t, peak, lookupConcurrency)
+	for i, ok := range seen {
+		assert.True(t, ok, "index %d not visited", i)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	expected := &Schedule{Name: "r", OwnerID: "o", Expression: "0 12 * * ? *", Description: "d"}
+
+	assert.Empty(t, Diff(expected, &Schedule{Name: "r", Expression: "cron(0 12 * * ? *)", Description: "d"}))
+	assert.Empty(t, Diff(expected, &Schedule{Name: "r", Expression: "cron(0 12 * * ? *)", Description: "d", TimeZone: "UTC",
+		Payload: json.RawMessage(`{}`)}))
+
+	assert.Equal(t, []string{DriftFieldExpression, DriftFieldDescription, DriftFieldState, DriftFieldPayload},
+		Diff(expected, &Schedule{Name: "r", Expression: "rate(1 hour)", Disabled: true, Payload: json.RawMessage(`{"user_id":"x","rule_name":"r"}`)}))
+
+	expected.Targets = []ScheduleTarget{{ID: "q", Arn: "arn:aws:sqs:q"}}
+	assert.Equal(t, []string{DriftFieldTargets},
+		Diff(expected, &Schedule{Name: "r", Expression: "cron(0 12 * * ? *)", Description: "d", Targets: []ScheduleTarget{{Arn: "arn:aws:sqs:other"}}}))
+}
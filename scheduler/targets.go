@@ -0,0 +1,188 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Target kinds an administrator can register. API destinations deliver to an
+// HTTP webhook through an EventBridge connection.
+const (
+	TargetKindLambda         = "lambda"
+	TargetKindSQS            = "sqs"
+	TargetKindSNS            = "sns"
+	TargetKindAPIDestination = "api_destination"
+)
+
+// TargetKinds lists every kind a target may have.
+var TargetKinds = []string{TargetKindLambda, TargetKindSQS, TargetKindSNS, TargetKindAPIDestination}
+
+// MaxTargets is the most targets EventBridge allows on one rule.
+const MaxTargets = 5
+
+// TimePlaceholder in an input template is replaced with the fire time.
+const TimePlaceholder = "<time>"
+
+// ErrInvalidTarget is returned for schedules whose targets cannot be delivered to.
+var ErrInvalidTarget = errors.New("invalid schedule target")
+
+// ScheduleTarget is one destination a schedule delivers its payload to.
+type ScheduleTarget struct {
+	// ID is unique within the schedule.
+	ID      string `json:"id"`
+	Kind    string `json:"kind"`
+	Arn     string `json:"arn"`
+	RoleArn string `json:"role_arn,omitempty"`
+	// InputTemplate reshapes the envelope for this target. It is JSON in which
+	// <user_id>, <rule_name> and <payload.path> placeholders take values from
+	// the envelope; paths that don't start with an envelope field are read
+	// from the payload, so <order.id> means <payload.order.id>. A placeholder
+	// making up a whole JSON string, like "<payload.items>", becomes the value
+	// itself, while one inside a longer string inserts the value as text.
+	// <time> is the fire time. Empty sends the envelope as is.
+	InputTemplate string `json:"input_template,omitempty"`
+}
+
+// placeholderPattern matches a quoted placeholder standing for a whole JSON
+// value before trying a bare one within a string.
+var placeholderPattern = regexp.MustCompile(`"<([A-Za-z0-9_.-]+)>"|<([A-Za-z0-9_.-]+)>`)
+
+// renderInput fills the target's template from the envelope, leaving
+// TimePlaceholder for the backend to substitute when the schedule fires.
+func (t ScheduleTarget) renderInput(envelope Envelope) (string, error) {
+	if t.InputTemplate == "" {
+		data, err := json.Marshal(envelope)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal payload: %w", err)
+		}
+		return string(data), nil
+	}
+
+	var payload interface{}
+	if err := decodeJSON(orEmptyObject(envelope.Payload), &payload); err != nil {
+		return "", fmt.Errorf("failed to decode payload: %w", err)
+	}
+	fields := map[string]interface{}{
+		"user_id":          envelope.UserID,
+		PayloadKeyRuleName: envelope.RuleName,
+		"payload":          payload,
+	}
+
+	var missing []string
+	rendered := placeholderPattern.ReplaceAllStringFunc(t.InputTemplate, func(match string) string {
+		whole := strings.HasPrefix(match, `"`)
+		path := strings.Trim(match, `"<>`)
+		if "<"+path+">" == TimePlaceholder {
+			return match
+		}
+		value, ok := lookupPath(fields, path)
+		if !ok {
+			missing = append(missing, path)
+			return match
+		}
+		encoded, _ := json.Marshal(value)
+		if whole {
+			return string(encoded)
+		}
+		// Inside a string, insert the value escaped but unquoted
+		text, isString := value.(string)
+		if !isString {
+			text = string(encoded)
+		}
+		quoted, _ := json.Marshal(text)
+		return string(quoted[1 : len(quoted)-1])
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("%w: target %s: input template refers to missing payload fields %s",
+			ErrInvalidTarget, t.ID, strings.Join(missing, ", "))
+	}
+	if !json.Valid([]byte(strings.ReplaceAll(rendered, TimePlaceholder, time.Time{}.Format(time.RFC3339)))) {
+		return "", fmt.Errorf("%w: target %s: input template is not valid JSON", ErrInvalidTarget, t.ID)
+	}
+	return rendered, nil
+}
+
+// lookupPath follows a dotted path through the envelope fields, indexing
+// arrays by number. Paths not starting with an envelope field are read from
+// the payload.
+func lookupPath(fields map[string]interface{}, path string) (interface{}, bool) {
+	segments := strings.Split(path, ".")
+	if _, ok := fields[segments[0]]; !ok {
+		segments = append([]string{"payload"}, segments...)
+	}
+
+	var current interface{} = fields
+	for _, segment := range segments {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			next, ok := node[segment]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case []interface{}:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			current = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// validateTargets checks the targets, or the default target when there are
+// none, can all be delivered the schedule's payload within MaxInputBytes.
+func (s *Schedule) validateTargets() error {
+	if len(s.Targets) > MaxTargets {
+		return fmt.Errorf("%w: at most %d targets per schedule", ErrInvalidTarget, MaxTargets)
+	}
+	seen := make(map[string]bool, len(s.Targets))
+	envelope := envelopeFor(s)
+	for _, target := range s.targetsOr("") {
+		if seen[target.ID] {
+			return fmt.Errorf("%w: target %s is listed twice", ErrInvalidTarget, target.ID)
+		}
+		seen[target.ID] = true
+		if !IsTargetKind(target.Kind) {
+			return fmt.Errorf("%w: target %s has unknown kind %q", ErrInvalidTarget, target.ID, target.Kind)
+		}
+		if target.Kind == TargetKindAPIDestination && target.RoleArn == "" {
+			return fmt.Errorf("%w: API destination %s needs a role ARN", ErrInvalidTarget, target.ID)
+		}
+		input, err := target.renderInput(envelope)
+		if err != nil {
+			return err
+		}
+		if err := checkInputSize(target.ID, input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// targetsOr returns the schedule's targets, or a single Lambda target at
+// defaultArn for schedules that name none.
+func (s *Schedule) targetsOr(defaultArn string) []ScheduleTarget {
+	if len(s.Targets) > 0 {
+		return s.Targets
+	}
+	return []ScheduleTarget{{ID: s.Name + "-target", Kind: TargetKindLambda, Arn: defaultArn}}
+}
+
+// IsTargetKind reports whether kind is one of TargetKinds.
+func IsTargetKind(kind string) bool {
+	for _, known := range TargetKinds {
+		if kind == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderInput_Template(t *testing.T) {
+	target := ScheduleTarget{ID: "orders", InputTemplate: `{"msg":"hi <name>","owner":"<user_id>","at":"<time>"}`}
+
+	input, err := target.renderInput(Envelope{UserID: "auth0|1", Payload: json.RawMessage(`{"name":"Bob \"B\"","user_id":"spoofed"}`)})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{"msg":"hi Bob \"B\"","owner":"auth0|1","at":"<time>"}`, input)
+}
+
+func TestRenderInput_TypedValues(t *testing.T) {
+	target := ScheduleTarget{ID: "orders", InputTemplate: `{"items":"<payload.order.items>","first":"<order.items.0.qty>","note":"qty <order.items.0.qty> of <order.items>"}`}
+
+	input, err := target.renderInput(Envelope{Payload: json.RawMessage(`{"order":{"items":[{"qty":12345678901234567890}]}}`)})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{"items":[{"qty":12345678901234567890}],"first":12345678901234567890,"note":"qty 12345678901234567890 of [{\"qty\":12345678901234567890}]"}`, input)
+}
+
+func TestRenderInput_Errors(t *testing.T) {
+	_, err := ScheduleTarget{ID: "a", InputTemplate: `{"id":"<order_id>"}`}.renderInput(Envelope{})
+	assert.ErrorIs(t, err, ErrInvalidTarget)
+	assert.Contains(t, err.Error(), "order_id")
+
+	_, err = ScheduleTarget{ID: "a", InputTemplate: `{"id": <user_id>}`}.renderInput(Envelope{UserID: "x"})
+	assert.ErrorIs(t, err, ErrInvalidTarget)
+}
+
+func TestValidate_InputTooLarge(t *testing.T) {
+	payload, _ := json.Marshal(map[string]string{"blob": strings.Repeat("x", MaxInputBytes)})
+	schedule := Schedule{Name: "daily", Expression: "cron(0 12 * * ? *)", Payload: payload}
+
+	assert.ErrorIs(t, schedule.Validate(time.Now()), ErrInputTooLarge)
+
+	// A template that drops the large field fits
+	schedule.Targets = []ScheduleTarget{{ID: "q", Kind: TargetKindSQS, Arn: "arn:aws:sqs:q", InputTemplate: `{"owner":"<user_id>"}`}}
+	assert.NoError(t, schedule.Validate(time.Now()))
+}
+
+func TestPayloadFromInput(t *testing.T) {
+	assert.JSONEq(t, `{"k":[1,2]}`, string(payloadFromInput([]byte(`{"user_id":"o","rule_name":"r","payload":{"k":[1,2]}}`))))
+	// Inputs written before envelopes come back whole
+	assert.JSONEq(t, `{"user_id":"o","k":"v"}`, string(payloadFromInput([]byte(`{"user_id":"o","k":"v"}`))))
+}
+
+func TestValidate_Targets(t *testing.T) {
+	schedule := Schedule{Name: "daily", Expression: "cron(0 12 * * ? *)"}
+	for i := 0; i <= MaxTargets; i++ {
+		schedule.Targets = append(schedule.Targets, ScheduleTarget{ID: string(rune('a' + i)), Kind: TargetKindLambda, Arn: "arn:aws:lambda:x"})
+	}
+	assert.ErrorIs(t, schedule.Validate(time.Now()), ErrInvalidTarget)
+
+	schedule.Targets = []ScheduleTarget{{ID: "a", Kind: TargetKindSQS}, {ID: "a", Kind: TargetKindSNS}}
+	assert.ErrorIs(t, schedule.Validate(time.Now()), ErrInvalidTarget)
+
+	schedule.Targets = []ScheduleTarget{{ID: "hook", Kind: TargetKindAPIDestination}}
+	assert.ErrorIs(t, schedule.Validate(time.Now()), ErrInvalidTarget)
+}
+
+func TestRuleTargets_DefaultAndTransformer(t *testing.T) {
+	s := &EventBridgeScheduler{targetArn: "arn:aws:lambda:default"}
+
+	targets, err := s.ruleTargets(&Schedule{Name: "rule", OwnerID: "auth0|1"})
+	assert.NoError(t, err)
+	if assert.Len(t, targets, 1) {
+		assert.Equal(t, "rule-target", aws.ToString(targets[0].Id))
+		assert.Equal(t, "arn:aws:lambda:default", aws.ToString(targets[0].Arn))
+		assert.JSONEq(t, `{"user_id":"auth0|1","rule_name":"rule","payload":{}}`, aws.ToString(targets[0].Input))
+	}
+
+	targets, err = s.ruleTargets(&Schedule{Name: "rule", OwnerID: "auth0|1", Targets: []ScheduleTarget{
+		{ID: "queue", Kind: TargetKindSQS, Arn: "arn:aws:sqs:q"},
+		{ID: "hook", Kind: TargetKindAPIDestination, Arn: "arn:aws:events:d", RoleArn: "arn:aws:iam::1:role/r", InputTemplate: `{"at":"<time>"}`},
+	}})
+	assert.NoError(t, err)
+	if assert.Len(t, targets, 2) {
+		assert.Nil(t, targets[0].InputTransformer)
+		assert.Nil(t, targets[1].Input)
+		assert.Equal(t, map[string]string{"time": "$.time"}, targets[1].InputTransformer.InputPathsMap)
+		assert.Equal(t, "arn:aws:iam::1:role/r", aws.ToString(targets[1].RoleArn))
+	}
+}
+
+func TestSchedulerAPIInput_SingleTarget(t *testing.T) {
+	s := &SchedulerAPIScheduler{targetArn: "arn:aws:lambda:default", roleArn: "arn:aws:iam::1:role/default", group: "default"}
+	schedule := &Schedule{Name: "rule", OwnerID: "auth0|1", Expression: "cron(0 12 * * ? *)", Targets: []ScheduleTarget{
+		{ID: "queue", Kind: TargetKindSQS, Arn: "arn:aws:sqs:q", InputTemplate: `{"at":"<time>"}`},
+	}}
+
+	input, err := s.scheduleInput(schedule)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"at":"<aws.scheduler.scheduled-time>"}`, aws.ToString(input.Target.Input))
+	assert.Equal(t, "arn:aws:iam::1:role/default", aws.ToString(input.Target.RoleArn))
+
+	schedule.Targets = append(schedule.Targets, ScheduleTarget{ID: "topic", Kind: TargetKindSNS, Arn: "arn:aws:sns:t"})
+	_, err = s.scheduleInput(schedule)
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
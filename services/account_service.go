@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/repositories"
+)
+
+var ErrNoPendingDeletion = errors.New("no account deletion is pending")
+
+const (
+	DefaultDeletionGracePeriod = 30 * 24 * time.Hour
+	deletionBatchSize          = 20
+)
+
+// UserEventsRemover deletes every scheduled event a user owns.
+type UserEventsRemover func(ctx context.Context, auth0UserID string) error
+
+type AccountService interface {
+	ExportUserData(auth0UserID string) (*models.UserDataExport, error)
+	ScheduleDeletion(auth0UserID string) (*models.AccountDeletion, error)
+	GetPendingDeletion(auth0UserID string) (*models.AccountDeletion, error)
+	CancelDeletion(auth0UserID string) error
+	ProcessDueDeletions(ctx context.Context) (int, error)
+	RunDeletionWorker(ctx context.Context, interval time.Duration)
+}
+
+type accountService struct {
+	repo         repositories.AccountRepository
+	userService  UserService
+	removeEvents UserEventsRemover
+	gracePeriod  time.Duration
+	postsMode    string
+}
+
+// NewAccountService creates the account service. Erased accounts have their
+// posts anonymized unless postsMode is models.DeletedPostsDelete.
+func NewAccountService(repo repositories.AccountRepository, userService UserService, removeEvents UserEventsRemover, gracePeriod time.Duration, postsMode string) AccountService {
+	if gracePeriod <= 0 {
+		gracePeriod = DefaultDeletionGracePeriod
+	}
+	if postsMode != models.DeletedPostsDelete {
+		postsMode = models.DeletedPostsAnonymize
+	}
+	return &accountService{
+		repo:         repo,
+		userService:  userService,
+		removeEvents: removeEvents,
+		gracePeriod:  gracePeriod,
+		postsMode:    postsMode,
+	}
+}
+
+func (s *accountService) ExportUserData(auth0UserID string) (*models.UserDataExport, error) {
+	profile, err := s.repo.GetProfile(auth0UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	role, err := s.userService.GetUserRole(auth0UserID)
+	if err != nil {
+		return nil, err
+	}
+	posts, err := s.repo.ListPostsByAuthor(auth0UserID)
+	if err != nil {
+		return nil, err
+	}
+	if posts == nil {
+		posts = []models.Post{}
+	}
+
+	return &models.UserDataExport{
+		Profile: profile,
+		Role:    role,
+		Posts:   posts,
+	}, nil
+}
+
+func (s *accountService) ScheduleDeletion(auth0UserID string) (*models.AccountDeletion, error) {
+	return s.repo.ScheduleDeletion(auth0UserID, time.Now().Add(s.gracePeriod))
+}
+
+func (s *accountService) GetPendingDeletion(auth0UserID string) (*models.AccountDeletion, error) {
+	deletion, err := s.repo.GetPendingDeletion(auth0UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoPendingDeletion
+		}
+		return nil, err
+	}
+	return deletion, nil
+}
+
+func (s *accountService) CancelDeletion(auth0UserID string) error {
+	if err := s.repo.CancelDeletion(auth0UserID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoPendingDeletion
+		}
+		return err
+	}
+	return nil
+}
+
+// ProcessDueDeletions erases accounts whose grace period has ended. Scheduled
+// events are removed first so a failure leaves the account to retry later.
+func (s *accountService) ProcessDueDeletions(ctx context.Context) (int, error) {
+	due, err := s.repo.ListDueDeletions(deletionBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	erased := 0
+	for _, deletion := range due {
+		if s.removeEvents != nil {
+			if err := s.removeEvents(ctx, deletion.Auth0UserID); err != nil {
+				log.Printf("failed to remove events for %s: %v", deletion.Auth0UserID, err)
+				continue
+			}
+		}
+		deleted := webhookEventMessage(models.WebhookEventUserDeleted, webhookUserData{Auth0UserID: deletion.Auth0UserID})
+		if err := s.repo.EraseUser(deletion.Auth0UserID, s.postsMode, deleted); err != nil {
+			log.Printf("failed to erase account %s: %v", deletion.Auth0UserID, err)
+			continue
+		}
+		erased++
+	}
+	return erased, nil
+}
+
+// RunDeletionWorker processes due deletions every interval until ctx is done.
+func (s *accountService) RunDeletionWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			erased, err := s.ProcessDueDeletions(ctx)
+			if err != nil {
+				log.Printf("account deletion worker: %v", err)
+				continue
+			}
+			if erased > 0 {
+				log.Printf("account deletion worker: erased %d account(s)", erased)
+			}
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/repositories"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 200
+	maxAuditExportRows   = 10000
+)
+
+type AuditService interface {
+	Record(entry *models.AuditLog) error
+	List(filter models.AuditLogFilter, page, pageSize int) (*models.AuditLogPage, error)
+	Export(filter models.AuditLogFilter) ([]models.AuditLog, error)
+}
+
+type auditService struct {
+	repo repositories.AuditRepository
+}
+
+func NewAuditService(repo repositories.AuditRepository) AuditService {
+	return &auditService{repo: repo}
+}
+
+func (s *auditService) Record(entry *models.AuditLog) error {
+	return s.repo.Create(entry)
+}
+
+func (s *auditService) List(filter models.AuditLogFilter, page, pageSize int) (*models.AuditLogPage, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultAuditPageSize
+	}
+	if pageSize > maxAuditPageSize {
+		pageSize = maxAuditPageSize
+	}
+
+	total, err := s.repo.Count(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.Limit = pageSize
+	filter.Offset = (page - 1) * pageSize
+	items, err := s.repo.List(filter)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.AuditLog{}
+	}
+
+	return &models.AuditLogPage{
+		Items:    items,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+func (s *auditService) Export(filter models.AuditLogFilter) ([]models.AuditLog, error) {
+	filter.Limit = maxAuditExportRows
+	filter.Offset = 0
+	return s.repo.List(filter)
+}
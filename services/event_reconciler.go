@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/repositories"
+	"github.com/dat1010/go-api/scheduler"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidReconcileRequest = errors.New("invalid reconcile request")
+
+// EventReconciler compares stored events with the rules the scheduler backend
+// actually holds and optionally repairs the differences.
+type EventReconciler interface {
+	Reconcile(ctx context.Context, req models.ReconcileRequest) (*models.DriftReport, error)
+	RunReconciler(ctx context.Context, interval time.Duration)
+}
+
+type eventReconciler struct {
+	events  *eventService
+	enqueue JobEnqueuer
+}
+
+// NewEventReconciler reconciles the events in repo against the backend,
+// resolving targets the same way the event service does. Missing and
+// mismatched rules are repaired by schedule sync jobs added with enqueue.
+func NewEventReconciler(repo repositories.EventRepository, backend scheduler.Scheduler, resolve EventTargetResolver, enqueue JobEnqueuer) EventReconciler {
+	return &eventReconciler{events: &eventService{repo: repo, scheduler: backend, resolve: resolve}, enqueue: enqueue}
+}
+
+// Reconcile checks every owner that has stored events. Rules are only found
+// through their owner's namespace, so orphans of owners without any stored
+// event are not seen.
+func (r *eventReconciler) Reconcile(ctx context.Context, req models.ReconcileRequest) (*models.DriftReport, error) {
+	switch req.Orphans {
+	case models.OrphanReport, models.OrphanDisable, models.OrphanAdopt:
+	default:
+		return nil, fmt.Errorf("%w: orphans must be %q, %q or empty", ErrInvalidReconcileRequest, models.OrphanDisable, models.OrphanAdopt)
+	}
+
+	events, err := r.events.repo.ListAll()
+	if err != nil {
+		return nil, err
+	}
+	byOwner := make(map[string][]models.Event)
+	var owners []string
+	for _, event := range events {
+		if _, seen := byOwner[event.OwnerID]; !seen {
+			owners = append(owners, event.OwnerID)
+		}
+		byOwner[event.OwnerID] = append(byOwner[event.OwnerID], event)
+	}
+
+	report := &models.DriftReport{
+		CheckedAt: time.Now(),
+		Owners:    len(owners),
+		Events:    len(events),
+		Drift:     []models.EventDrift{},
+	}
+	for _, ownerID := range owners {
+		if err := r.reconcileOwner(ctx, ownerID, byOwner[ownerID], req, report); err != nil {
+			log.Printf("event reconciler: owner %s: %v", ownerID, err)
+			report.Failures = append(report.Failures, ownerID)
+		}
+	}
+	return report, nil
+}
+
+func (r *eventReconciler) reconcileOwner(ctx context.Context, ownerID string, events []models.Event, req models.ReconcileRequest, report *models.DriftReport) error {
+	actual, err := r.events.scheduler.ListByOwner(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+	report.Rules += len(actual)
+
+	byName := make(map[string]*scheduler.Schedule, len(actual))
+	for i := range actual {
+		byName[actual[i].Name] = &actual[i]
+	}
+
+	for i := range events {
+		event := &events[i]
+		current, found := byName[event.RuleName]
+		delete(byName, event.RuleName)
+
+		expected, err := r.events.scheduleFor(event)
+		if err != nil {
+			report.Drift = append(report.Drift, models.EventDrift{
+				Kind:        models.DriftMismatch,
+				OwnerID:     ownerID,
+				EventName:   event.Name,
+				RuleName:    event.RuleName,
+				Fields:      []string{scheduler.DriftFieldTargets},
+				RepairError: err.Error(),
+			})
+			continue
+		}
+
+		drift := models.EventDrift{OwnerID: ownerID, EventName: event.Name, RuleName: event.RuleName}
+		if !found {
+			drift.Kind = models.DriftMissing
+		} else if fields := scheduler.Diff(expected, current); len(fields) > 0 {
+			drift.Kind = models.DriftMismatch
+			drift.Fields = fields
+		} else {
+			continue
+		}
+		// The events may have changed or gone since they were listed, so the
+		// repair is left to a sync job that reads the event as it is then
+		if req.Recreate {
+			drift.Repair, drift.RepairError = repairResult(models.RepairSyncQueued, r.enqueue(scheduleSyncMessage(event.RuleName)))
+		}
+		report.Drift = append(report.Drift, drift)
+	}
+
+	// Whatever is left in the owner's namespace has no stored event
+	for _, orphan := range actual {
+		if _, left := byName[orphan.Name]; !left {
+			continue
+		}
+		if req.Orphans != models.OrphanReport {
+			// An event created since the listing owns the rule after all
+			if _, err := r.events.repo.GetByRuleName(orphan.Name); err == nil {
+				continue
+			} else if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+		}
+		drift := models.EventDrift{Kind: models.DriftOrphan, OwnerID: ownerID, RuleName: orphan.Name}
+		switch req.Orphans {
+		case models.OrphanDisable:
+			if !orphan.Disabled {
+				drift.Repair, drift.RepairError = repairResult(models.RepairDisabled, r.events.scheduler.Disable(ctx, orphan.Name))
+			}
+		case models.OrphanAdopt:
+			drift.EventName = orphan.Name
+			drift.Repair, drift.RepairError = repairResult(models.RepairAdopted, r.adopt(ownerID, orphan))
+		}
+		report.Drift = append(report.Drift, drift)
+	}
+	return nil
+}
+
+// adopt stores an orphaned rule as an event named after the rule, keeping the
+// rule as it is.
+func (r *eventReconciler) adopt(ownerID string, orphan scheduler.Schedule) error {
+	state := models.EventStateEnabled
+	if orphan.Disabled {
+		state = models.EventStateDisabled
+	}
+	event := &models.Event{
+		ID:          uuid.New().String(),
+		OwnerID:     ownerID,
+		Name:        orphan.Name,
+		RuleName:    orphan.Name,
+		Description: orphan.Description,
+		Schedule:    orphan.Expression,
+		EventTiming: normalizeTiming(models.EventTiming{
+			TimeZone:              orphan.TimeZone,
+			StartAt:               orphan.StartAt,
+			EndAt:                 orphan.EndAt,
+			FlexibleWindowMinutes: int(orphan.FlexibleWindow / time.Minute),
+		}),
+		Payload: models.JSONObject(orphan.Payload),
+		Targets: models.EventTargetBindings{},
+		State:   state,
+	}
+	return r.events.repo.Create(event, repositories.NoActiveEventLimit)
+}
+
+// repairResult reports the repair as done, or its error.
+func repairResult(repair string, err error) (string, string) {
+	if err != nil {
+		return "", err.Error()
+	}
+	return repair, ""
+}
+
+// RunReconciler reports drift every interval until ctx is done. It queues
+// sync jobs for missing and mismatched rules, as a backstop for sync jobs
+// that were dead-lettered, but leaves orphans alone.
+func (r *eventReconciler) RunReconciler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := r.Reconcile(ctx, models.ReconcileRequest{Recreate: true})
+			if err != nil {
+				log.Printf("event reconciler: %v", err)
+				continue
+			}
+			for _, drift := range report.Drift {
+				log.Printf("event reconciler: %s rule %s (event %q) %v repair=%q error=%q",
+					drift.Kind, drift.RuleName, drift.EventName, drift.Fields, drift.Repair, drift.RepairError)
+			}
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/scheduler"
+	"github.com/stretchr/testify/assert"
+)
+
+// listingScheduler returns fixed schedules per owner and records repairs.
+type listingScheduler struct {
+	fakeScheduler
+	schedules map[string][]scheduler.Schedule
+	updated   []string
+}
+
+func (s *listingScheduler) ListByOwner(ctx context.Context, ownerID string) ([]scheduler.Schedule, error) {
+	return s.schedules[ownerID], nil
+}
+
+func (s *listingScheduler) Update(ctx context.Context, schedule *scheduler.Schedule) error {
+	s.updated = append(s.updated, schedule.Name)
+	return nil
+}
+
+func reconcileFixture() (*fakeEventRepository, *listingScheduler) {
+	repo := &fakeEventRepository{created: []*models.Event{
+		{ID: "1", OwnerID: "auth0|owner", Name: "daily", RuleName: "rule-daily", Schedule: "0 12 * * ? *", State: models.EventStateEnabled},
+		{ID: "2", OwnerID: "auth0|owner", Name: "hourly", RuleName: "rule-hourly", Schedule: "rate(1 hour)", State: models.EventStateEnabled},
+		{ID: "3", OwnerID: "auth0|owner", Name: "gone", RuleName: "rule-gone", Schedule: "rate(5 minutes)", State: models.EventStateEnabled},
+	}}
+	sched := &listingScheduler{schedules: map[string][]scheduler.Schedule{
+		"auth0|owner": {
+			{Name: "rule-daily", OwnerID: "auth0|owner", Expression: "cron(0 12 * * ? *)"},
+			{Name: "rule-hourly", OwnerID: "auth0|owner", Expression: "rate(2 hours)", Disabled: true},
+			{Name: "rule-stray", OwnerID: "auth0|owner", Expression: "rate(1 day)", Payload: json.RawMessage(`{"k":"v"}`)},
+		},
+	}}
+	return repo, sched
+}
+
+func TestReconcile_ReportsDriftWithoutRepairing(t *testing.T) {
+	repo, sched := reconcileFixture()
+	reconciler := NewEventReconciler(repo, sched, nil, repo.enqueue)
+
+	report, err := reconciler.Reconcile(context.Background(), models.ReconcileRequest{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Owners)
+	assert.Equal(t, 3, report.Events)
+	assert.Equal(t, 3, report.Rules)
+	assert.Equal(t, []models.EventDrift{
+		{Kind: models.DriftMismatch, OwnerID: "auth0|owner", EventName: "hourly", RuleName: "rule-hourly",
+			Fields: []string{scheduler.DriftFieldExpression, scheduler.DriftFieldState}},
+		{Kind: models.DriftMissing, OwnerID: "auth0|owner", EventName: "gone", RuleName: "rule-gone"},
+		{Kind: models.DriftOrphan, OwnerID: "auth0|owner", RuleName: "rule-stray"},
+	}, report.Drift)
+	assert.Empty(t, sched.created)
+	assert.Empty(t, sched.updated)
+	assert.Empty(t, sched.disabled)
+}
+
+func TestReconcile_Repairs(t *testing.T) {
+	repo, sched := reconcileFixture()
+	reconciler := NewEventReconciler(repo, sched, nil, repo.enqueue)
+
+	report, err := reconciler.Reconcile(context.Background(), models.ReconcileRequest{Recreate: true, Orphans: models.OrphanAdopt})
+
+	assert.NoError(t, err)
+	// Rules are only put by the queued sync jobs, from the events as they are then
+	assert.Empty(t, sched.updated)
+	assert.Empty(t, sched.created)
+	assert.Equal(t, []models.OutboxMessage{scheduleSyncMessage("rule-hourly"), scheduleSyncMessage("rule-gone")}, repo.outbox)
+	if assert.Len(t, report.Drift, 3) {
+		assert.Equal(t, models.RepairSyncQueued, report.Drift[0].Repair)
+		assert.Equal(t, models.RepairSyncQueued, report.Drift[1].Repair)
+		assert.Equal(t, models.RepairAdopted, report.Drift[2].Repair)
+	}
+
+	// The "gone" event is deleted before its job runs, so the job removes its rule instead
+	assert.NoError(t, repo.Delete("3"))
+	service := NewEventService(repo, sched, nil, nil, nil)
+	assert.NoError(t, runScheduleSyncs(t, service, repo))
+	assert.Equal(t, []string{"rule-hourly"}, sched.updated)
+	assert.Equal(t, []string{"rule-gone"}, sched.deleted)
+	assert.Empty(t, sched.created)
+
+	adopted, err := repo.GetByOwnerAndName("auth0|owner", "rule-stray")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "rule-stray", adopted.RuleName)
+		assert.JSONEq(t, `{"k":"v"}`, string(adopted.Payload))
+	}
+}
+
+func TestReconcile_DisablesOrphans(t *testing.T) {
+	repo, sched := reconcileFixture()
+	reconciler := NewEventReconciler(repo, sched, nil, repo.enqueue)
+
+	_, err := reconciler.Reconcile(context.Background(), models.ReconcileRequest{Orphans: models.OrphanDisable})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rule-stray"}, sched.disabled)
+
+	_, err = reconciler.Reconcile(context.Background(), models.ReconcileRequest{Orphans: "delete"})
+	assert.ErrorIs(t, err, ErrInvalidReconcileRequest)
+}
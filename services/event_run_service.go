@@ -0,0 +1,165 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/repositories"
+)
+
+var (
+	ErrRunCallbacksDisabled = errors.New("event run callbacks are not configured")
+	ErrInvalidRunSignature  = errors.New("event run callback signature is invalid or expired")
+	ErrInvalidRunReport     = errors.New("invalid event run report")
+)
+
+const (
+	// RunCallbackTolerance is how far a callback's timestamp may be from now.
+	RunCallbackTolerance = 5 * time.Minute
+
+	maxRunErrorBytes  = 4 << 10
+	maxRunOutputBytes = 16 << 10
+)
+
+type EventRunService interface {
+	VerifyCallback(body []byte, timestamp, signature string) error
+	RecordRun(report *models.EventRunReport) error
+	ListRuns(ownerID, name string, page, pageSize int) (*models.EventRunPage, error)
+}
+
+type eventRunService struct {
+	runs   repositories.EventRunRepository
+	events repositories.EventRepository
+	secret []byte
+}
+
+// NewEventRunService records runs reported by targets. Callbacks must be
+// signed with secret; an empty secret disables them.
+func NewEventRunService(runs repositories.EventRunRepository, events repositories.EventRepository, secret []byte) EventRunService {
+	return &eventRunService{runs: runs, events: events, secret: secret}
+}
+
+// VerifyCallback checks the hex HMAC-SHA256 of "<timestamp>.<body>", where
+// timestamp is Unix seconds within RunCallbackTolerance of now.
+func (s *eventRunService) VerifyCallback(body []byte, timestamp, signature string) error {
+	if len(s.secret) == 0 {
+		return ErrRunCallbacksDisabled
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidRunSignature
+	}
+	if age := time.Since(time.Unix(seconds, 0)); age > RunCallbackTolerance || age < -RunCallbackTolerance {
+		return ErrInvalidRunSignature
+	}
+
+	expected := SignRunCallback(s.secret, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(strings.TrimPrefix(signature, "sha256="))) {
+		return ErrInvalidRunSignature
+	}
+	return nil
+}
+
+// SignRunCallback returns the signature a target sends with a callback body.
+func SignRunCallback(secret []byte, timestamp string, body []byte) string {
+	return signTimestamped(secret, timestamp, body)
+}
+
+// signTimestamped returns the hex HMAC-SHA256 of "<timestamp>.<body>".
+func signTimestamped(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *eventRunService) RecordRun(report *models.EventRunReport) error {
+	if report.RuleName == "" || report.RunID == "" || len(report.RunID) > 128 {
+		return fmt.Errorf("%w: rule_name and run_id (at most 128 characters) are required", ErrInvalidRunReport)
+	}
+
+	event, err := s.events.GetByRuleName(report.RuleName)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrEventNotFound
+		}
+		return err
+	}
+
+	at := time.Now()
+	if report.Time != nil {
+		at = *report.Time
+	}
+
+	switch report.Status {
+	case models.EventRunReportStarted:
+		return s.runs.RecordStart(event.ID, report.RunID, at)
+	case models.EventRunReportSucceeded:
+		return s.runs.RecordFinish(event.ID, report.RunID, models.EventRunStatusSucceeded, at,
+			"", truncateUTF8(report.Output, maxRunOutputBytes))
+	case models.EventRunReportFailed:
+		// The owner is notified once the failure commits
+		return s.runs.RecordFinish(event.ID, report.RunID, models.EventRunStatusFailed, at,
+			truncateUTF8(report.Error, maxRunErrorBytes), truncateUTF8(report.Output, maxRunOutputBytes),
+			eventFailedMessage(event, report))
+	default:
+		return fmt.Errorf("%w: status must be started, succeeded or failed", ErrInvalidRunReport)
+	}
+}
+
+// ListRuns returns ErrEventNotFound unless the owner has an event with that name.
+func (s *eventRunService) ListRuns(ownerID, name string, page, pageSize int) (*models.EventRunPage, error) {
+	event, err := s.events.GetByOwnerAndName(ownerID, name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrEventNotFound
+		}
+		return nil, err
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultEventPageSize
+	}
+	if pageSize > maxEventPageSize {
+		pageSize = maxEventPageSize
+	}
+
+	total, err := s.runs.CountByEvent(event.ID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.runs.ListPageByEvent(event.ID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.EventRun{}
+	}
+
+	return &models.EventRunPage{
+		Items:    items,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a character.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
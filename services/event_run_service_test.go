@@ -0,0 +1,118 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordedRun struct {
+	eventID, runID, status, errorText, output string
+}
+
+type fakeEventRunRepository struct {
+	started  []recordedRun
+	finished []recordedRun
+	outbox   []models.OutboxMessage
+}
+
+func (r *fakeEventRunRepository) RecordStart(eventID, runID string, startedAt time.Time) error {
+	r.started = append(r.started, recordedRun{eventID: eventID, runID: runID, status: models.EventRunStatusRunning})
+	return nil
+}
+
+// RecordFinish ignores a run finishing again with the status it has, like
+// the real repository.
+func (r *fakeEventRunRepository) RecordFinish(eventID, runID, status string, finishedAt time.Time, errorText, output string, outbox ...models.OutboxMessage) error {
+	for _, run := range r.finished {
+		if run.eventID == eventID && run.runID == runID && run.status == status {
+			return nil
+		}
+	}
+	r.finished = append(r.finished, recordedRun{eventID, runID, status, errorText, output})
+	r.outbox = append(r.outbox, outbox...)
+	return nil
+}
+
+func (r *fakeEventRunRepository) ListPageByEvent(eventID string, limit, offset int) ([]models.EventRun, error) {
+	return nil, nil
+}
+
+func (r *fakeEventRunRepository) CountByEvent(eventID string) (int, error) {
+	return 0, nil
+}
+
+func TestVerifyCallback(t *testing.T) {
+	secret := []byte("callback-secret")
+	service := NewEventRunService(&fakeEventRunRepository{}, &fakeEventRepository{}, secret)
+	body := []byte(`{"rule_name":"r","run_id":"1","status":"started"}`)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+
+	assert.NoError(t, service.VerifyCallback(body, now, SignRunCallback(secret, now, body)))
+	assert.NoError(t, service.VerifyCallback(body, now, "sha256="+SignRunCallback(secret, now, body)))
+	assert.ErrorIs(t, service.VerifyCallback(body, now, SignRunCallback([]byte("other"), now, body)), ErrInvalidRunSignature)
+	assert.ErrorIs(t, service.VerifyCallback([]byte(`{}`), now, SignRunCallback(secret, now, body)), ErrInvalidRunSignature)
+
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	assert.ErrorIs(t, service.VerifyCallback(body, stale, SignRunCallback(secret, stale, body)), ErrInvalidRunSignature)
+
+	disabled := NewEventRunService(&fakeEventRunRepository{}, &fakeEventRepository{}, nil)
+	assert.ErrorIs(t, disabled.VerifyCallback(body, now, ""), ErrRunCallbacksDisabled)
+}
+
+func TestRecordRun(t *testing.T) {
+	runs := &fakeEventRunRepository{}
+	events := &fakeEventRepository{created: []*models.Event{{ID: "e1", OwnerID: "auth0|owner", Name: "daily", RuleName: "go-api-x-1"}}}
+	service := NewEventRunService(runs, events, []byte("secret"))
+
+	assert.NoError(t, service.RecordRun(&models.EventRunReport{RuleName: "go-api-x-1", RunID: "run-1", Status: "started"}))
+	assert.NoError(t, service.RecordRun(&models.EventRunReport{
+		RuleName: "go-api-x-1", RunID: "run-1", Status: "failed",
+		Error: strings.Repeat("é", maxRunErrorBytes),
+	}))
+
+	assert.Equal(t, []recordedRun{{eventID: "e1", runID: "run-1", status: models.EventRunStatusRunning}}, runs.started)
+	if assert.Len(t, runs.finished, 1) {
+		assert.Equal(t, models.EventRunStatusFailed, runs.finished[0].status)
+		assert.LessOrEqual(t, len(runs.finished[0].errorText), maxRunErrorBytes)
+	}
+	if assert.Len(t, runs.outbox, 1) {
+		assert.Equal(t, models.JobKindNotifyEventFailed, runs.outbox[0].Kind)
+		assert.Contains(t, string(runs.outbox[0].Payload), `"owner_id":"auth0|owner"`)
+	}
+
+	assert.ErrorIs(t, service.RecordRun(&models.EventRunReport{RuleName: "missing", RunID: "1", Status: "started"}), ErrEventNotFound)
+	assert.ErrorIs(t, service.RecordRun(&models.EventRunReport{RuleName: "go-api-x-1", RunID: "1", Status: "exploded"}), ErrInvalidRunReport)
+	assert.ErrorIs(t, service.RecordRun(&models.EventRunReport{RuleName: "go-api-x-1", Status: "started"}), ErrInvalidRunReport)
+}
+
+func TestRecordRun_RepeatedFailureNotifiesOnce(t *testing.T) {
+	runs := &fakeEventRunRepository{}
+	events := &fakeEventRepository{created: []*models.Event{{ID: "e1", OwnerID: "auth0|owner", Name: "daily", RuleName: "go-api-x-1"}}}
+	service := NewEventRunService(runs, events, []byte("secret"))
+	report := &models.EventRunReport{RuleName: "go-api-x-1", RunID: "run-1", Status: "failed", Error: "timeout"}
+
+	// A target retrying its callback reports the same failure again
+	assert.NoError(t, service.RecordRun(report))
+	assert.NoError(t, service.RecordRun(report))
+
+	assert.Len(t, runs.finished, 1)
+	assert.Len(t, runs.outbox, 1)
+}
+
+func TestListRuns_OtherOwner(t *testing.T) {
+	events := &fakeEventRepository{created: []*models.Event{{ID: "e1", OwnerID: "auth0|owner", Name: "daily"}}}
+	service := NewEventRunService(&fakeEventRunRepository{}, events, nil)
+
+	_, err := service.ListRuns("auth0|other", "daily", 1, 20)
+	assert.ErrorIs(t, err, ErrEventNotFound)
+
+	page, err := service.ListRuns("auth0|owner", "daily", 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, page.Page)
+	assert.NotNil(t, page.Items)
+}
@@ -0,0 +1,554 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/repositories"
+	"github.com/dat1010/go-api/scheduler"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrEventExists        = errors.New("an event with that name already exists")
+	ErrEventNotFound      = errors.New("event not found")
+	ErrEventQuotaExceeded = errors.New("active event quota reached")
+	ErrInvalidTimeZone    = errors.New("unknown time zone")
+	ErrInvalidRunCount    = errors.New("next run count out of range")
+	ErrInvalidPayload     = errors.New("payload must be a JSON object")
+
+	ErrInvalidIdempotencyKey = errors.New("Idempotency-Key must be 1 to 255 printable ASCII characters")
+	ErrIdempotencyKeyReused  = errors.New("Idempotency-Key was already used with a different request")
+)
+
+// maxIdempotencyKeyLength bounds Idempotency-Key headers.
+const maxIdempotencyKeyLength = 255
+
+// Next run previews list DefaultNextRuns fire times unless asked for more, up to MaxNextRuns.
+const (
+	DefaultNextRuns = 5
+	MaxNextRuns     = 50
+)
+
+const (
+	defaultEventPageSize = 20
+	maxEventPageSize     = 100
+)
+
+// EventQuotaLookup returns how many enabled events a user may have at once.
+type EventQuotaLookup func(ownerID string) (int, error)
+
+// EventTargetResolver maps an event's target bindings to registered targets.
+type EventTargetResolver func(bindings []models.EventTargetBinding) ([]scheduler.ScheduleTarget, error)
+
+// EventPayloadValidator checks a payload against the schemas of the target kinds it is sent to.
+type EventPayloadValidator func(kinds []string, payload json.RawMessage) error
+
+type EventService interface {
+	CreateEvent(ctx context.Context, ownerID string, req *models.CreateEventRequest) (*models.Event, error)
+	CreateEventIdempotent(ctx context.Context, ownerID, key string, req *models.CreateEventRequest) (*models.Event, bool, error)
+	GetEvent(ownerID, name string) (*models.Event, error)
+	ListUserEvents(ownerID string) ([]models.Event, error)
+	ListUserEventPage(ownerID string, page, pageSize int) (*models.EventPage, error)
+	UpdateEvent(ctx context.Context, ownerID, name string, req *models.UpdateEventRequest) (*models.Event, error)
+	PauseEvent(ctx context.Context, ownerID, name string) (*models.Event, error)
+	ResumeEvent(ctx context.Context, ownerID, name string) (*models.Event, error)
+	DeleteEvent(ctx context.Context, ownerID, name string) error
+	HandleScheduleSync(ctx context.Context, payload json.RawMessage) error
+	PreviewSchedule(req *models.PreviewScheduleRequest) (*models.SchedulePreview, error)
+	AttachNextRuns(events []models.Event, count int, timeZone string) error
+}
+
+type eventService struct {
+	repo      repositories.EventRepository
+	scheduler scheduler.Scheduler
+	quotaFor  EventQuotaLookup
+	resolve   EventTargetResolver
+	validate  EventPayloadValidator
+}
+
+func NewEventService(repo repositories.EventRepository, scheduler scheduler.Scheduler, quotaFor EventQuotaLookup, resolve EventTargetResolver, validate EventPayloadValidator) EventService {
+	return &eventService{repo: repo, scheduler: scheduler, quotaFor: quotaFor, resolve: resolve, validate: validate}
+}
+
+// CreateEvent stores the event once the backend has checked its schedule. A
+// job queued in the same transaction then schedules it.
+func (s *eventService) CreateEvent(ctx context.Context, ownerID string, req *models.CreateEventRequest) (*models.Event, error) {
+	payload, err := normalizePayload(req.Payload)
+	if err != nil {
+		return nil, err
+	}
+	return s.createEvent(ctx, ownerID, req, payload, "", "")
+}
+
+// CreateEventIdempotent creates the event once per key: repeating a request
+// with the same key returns the event the first one created, reporting it as
+// replayed, while sending a different request with the key fails with
+// ErrIdempotencyKeyReused. An empty key behaves like CreateEvent.
+func (s *eventService) CreateEventIdempotent(ctx context.Context, ownerID, key string, req *models.CreateEventRequest) (*models.Event, bool, error) {
+	if key == "" {
+		event, err := s.CreateEvent(ctx, ownerID, req)
+		return event, false, err
+	}
+	if !validIdempotencyKey(key) {
+		return nil, false, ErrInvalidIdempotencyKey
+	}
+	payload, err := normalizePayload(req.Payload)
+	if err != nil {
+		return nil, false, err
+	}
+	hash, err := requestHash(req, payload)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if event, err := s.replay(ownerID, key, hash); event != nil || err != nil {
+		return event, event != nil, err
+	}
+	event, err := s.createEvent(ctx, ownerID, req, payload, key, hash)
+	if errors.Is(err, ErrEventExists) {
+		// A concurrent request with the same key may have created the event first
+		if replayed, replayErr := s.replay(ownerID, key, hash); replayed != nil || replayErr != nil {
+			return replayed, replayed != nil, replayErr
+		}
+	}
+	return event, false, err
+}
+
+// replay returns the event an earlier request with the key created, or nil
+// when the key is unused.
+func (s *eventService) replay(ownerID, key, hash string) (*models.Event, error) {
+	event, storedHash, err := s.repo.GetByIdempotencyKey(ownerID, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if storedHash != hash {
+		return nil, ErrIdempotencyKeyReused
+	}
+	return event, nil
+}
+
+// createEvent stores the event, recording the idempotency key with it when
+// one is given.
+func (s *eventService) createEvent(ctx context.Context, ownerID string, req *models.CreateEventRequest, payload models.JSONObject, key, hash string) (*models.Event, error) {
+	id := uuid.New().String()
+	event := &models.Event{
+		ID:          id,
+		OwnerID:     ownerID,
+		Name:        req.Name,
+		RuleName:    scheduler.RuleName(ownerID, id),
+		Description: req.Description,
+		Schedule:    req.Schedule,
+		EventTiming: normalizeTiming(req.EventTiming),
+		Payload:     payload,
+		Targets:     bindingsOrEmpty(req.Targets),
+		State:       models.EventStateEnabled,
+	}
+	schedule, err := s.checkedSchedule(event)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.scheduler.Check(schedule); err != nil {
+		return nil, err
+	}
+	maxActive, err := s.activeLimit(ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if key == "" {
+		err = s.repo.Create(event, maxActive, scheduleSyncMessage(event.RuleName))
+	} else {
+		err = s.repo.CreateIdempotent(event, key, hash, maxActive, scheduleSyncMessage(event.RuleName))
+	}
+	if err != nil {
+		if repositories.IsUniqueViolation(err) {
+			return nil, ErrEventExists
+		}
+		if errors.Is(err, repositories.ErrActiveEventLimit) {
+			return nil, ErrEventQuotaExceeded
+		}
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// GetEvent returns ErrEventNotFound when the event does not exist or belongs
+// to someone else, so callers cannot probe other users' event names.
+func (s *eventService) GetEvent(ownerID, name string) (*models.Event, error) {
+	event, err := s.repo.GetByOwnerAndName(ownerID, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrEventNotFound
+	}
+	return event, err
+}
+
+func (s *eventService) UpdateEvent(ctx context.Context, ownerID, name string, req *models.UpdateEventRequest) (*models.Event, error) {
+	event, err := s.GetEvent(ownerID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Description != nil {
+		event.Description = *req.Description
+	}
+	if req.Schedule != nil {
+		event.Schedule = *req.Schedule
+	}
+	if req.EventTiming != nil {
+		event.EventTiming = normalizeTiming(*req.EventTiming)
+	}
+	if req.Payload != nil {
+		if event.Payload, err = normalizePayload(req.Payload); err != nil {
+			return nil, err
+		}
+	}
+	if req.Targets != nil {
+		event.Targets = bindingsOrEmpty(req.Targets)
+	}
+
+	// Targets are resolved afresh so changes to registered targets are picked up
+	schedule, err := s.checkedSchedule(event)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.scheduler.Check(schedule); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(event, repositories.NoActiveEventLimit, scheduleSyncMessage(event.RuleName)); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+func (s *eventService) PauseEvent(ctx context.Context, ownerID, name string) (*models.Event, error) {
+	return s.setState(ownerID, name, models.EventStateDisabled)
+}
+
+func (s *eventService) ResumeEvent(ctx context.Context, ownerID, name string) (*models.Event, error) {
+	return s.setState(ownerID, name, models.EventStateEnabled)
+}
+
+func (s *eventService) setState(ownerID, name, state string) (*models.Event, error) {
+	event, err := s.GetEvent(ownerID, name)
+	if err != nil {
+		return nil, err
+	}
+	if event.State == state {
+		return event, nil
+	}
+	maxActive := repositories.NoActiveEventLimit
+	if state == models.EventStateEnabled {
+		if maxActive, err = s.activeLimit(ownerID); err != nil {
+			return nil, err
+		}
+	}
+
+	event.State = state
+	if err := s.repo.Update(event, maxActive, scheduleSyncMessage(event.RuleName)); err != nil {
+		if errors.Is(err, repositories.ErrActiveEventLimit) {
+			return nil, ErrEventQuotaExceeded
+		}
+		return nil, err
+	}
+	return event, nil
+}
+
+// DeleteEvent removes the event row and queues removing its schedule.
+func (s *eventService) DeleteEvent(ctx context.Context, ownerID, name string) error {
+	event, err := s.GetEvent(ownerID, name)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(event.ID, scheduleSyncMessage(event.RuleName))
+}
+
+// scheduleSyncJob is the payload of a JobKindEventScheduleSync job.
+type scheduleSyncJob struct {
+	RuleName string `json:"rule_name"`
+}
+
+// scheduleSyncMessage is the outbox message that brings a rule in line with
+// its stored event.
+func scheduleSyncMessage(ruleName string) models.OutboxMessage {
+	return outboxMessage(models.JobKindEventScheduleSync, scheduleSyncJob{RuleName: ruleName})
+}
+
+// HandleScheduleSync puts the rule as its event is stored now, or deletes it
+// when the event is gone. Working from the stored event rather than the
+// change that queued the job makes jobs safe to run late, twice or out of
+// order; the reconciler repairs whatever a dead job leaves behind.
+func (s *eventService) HandleScheduleSync(ctx context.Context, payload json.RawMessage) error {
+	var job scheduleSyncJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return err
+	}
+	event, err := s.repo.GetByRuleName(job.RuleName)
+	if errors.Is(err, sql.ErrNoRows) {
+		if err := s.scheduler.Delete(ctx, job.RuleName); err != nil && !errors.Is(err, scheduler.ErrNotFound) {
+			return err
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	schedule, err := s.scheduleFor(event)
+	if err != nil {
+		return err
+	}
+	// Update leaves an existing rule in place if its targets fail, where
+	// Create would roll it back and stop the event firing
+	err = s.scheduler.Update(ctx, schedule)
+	if errors.Is(err, scheduler.ErrNotFound) {
+		return s.scheduler.Create(ctx, schedule)
+	}
+	return err
+}
+
+// PreviewSchedule validates the expression and lists its next fire times
+// without scheduling anything.
+func (s *eventService) PreviewSchedule(req *models.PreviewScheduleRequest) (*models.SchedulePreview, error) {
+	count, loc, err := nextRunOptions(req.Count, req.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	expr, err := scheduler.Compile(req.Schedule, req.TimeZone, req.StartAt, req.EndAt)
+	if err != nil {
+		return nil, err
+	}
+	return &models.SchedulePreview{
+		Schedule:   req.Schedule,
+		Expression: expr.String(),
+		TimeZone:   loc.String(),
+		NextRuns:   scheduler.NextRuns(expr, time.Now(), count, loc),
+	}, nil
+}
+
+// AttachNextRuns fills in NextRuns for each enabled event. A count of zero
+// means DefaultNextRuns and an empty time zone shows each event's runs in
+// its own zone.
+func (s *eventService) AttachNextRuns(events []models.Event, count int, timeZone string) error {
+	count, display, err := nextRunOptions(count, timeZone)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for i := range events {
+		event := &events[i]
+		if event.State != models.EventStateEnabled {
+			continue
+		}
+		expr, err := scheduler.Compile(event.Schedule, event.TimeZone, event.StartAt, event.EndAt)
+		if err != nil {
+			// Events stored before validation existed may not parse; leave them without a preview.
+			continue
+		}
+		loc := display
+		if timeZone == "" {
+			if eventLoc, err := time.LoadLocation(event.TimeZone); err == nil {
+				loc = eventLoc
+			}
+		}
+		event.NextRuns = scheduler.NextRuns(expr, now, count, loc)
+	}
+	return nil
+}
+
+func nextRunOptions(count int, timeZone string) (int, *time.Location, error) {
+	if count == 0 {
+		count = DefaultNextRuns
+	}
+	if count < 0 || count > MaxNextRuns {
+		return 0, nil, ErrInvalidRunCount
+	}
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %s", ErrInvalidTimeZone, timeZone)
+	}
+	return count, loc, nil
+}
+
+// activeLimit returns how many enabled events the owner may have. The
+// repository checks it in the same transaction that enables the event.
+func (s *eventService) activeLimit(ownerID string) (int, error) {
+	if s.quotaFor == nil {
+		return repositories.NoActiveEventLimit, nil
+	}
+	return s.quotaFor(ownerID)
+}
+
+// normalizeTiming stores UTC explicitly when no time zone is given.
+func normalizeTiming(timing models.EventTiming) models.EventTiming {
+	if timing.TimeZone == "" {
+		timing.TimeZone = "UTC"
+	}
+	return timing
+}
+
+// scheduleFor converts a stored event into the scheduler's representation,
+// resolving its target bindings.
+func (s *eventService) scheduleFor(event *models.Event) (*scheduler.Schedule, error) {
+	schedule := &scheduler.Schedule{
+		Name:           event.RuleName,
+		OwnerID:        event.OwnerID,
+		Description:    event.Description,
+		Expression:     event.Schedule,
+		TimeZone:       event.TimeZone,
+		StartAt:        event.StartAt,
+		EndAt:          event.EndAt,
+		FlexibleWindow: time.Duration(event.FlexibleWindowMinutes) * time.Minute,
+		Payload:        json.RawMessage(event.Payload),
+		Disabled:       event.State == models.EventStateDisabled,
+	}
+	if len(event.Targets) == 0 {
+		return schedule, nil
+	}
+	if s.resolve == nil {
+		return nil, fmt.Errorf("%w: no targets are configured", ErrUnknownEventTarget)
+	}
+	targets, err := s.resolve(event.Targets)
+	if err != nil {
+		return nil, err
+	}
+	schedule.Targets = targets
+	return schedule, nil
+}
+
+// checkedSchedule builds the event's schedule and checks it can be delivered:
+// the schedule must be valid and the payload must match the schema of every
+// kind of target it goes to.
+func (s *eventService) checkedSchedule(event *models.Event) (*scheduler.Schedule, error) {
+	schedule, err := s.scheduleFor(event)
+	if err != nil {
+		return nil, err
+	}
+	if err := schedule.Validate(time.Now()); err != nil {
+		return nil, err
+	}
+	if s.validate != nil {
+		if err := s.validate(targetKinds(schedule), schedule.Payload); err != nil {
+			return nil, err
+		}
+	}
+	return schedule, nil
+}
+
+// targetKinds lists the distinct kinds of the schedule's targets; schedules
+// without targets go to the default Lambda.
+func targetKinds(schedule *scheduler.Schedule) []string {
+	if len(schedule.Targets) == 0 {
+		return []string{scheduler.TargetKindLambda}
+	}
+	var kinds []string
+	seen := make(map[string]bool, len(schedule.Targets))
+	for _, target := range schedule.Targets {
+		if !seen[target.Kind] {
+			seen[target.Kind] = true
+			kinds = append(kinds, target.Kind)
+		}
+	}
+	return kinds
+}
+
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for _, r := range key {
+		if r < ' ' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// requestHash fingerprints a create request, with its payload normalized so
+// whitespace alone doesn't make a retry look like a different request.
+func requestHash(req *models.CreateEventRequest, payload models.JSONObject) (string, error) {
+	normalized := *req
+	normalized.Payload = json.RawMessage(payload)
+	data, err := json.Marshal(normalized)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// normalizePayload compacts a client's payload, treating a missing or null
+// one as {}. Anything but a JSON object returns ErrInvalidPayload.
+func normalizePayload(raw json.RawMessage) (models.JSONObject, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return models.JSONObject(`{}`), nil
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err != nil || raw[0] != '{' {
+		return nil, ErrInvalidPayload
+	}
+	return models.JSONObject(compact.Bytes()), nil
+}
+
+// bindingsOrEmpty stores no bindings as an empty list rather than null.
+func bindingsOrEmpty(bindings []models.EventTargetBinding) models.EventTargetBindings {
+	if bindings == nil {
+		return models.EventTargetBindings{}
+	}
+	return bindings
+}
+
+func (s *eventService) ListUserEvents(ownerID string) ([]models.Event, error) {
+	events, err := s.repo.ListByOwner(ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if events == nil {
+		events = []models.Event{}
+	}
+	return events, nil
+}
+
+func (s *eventService) ListUserEventPage(ownerID string, page, pageSize int) (*models.EventPage, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultEventPageSize
+	}
+	if pageSize > maxEventPageSize {
+		pageSize = maxEventPageSize
+	}
+
+	total, err := s.repo.CountByOwner(ownerID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.repo.ListPageByOwner(ownerID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.Event{}
+	}
+
+	return &models.EventPage{
+		Items:    items,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
@@ -0,0 +1,590 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/repositories"
+	"github.com/dat1010/go-api/scheduler"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeEventRepository struct {
+	commitErr error
+	created   []*models.Event
+	keys      map[string]idempotentCreate
+	outbox    []models.OutboxMessage
+}
+
+type idempotentCreate struct {
+	event *models.Event
+	hash  string
+}
+
+func (r *fakeEventRepository) Create(event *models.Event, maxActive int, outbox ...models.OutboxMessage) error {
+	if err := r.checkActiveLimit(event, maxActive); err != nil {
+		return err
+	}
+	if r.commitErr != nil {
+		return r.commitErr
+	}
+	r.created = append(r.created, event)
+	r.outbox = append(r.outbox, outbox...)
+	return nil
+}
+
+func (r *fakeEventRepository) CreateIdempotent(event *models.Event, key, requestHash string, maxActive int, outbox ...models.OutboxMessage) error {
+	if _, used := r.keys[event.OwnerID+"/"+key]; used {
+		return &pgconn.PgError{Code: "23505"}
+	}
+	if err := r.Create(event, maxActive, outbox...); err != nil {
+		return err
+	}
+	if r.keys == nil {
+		r.keys = make(map[string]idempotentCreate)
+	}
+	r.keys[event.OwnerID+"/"+key] = idempotentCreate{event: event, hash: requestHash}
+	return nil
+}
+
+func (r *fakeEventRepository) GetByIdempotencyKey(ownerID, key string) (*models.Event, string, error) {
+	created, ok := r.keys[ownerID+"/"+key]
+	if !ok {
+		return nil, "", sql.ErrNoRows
+	}
+	copied := *created.event
+	return &copied, created.hash, nil
+}
+
+func (r *fakeEventRepository) GetByOwnerAndName(ownerID, name string) (*models.Event, error) {
+	for _, event := range r.created {
+		if event.OwnerID == ownerID && event.Name == name {
+			copied := *event
+			return &copied, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeEventRepository) GetByRuleName(ruleName string) (*models.Event, error) {
+	for _, event := range r.created {
+		if event.RuleName == ruleName {
+			copied := *event
+			return &copied, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeEventRepository) ListByOwner(ownerID string) ([]models.Event, error) {
+	return nil, nil
+}
+
+func (r *fakeEventRepository) ListAll() ([]models.Event, error) {
+	events := make([]models.Event, 0, len(r.created))
+	for _, event := range r.created {
+		events = append(events, *event)
+	}
+	return events, nil
+}
+
+func (r *fakeEventRepository) ListPageByOwner(ownerID string, limit, offset int) ([]models.Event, error) {
+	var events []models.Event
+	for _, event := range r.created {
+		if event.OwnerID == ownerID {
+			events = append(events, *event)
+		}
+	}
+	if offset >= len(events) {
+		return nil, nil
+	}
+	events = events[offset:]
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func (r *fakeEventRepository) CountByOwner(ownerID string) (int, error) {
+	count := 0
+	for _, event := range r.created {
+		if event.OwnerID == ownerID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeEventRepository) checkActiveLimit(event *models.Event, maxActive int) error {
+	if maxActive == repositories.NoActiveEventLimit || event.State != models.EventStateEnabled {
+		return nil
+	}
+	active := 0
+	for _, existing := range r.created {
+		if existing.OwnerID == event.OwnerID && existing.ID != event.ID && existing.State == models.EventStateEnabled {
+			active++
+		}
+	}
+	if active >= maxActive {
+		return repositories.ErrActiveEventLimit
+	}
+	return nil
+}
+
+func (r *fakeEventRepository) Update(event *models.Event, maxActive int, outbox ...models.OutboxMessage) error {
+	if err := r.checkActiveLimit(event, maxActive); err != nil {
+		return err
+	}
+	for i, existing := range r.created {
+		if existing.ID == event.ID {
+			r.created[i] = event
+		}
+	}
+	r.outbox = append(r.outbox, outbox...)
+	return nil
+}
+
+func (r *fakeEventRepository) Delete(id string, outbox ...models.OutboxMessage) error {
+	for i, existing := range r.created {
+		if existing.ID == id {
+			r.created = append(r.created[:i], r.created[i+1:]...)
+			break
+		}
+	}
+	r.outbox = append(r.outbox, outbox...)
+	return nil
+}
+
+// enqueue stands in for JobService.Enqueue, queueing messages with those
+// the repository wrote.
+func (r *fakeEventRepository) enqueue(messages ...models.OutboxMessage) error {
+	r.outbox = append(r.outbox, messages...)
+	return nil
+}
+
+// runScheduleSyncs runs the jobs the repository queued, as a job worker would.
+func runScheduleSyncs(t *testing.T, service EventService, repo *fakeEventRepository) error {
+	t.Helper()
+	queued := repo.outbox
+	repo.outbox = nil
+	for _, message := range queued {
+		assert.Equal(t, models.JobKindEventScheduleSync, message.Kind)
+		if err := service.HandleScheduleSync(context.Background(), json.RawMessage(message.Payload)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type fakeScheduler struct {
+	scheduler.Scheduler
+	checkErr  error
+	createErr error
+	deleteErr error
+	created   []string
+	deleted   []string
+	disabled  []string
+	last      *scheduler.Schedule
+}
+
+func (s *fakeScheduler) Check(schedule *scheduler.Schedule) error {
+	return s.checkErr
+}
+
+func (s *fakeScheduler) Create(ctx context.Context, schedule *scheduler.Schedule) error {
+	if s.createErr != nil {
+		return s.createErr
+	}
+	s.created = append(s.created, schedule.Name)
+	s.last = schedule
+	return nil
+}
+
+// Update only finds schedules the fake created.
+func (s *fakeScheduler) Update(ctx context.Context, schedule *scheduler.Schedule) error {
+	for _, name := range s.created {
+		if name == schedule.Name {
+			s.last = schedule
+			return nil
+		}
+	}
+	return scheduler.ErrNotFound
+}
+
+func (s *fakeScheduler) Delete(ctx context.Context, name string) error {
+	if s.deleteErr != nil {
+		return s.deleteErr
+	}
+	s.deleted = append(s.deleted, name)
+	return nil
+}
+
+func (s *fakeScheduler) Disable(ctx context.Context, name string) error {
+	s.disabled = append(s.disabled, name)
+	return nil
+}
+
+func TestCreateEvent_KeepsOwnerOutOfPayload(t *testing.T) {
+	repo := &fakeEventRepository{}
+	sched := &fakeScheduler{}
+	service := NewEventService(repo, sched, nil, nil, nil)
+
+	event, err := service.CreateEvent(context.Background(), "auth0|owner", &models.CreateEventRequest{
+		Name:     "daily",
+		Schedule: "0 12 * * ? *",
+		Payload:  json.RawMessage(`{"key": "value", "user_id": "auth0|spoofed"}`),
+	})
+
+	assert.NoError(t, err)
+	assert.NoError(t, runScheduleSyncs(t, service, repo))
+	assert.Equal(t, `{"key":"value","user_id":"auth0|spoofed"}`, string(event.Payload))
+	// The owner the targets see comes from the caller, not the payload
+	if assert.NotNil(t, sched.last) {
+		assert.Equal(t, "auth0|owner", sched.last.OwnerID)
+	}
+	assert.Equal(t, models.EventStateEnabled, event.State)
+	assert.Equal(t, []string{event.RuleName}, sched.created)
+	assert.Len(t, repo.created, 1)
+}
+
+func TestCreateEvent_SameNameForDifferentOwners(t *testing.T) {
+	repo := &fakeEventRepository{}
+	sched := &fakeScheduler{}
+	service := NewEventService(repo, sched, nil, nil, nil)
+	request := &models.CreateEventRequest{Name: "daily", Schedule: "0 12 * * ? *"}
+
+	first, err := service.CreateEvent(context.Background(), "auth0|alice", request)
+	assert.NoError(t, err)
+	second, err := service.CreateEvent(context.Background(), "auth0|bob", request)
+	assert.NoError(t, err)
+	assert.NoError(t, runScheduleSyncs(t, service, repo))
+
+	assert.NotEqual(t, first.RuleName, second.RuleName)
+	assert.True(t, strings.HasPrefix(first.RuleName, scheduler.OwnerNamespace("auth0|alice")))
+	assert.Equal(t, []string{first.RuleName, second.RuleName}, sched.created)
+}
+
+func TestCreateEvent_UnsupportedScheduleStoresNothing(t *testing.T) {
+	repo := &fakeEventRepository{}
+	sched := &fakeScheduler{checkErr: fmt.Errorf("%w: EventBridge rules only run UTC schedules", scheduler.ErrUnsupported)}
+	service := NewEventService(repo, sched, nil, nil, nil)
+
+	_, err := service.CreateEvent(context.Background(), "auth0|owner", &models.CreateEventRequest{Name: "daily", Schedule: "0 12 * * ? *"})
+
+	assert.ErrorIs(t, err, scheduler.ErrUnsupported)
+	assert.Empty(t, repo.created)
+	assert.Empty(t, repo.outbox)
+}
+
+func TestCreateEvent_CommitFailureSchedulesNothing(t *testing.T) {
+	repo := &fakeEventRepository{commitErr: errors.New("connection reset")}
+	sched := &fakeScheduler{}
+	service := NewEventService(repo, sched, nil, nil, nil)
+
+	_, err := service.CreateEvent(context.Background(), "auth0|owner", &models.CreateEventRequest{Name: "daily", Schedule: "0 12 * * ? *"})
+
+	assert.Error(t, err)
+	assert.Empty(t, repo.outbox)
+	assert.Empty(t, sched.created)
+}
+
+func TestHandleScheduleSync_FailureKeepsEvent(t *testing.T) {
+	repo := &fakeEventRepository{}
+	sched := &fakeScheduler{createErr: errors.New("throttled")}
+	service := NewEventService(repo, sched, nil, nil, nil)
+
+	_, err := service.CreateEvent(context.Background(), "auth0|owner", &models.CreateEventRequest{Name: "daily", Schedule: "0 12 * * ? *"})
+	assert.NoError(t, err)
+
+	// The job fails and is retried later; the event stays stored
+	assert.Error(t, runScheduleSyncs(t, service, repo))
+	assert.Len(t, repo.created, 1)
+}
+
+func TestGetEvent_OtherOwnerNotFound(t *testing.T) {
+	repo := &fakeEventRepository{created: []*models.Event{{ID: "1", OwnerID: "auth0|owner", Name: "daily"}}}
+	service := NewEventService(repo, &fakeScheduler{}, nil, nil, nil)
+
+	_, err := service.GetEvent("auth0|other", "daily")
+
+	assert.ErrorIs(t, err, ErrEventNotFound)
+}
+
+func TestPauseEvent_DisablesSchedule(t *testing.T) {
+	repo := &fakeEventRepository{created: []*models.Event{{ID: "1", OwnerID: "auth0|owner", Name: "daily", RuleName: "go-api-owner-1", State: models.EventStateEnabled}}}
+	sched := &fakeScheduler{}
+	service := NewEventService(repo, sched, nil, nil, nil)
+
+	event, err := service.PauseEvent(context.Background(), "auth0|owner", "daily")
+
+	assert.NoError(t, err)
+	assert.NoError(t, runScheduleSyncs(t, service, repo))
+	assert.Equal(t, models.EventStateDisabled, event.State)
+	if assert.NotNil(t, sched.last) {
+		assert.Equal(t, "go-api-owner-1", sched.last.Name)
+		assert.True(t, sched.last.Disabled)
+	}
+	assert.Equal(t, models.EventStateDisabled, repo.created[0].State)
+}
+
+func TestDeleteEvent_MissingScheduleStillDeletesRow(t *testing.T) {
+	repo := &fakeEventRepository{created: []*models.Event{{ID: "1", OwnerID: "auth0|owner", Name: "daily"}}}
+	sched := &fakeScheduler{deleteErr: scheduler.ErrNotFound}
+	service := NewEventService(repo, sched, nil, nil, nil)
+
+	err := service.DeleteEvent(context.Background(), "auth0|owner", "daily")
+
+	assert.NoError(t, err)
+	assert.Empty(t, repo.created)
+	assert.NoError(t, runScheduleSyncs(t, service, repo))
+}
+
+func TestCreateEvent_QuotaReached(t *testing.T) {
+	repo := &fakeEventRepository{created: []*models.Event{{ID: "1", OwnerID: "auth0|owner", Name: "daily", State: models.EventStateEnabled}}}
+	sched := &fakeScheduler{}
+	service := NewEventService(repo, sched, func(ownerID string) (int, error) { return 1, nil }, nil, nil)
+
+	_, err := service.CreateEvent(context.Background(), "auth0|owner", &models.CreateEventRequest{Name: "hourly", Schedule: "0 * * * ? *"})
+
+	assert.ErrorIs(t, err, ErrEventQuotaExceeded)
+	assert.Empty(t, sched.created)
+}
+
+func TestResumeEvent_QuotaReached(t *testing.T) {
+	repo := &fakeEventRepository{created: []*models.Event{
+		{ID: "1", OwnerID: "auth0|owner", Name: "daily", State: models.EventStateEnabled},
+		{ID: "2", OwnerID: "auth0|owner", Name: "hourly", State: models.EventStateDisabled},
+	}}
+	service := NewEventService(repo, &fakeScheduler{}, func(ownerID string) (int, error) { return 1, nil }, nil, nil)
+
+	_, err := service.ResumeEvent(context.Background(), "auth0|owner", "hourly")
+
+	assert.ErrorIs(t, err, ErrEventQuotaExceeded)
+	assert.Equal(t, models.EventStateDisabled, repo.created[1].State)
+}
+
+func TestCreateEvent_InvalidExpressionNeverReachesScheduler(t *testing.T) {
+	repo := &fakeEventRepository{}
+	sched := &fakeScheduler{}
+	service := NewEventService(repo, sched, nil, nil, nil)
+
+	_, err := service.CreateEvent(context.Background(), "auth0|owner", &models.CreateEventRequest{Name: "daily", Schedule: "0 12 * *"})
+
+	assert.ErrorIs(t, err, scheduler.ErrInvalidExpression)
+	assert.Empty(t, sched.created)
+	assert.Empty(t, repo.created)
+}
+
+func TestCreateEvent_OneTimeInTimeZone(t *testing.T) {
+	repo := &fakeEventRepository{}
+	sched := &fakeScheduler{}
+	service := NewEventService(repo, sched, nil, nil, nil)
+
+	when := time.Now().Add(48 * time.Hour).Format("2006-01-02T15:04:05")
+	event, err := service.CreateEvent(context.Background(), "auth0|owner", &models.CreateEventRequest{
+		Name:        "reminder",
+		Schedule:    "at(" + when + ")",
+		EventTiming: models.EventTiming{TimeZone: "America/Chicago", FlexibleWindowMinutes: 15},
+	})
+
+	assert.NoError(t, err)
+	assert.NoError(t, runScheduleSyncs(t, service, repo))
+	assert.Equal(t, "America/Chicago", event.TimeZone)
+	assert.Equal(t, []string{event.RuleName}, sched.created)
+}
+
+func TestCreateEvent_DefaultsToUTC(t *testing.T) {
+	service := NewEventService(&fakeEventRepository{}, &fakeScheduler{}, nil, nil, nil)
+
+	event, err := service.CreateEvent(context.Background(), "auth0|owner", &models.CreateEventRequest{Name: "daily", Schedule: "0 12 * * ? *"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "UTC", event.TimeZone)
+}
+
+func TestCreateEvent_PastOneTimeRejected(t *testing.T) {
+	sched := &fakeScheduler{}
+	service := NewEventService(&fakeEventRepository{}, sched, nil, nil, nil)
+
+	_, err := service.CreateEvent(context.Background(), "auth0|owner", &models.CreateEventRequest{Name: "late", Schedule: "at(2020-01-01T09:00:00)"})
+
+	assert.ErrorIs(t, err, scheduler.ErrInvalidExpression)
+	assert.Empty(t, sched.created)
+}
+
+func TestListUserEventPage_ClampsAndPages(t *testing.T) {
+	repo := &fakeEventRepository{}
+	for i := 0; i < 3; i++ {
+		repo.created = append(repo.created, &models.Event{ID: fmt.Sprint(i), OwnerID: "auth0|owner"})
+	}
+	repo.created = append(repo.created, &models.Event{ID: "other", OwnerID: "auth0|other"})
+	service := NewEventService(repo, &fakeScheduler{}, nil, nil, nil)
+
+	page, err := service.ListUserEventPage("auth0|owner", 2, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, page.Total)
+	assert.Len(t, page.Items, 1)
+	assert.Equal(t, "2", page.Items[0].ID)
+
+	page, err = service.ListUserEventPage("auth0|owner", 9, 500)
+	assert.NoError(t, err)
+	assert.Equal(t, maxEventPageSize, page.PageSize)
+	assert.NotNil(t, page.Items)
+	assert.Empty(t, page.Items)
+}
+
+func TestCreateEvent_ResolvesTargets(t *testing.T) {
+	sched := &fakeScheduler{}
+	resolve := func(bindings []models.EventTargetBinding) ([]scheduler.ScheduleTarget, error) {
+		targets := make([]scheduler.ScheduleTarget, len(bindings))
+		for i, binding := range bindings {
+			targets[i] = scheduler.ScheduleTarget{ID: binding.Target, Kind: scheduler.TargetKindSQS, Arn: "arn:aws:sqs:us-east-1:1:" + binding.Target, InputTemplate: binding.InputTemplate}
+		}
+		return targets, nil
+	}
+	repo := &fakeEventRepository{}
+	service := NewEventService(repo, sched, nil, resolve, nil)
+
+	event, err := service.CreateEvent(context.Background(), "auth0|owner", &models.CreateEventRequest{
+		Name:     "daily",
+		Schedule: "0 12 * * ? *",
+		Targets: []models.EventTargetBinding{
+			{Target: "orders"},
+			{Target: "audit", InputTemplate: `{"owner":"<user_id>","at":"<time>"}`},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.NoError(t, runScheduleSyncs(t, service, repo))
+	assert.Len(t, event.Targets, 2)
+	if assert.NotNil(t, sched.last) {
+		assert.Equal(t, "orders", sched.last.Targets[0].ID)
+		assert.Equal(t, "audit", sched.last.Targets[1].ID)
+	}
+}
+
+func TestCreateEvent_TargetsWithoutRegistry(t *testing.T) {
+	service := NewEventService(&fakeEventRepository{}, &fakeScheduler{}, nil, nil, nil)
+
+	_, err := service.CreateEvent(context.Background(), "auth0|owner", &models.CreateEventRequest{
+		Name:     "daily",
+		Schedule: "0 12 * * ? *",
+		Targets:  []models.EventTargetBinding{{Target: "orders"}},
+	})
+
+	assert.ErrorIs(t, err, ErrUnknownEventTarget)
+}
+
+func TestCreateEvent_InvalidInputTemplate(t *testing.T) {
+	resolve := func(bindings []models.EventTargetBinding) ([]scheduler.ScheduleTarget, error) {
+		return []scheduler.ScheduleTarget{{ID: "orders", Kind: scheduler.TargetKindLambda, Arn: "arn:aws:lambda:x", InputTemplate: bindings[0].InputTemplate}}, nil
+	}
+	service := NewEventService(&fakeEventRepository{}, &fakeScheduler{}, nil, resolve, nil)
+
+	_, err := service.CreateEvent(context.Background(), "auth0|owner", &models.CreateEventRequest{
+		Name:     "daily",
+		Schedule: "0 12 * * ? *",
+		Targets:  []models.EventTargetBinding{{Target: "orders", InputTemplate: `{"order":"<order_id>"}`}},
+	})
+
+	assert.ErrorIs(t, err, scheduler.ErrInvalidTarget)
+}
+
+func TestCreateEvent_PayloadChecks(t *testing.T) {
+	var gotKinds []string
+	validate := func(kinds []string, payload json.RawMessage) error {
+		gotKinds = kinds
+		return fmt.Errorf("%w: /qty: expected integer", ErrPayloadRejected)
+	}
+	service := NewEventService(&fakeEventRepository{}, &fakeScheduler{}, nil, nil, validate)
+
+	_, err := service.CreateEvent(context.Background(), "auth0|owner", &models.CreateEventRequest{
+		Name: "daily", Schedule: "0 12 * * ? *", Payload: json.RawMessage(`["not","an","object"]`),
+	})
+	assert.ErrorIs(t, err, ErrInvalidPayload)
+
+	_, err = service.CreateEvent(context.Background(), "auth0|owner", &models.CreateEventRequest{
+		Name: "daily", Schedule: "0 12 * * ? *", Payload: json.RawMessage(`{"qty":"many"}`),
+	})
+	assert.ErrorIs(t, err, ErrPayloadRejected)
+	assert.Equal(t, []string{scheduler.TargetKindLambda}, gotKinds)
+}
+
+func TestCreateEventIdempotent(t *testing.T) {
+	repo := &fakeEventRepository{}
+	sched := &fakeScheduler{}
+	service := NewEventService(repo, sched, nil, nil, nil)
+	request := &models.CreateEventRequest{Name: "daily", Schedule: "0 12 * * ? *", Payload: json.RawMessage(`{"a": 1}`)}
+
+	first, replayed, err := service.CreateEventIdempotent(context.Background(), "auth0|owner", "key-1", request)
+	assert.NoError(t, err)
+	assert.False(t, replayed)
+
+	// A retry, even with different whitespace, gets the same event back without scheduling again
+	retry := *request
+	retry.Payload = json.RawMessage(`{"a":1}`)
+	second, replayed, err := service.CreateEventIdempotent(context.Background(), "auth0|owner", "key-1", &retry)
+	assert.NoError(t, err)
+	assert.True(t, replayed)
+	assert.Equal(t, first.ID, second.ID)
+	assert.NoError(t, runScheduleSyncs(t, service, repo))
+	assert.Len(t, sched.created, 1)
+
+	changed := *request
+	changed.Schedule = "0 13 * * ? *"
+	_, _, err = service.CreateEventIdempotent(context.Background(), "auth0|owner", "key-1", &changed)
+	assert.ErrorIs(t, err, ErrIdempotencyKeyReused)
+
+	// Keys are per owner
+	_, replayed, err = service.CreateEventIdempotent(context.Background(), "auth0|other", "key-1", request)
+	assert.NoError(t, err)
+	assert.False(t, replayed)
+
+	_, _, err = service.CreateEventIdempotent(context.Background(), "auth0|owner", "bad\nkey", request)
+	assert.ErrorIs(t, err, ErrInvalidIdempotencyKey)
+}
+
+func TestHandleScheduleSync_KeepsExistingRuleWhenTargetsFail(t *testing.T) {
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		operation := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "AWSEvents.")
+		calls = append(calls, operation)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		if operation == "PutTargets" {
+			_, _ = io.WriteString(w, `{"FailedEntryCount":1,"FailedEntries":[{"TargetId":"t","ErrorCode":"ThrottlingException","ErrorMessage":"Rate exceeded"}]}`)
+			return
+		}
+		_, _ = io.WriteString(w, "{}")
+	}))
+	t.Cleanup(server.Close)
+	backend := scheduler.NewEventBridgeScheduler(eventbridge.New(eventbridge.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		Credentials:  aws.AnonymousCredentials{},
+		HTTPClient:   server.Client(),
+	}), "arn:aws:lambda:default")
+	repo := &fakeEventRepository{created: []*models.Event{{
+		ID: "1", OwnerID: "auth0|owner", Name: "daily", RuleName: "go-api-owner-1",
+		Schedule: "rate(1 hour)", State: models.EventStateDisabled,
+	}}}
+	service := NewEventService(repo, backend, nil, nil, nil)
+
+	err := service.HandleScheduleSync(context.Background(), json.RawMessage(scheduleSyncMessage("go-api-owner-1").Payload))
+
+	assert.ErrorContains(t, err, "Rate exceeded")
+	assert.Equal(t, []string{"PutRule", "PutTargets"}, calls)
+}
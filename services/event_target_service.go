@@ -0,0 +1,289 @@
+package services
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/repositories"
+	"github.com/dat1010/go-api/scheduler"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+var (
+	ErrEventTargetExists   = errors.New("event target already exists")
+	ErrEventTargetNotFound = errors.New("event target not found")
+	ErrEventTargetInUse    = errors.New("event target is used by scheduled events")
+	ErrInvalidEventTarget  = errors.New("invalid event target")
+	ErrUnknownEventTarget  = errors.New("unknown event target")
+
+	ErrEventTargetSchemaNotFound = errors.New("no schema is registered for that target kind")
+	ErrInvalidEventTargetSchema  = errors.New("invalid event target schema")
+	ErrPayloadRejected           = errors.New("payload does not match the target schema")
+)
+
+// Target names double as EventBridge target IDs, so they follow the same rules.
+var eventTargetNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// arnMarkers is the part of a target's ARN that identifies the service its kind needs.
+var arnMarkers = map[string]string{
+	scheduler.TargetKindLambda:         ":lambda:",
+	scheduler.TargetKindSQS:            ":sqs:",
+	scheduler.TargetKindSNS:            ":sns:",
+	scheduler.TargetKindAPIDestination: ":api-destination/",
+}
+
+type EventTargetService interface {
+	ListTargets() ([]models.EventTarget, error)
+	ListTargetSummaries() ([]models.EventTargetSummary, error)
+	CreateTarget(req *models.CreateEventTargetRequest) (*models.EventTarget, error)
+	DeleteTarget(name string) error
+	ResolveTargets(bindings []models.EventTargetBinding) ([]scheduler.ScheduleTarget, error)
+	ListSchemas() ([]models.EventTargetSchema, error)
+	PutSchema(kind string, req *models.PutEventTargetSchemaRequest) (*models.EventTargetSchema, error)
+	DeleteSchema(kind string) error
+	ValidatePayload(kinds []string, payload json.RawMessage) error
+}
+
+type eventTargetService struct {
+	repo repositories.EventTargetRepository
+}
+
+func NewEventTargetService(repo repositories.EventTargetRepository) EventTargetService {
+	return &eventTargetService{repo: repo}
+}
+
+func (s *eventTargetService) ListTargets() ([]models.EventTarget, error) {
+	targets, err := s.repo.List()
+	if err != nil {
+		return nil, err
+	}
+	if targets == nil {
+		targets = []models.EventTarget{}
+	}
+	return targets, nil
+}
+
+// ListTargetSummaries lists the targets without their ARNs, for users choosing one.
+func (s *eventTargetService) ListTargetSummaries() ([]models.EventTargetSummary, error) {
+	targets, err := s.repo.List()
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]models.EventTargetSummary, 0, len(targets))
+	for _, target := range targets {
+		summaries = append(summaries, models.EventTargetSummary{
+			Name:        target.Name,
+			Kind:        target.Kind,
+			Description: target.Description,
+		})
+	}
+	return summaries, nil
+}
+
+func (s *eventTargetService) CreateTarget(req *models.CreateEventTargetRequest) (*models.EventTarget, error) {
+	target := &models.EventTarget{
+		Name:        req.Name,
+		Kind:        req.Kind,
+		Arn:         req.Arn,
+		RoleArn:     req.RoleArn,
+		Description: req.Description,
+	}
+	if err := validateEventTarget(target); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(target); err != nil {
+		if repositories.IsUniqueViolation(err) {
+			return nil, ErrEventTargetExists
+		}
+		return nil, err
+	}
+	return target, nil
+}
+
+// DeleteTarget refuses to remove a target any event still sends to.
+func (s *eventTargetService) DeleteTarget(name string) error {
+	inUse, err := s.repo.IsInUse(name)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return ErrEventTargetInUse
+	}
+	if err := s.repo.Delete(name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrEventTargetNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ResolveTargets looks up the registered target behind each binding.
+func (s *eventTargetService) ResolveTargets(bindings []models.EventTargetBinding) ([]scheduler.ScheduleTarget, error) {
+	if len(bindings) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(bindings))
+	for _, binding := range bindings {
+		names = append(names, binding.Target)
+	}
+	found, err := s.repo.GetByNames(names)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]models.EventTarget, len(found))
+	for _, target := range found {
+		byName[target.Name] = target
+	}
+
+	targets := make([]scheduler.ScheduleTarget, 0, len(bindings))
+	var unknown []string
+	for _, binding := range bindings {
+		target, ok := byName[binding.Target]
+		if !ok {
+			unknown = append(unknown, binding.Target)
+			continue
+		}
+		targets = append(targets, scheduler.ScheduleTarget{
+			ID:            target.Name,
+			Kind:          target.Kind,
+			Arn:           target.Arn,
+			RoleArn:       target.RoleArn,
+			InputTemplate: binding.InputTemplate,
+		})
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEventTarget, strings.Join(unknown, ", "))
+	}
+	return targets, nil
+}
+
+func validateEventTarget(target *models.EventTarget) error {
+	if !eventTargetNamePattern.MatchString(target.Name) {
+		return fmt.Errorf("%w: name may only use letters, digits, '.', '-' and '_' (at most 64)", ErrInvalidEventTarget)
+	}
+	marker, ok := arnMarkers[target.Kind]
+	if !ok {
+		return fmt.Errorf("%w: kind must be one of %s", ErrInvalidEventTarget, strings.Join(scheduler.TargetKinds, ", "))
+	}
+	if !strings.HasPrefix(target.Arn, "arn:") || !strings.Contains(target.Arn, marker) {
+		return fmt.Errorf("%w: arn is not a %s ARN", ErrInvalidEventTarget, target.Kind)
+	}
+	if target.RoleArn != "" && (!strings.HasPrefix(target.RoleArn, "arn:") || !strings.Contains(target.RoleArn, ":iam::")) {
+		return fmt.Errorf("%w: role_arn is not an IAM role ARN", ErrInvalidEventTarget)
+	}
+	if target.Kind == scheduler.TargetKindAPIDestination && target.RoleArn == "" {
+		return fmt.Errorf("%w: API destinations need a role_arn", ErrInvalidEventTarget)
+	}
+	return nil
+}
+
+func (s *eventTargetService) ListSchemas() ([]models.EventTargetSchema, error) {
+	schemas, err := s.repo.ListSchemas()
+	if err != nil {
+		return nil, err
+	}
+	if schemas == nil {
+		schemas = []models.EventTargetSchema{}
+	}
+	return schemas, nil
+}
+
+// PutSchema registers the schema payloads must match to reach targets of the
+// kind. Events already stored are not rechecked until they are next updated.
+func (s *eventTargetService) PutSchema(kind string, req *models.PutEventTargetSchemaRequest) (*models.EventTargetSchema, error) {
+	if !scheduler.IsTargetKind(kind) {
+		return nil, fmt.Errorf("%w: kind must be one of %s", ErrInvalidEventTargetSchema, strings.Join(scheduler.TargetKinds, ", "))
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, req.Schema); err != nil || !bytes.HasPrefix(compact.Bytes(), []byte("{")) {
+		return nil, fmt.Errorf("%w: schema must be a JSON object", ErrInvalidEventTargetSchema)
+	}
+	if _, err := compileSchema(kind, compact.Bytes()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEventTargetSchema, err)
+	}
+
+	schema := &models.EventTargetSchema{Kind: kind, Schema: models.JSONObject(compact.Bytes())}
+	if err := s.repo.PutSchema(schema); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+func (s *eventTargetService) DeleteSchema(kind string) error {
+	if err := s.repo.DeleteSchema(kind); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrEventTargetSchemaNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ValidatePayload checks the payload against the schema of each kind that
+// has one. Kinds without a schema accept any JSON object.
+func (s *eventTargetService) ValidatePayload(kinds []string, payload json.RawMessage) error {
+	schemas, err := s.repo.GetSchemas(kinds)
+	if err != nil || len(schemas) == 0 {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return fmt.Errorf("%w: %v", ErrPayloadRejected, err)
+	}
+
+	for _, schema := range schemas {
+		compiled, err := compileSchema(schema.Kind, schema.Schema)
+		if err != nil {
+			return fmt.Errorf("schema for %s targets: %w", schema.Kind, err)
+		}
+		var invalid *jsonschema.ValidationError
+		if err := compiled.Validate(document); errors.As(err, &invalid) {
+			return fmt.Errorf("%w for %s targets: %s", ErrPayloadRejected, schema.Kind, strings.Join(schemaViolations(invalid), "; "))
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compileSchema compiles a registered schema. Schemas may only refer to
+// themselves: nothing is loaded from files or the network.
+func compileSchema(kind string, schema []byte) (*jsonschema.Schema, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.LoadURL = func(url string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("schemas cannot load %s", url)
+	}
+	url := "event-target-schemas/" + kind + ".json"
+	if err := compiler.AddResource(url, bytes.NewReader(schema)); err != nil {
+		return nil, err
+	}
+	return compiler.Compile(url)
+}
+
+// schemaViolations lists the innermost errors, which name the offending field.
+func schemaViolations(err *jsonschema.ValidationError) []string {
+	if len(err.Causes) == 0 {
+		location := err.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+		return []string{location + ": " + err.Message}
+	}
+	var violations []string
+	for _, cause := range err.Causes {
+		violations = append(violations, schemaViolations(cause)...)
+	}
+	return violations
+}
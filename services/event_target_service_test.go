@@ -0,0 +1,170 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/scheduler"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeEventTargetRepository struct {
+	targets []models.EventTarget
+	inUse   map[string]bool
+	schemas map[string]models.EventTargetSchema
+}
+
+func (r *fakeEventTargetRepository) List() ([]models.EventTarget, error) {
+	return r.targets, nil
+}
+
+func (r *fakeEventTargetRepository) GetByNames(names []string) ([]models.EventTarget, error) {
+	var found []models.EventTarget
+	for _, target := range r.targets {
+		for _, name := range names {
+			if target.Name == name {
+				found = append(found, target)
+				break
+			}
+		}
+	}
+	return found, nil
+}
+
+func (r *fakeEventTargetRepository) Create(target *models.EventTarget) error {
+	r.targets = append(r.targets, *target)
+	return nil
+}
+
+func (r *fakeEventTargetRepository) IsInUse(name string) (bool, error) {
+	return r.inUse[name], nil
+}
+
+func (r *fakeEventTargetRepository) Delete(name string) error {
+	for i, target := range r.targets {
+		if target.Name == name {
+			r.targets = append(r.targets[:i], r.targets[i+1:]...)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (r *fakeEventTargetRepository) ListSchemas() ([]models.EventTargetSchema, error) {
+	var schemas []models.EventTargetSchema
+	for _, schema := range r.schemas {
+		schemas = append(schemas, schema)
+	}
+	return schemas, nil
+}
+
+func (r *fakeEventTargetRepository) GetSchemas(kinds []string) ([]models.EventTargetSchema, error) {
+	var schemas []models.EventTargetSchema
+	for _, kind := range kinds {
+		if schema, ok := r.schemas[kind]; ok {
+			schemas = append(schemas, schema)
+		}
+	}
+	return schemas, nil
+}
+
+func (r *fakeEventTargetRepository) PutSchema(schema *models.EventTargetSchema) error {
+	if r.schemas == nil {
+		r.schemas = make(map[string]models.EventTargetSchema)
+	}
+	r.schemas[schema.Kind] = *schema
+	return nil
+}
+
+func (r *fakeEventTargetRepository) DeleteSchema(kind string) error {
+	if _, ok := r.schemas[kind]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(r.schemas, kind)
+	return nil
+}
+
+func TestCreateTarget_Validation(t *testing.T) {
+	service := NewEventTargetService(&fakeEventTargetRepository{})
+
+	cases := []models.CreateEventTargetRequest{
+		{Name: "bad name", Kind: "sqs", Arn: "arn:aws:sqs:us-east-1:1:orders"},
+		{Name: "orders", Kind: "kinesis", Arn: "arn:aws:kinesis:us-east-1:1:stream/orders"},
+		{Name: "orders", Kind: "sqs", Arn: "arn:aws:sns:us-east-1:1:orders"},
+		{Name: "hook", Kind: "api_destination", Arn: "arn:aws:events:us-east-1:1:api-destination/hook/abc"},
+	}
+	for _, req := range cases {
+		_, err := service.CreateTarget(&req)
+		assert.ErrorIs(t, err, ErrInvalidEventTarget, req.Name)
+	}
+
+	target, err := service.CreateTarget(&models.CreateEventTargetRequest{
+		Name:    "hook",
+		Kind:    scheduler.TargetKindAPIDestination,
+		Arn:     "arn:aws:events:us-east-1:1:api-destination/hook/abc",
+		RoleArn: "arn:aws:iam::1:role/events-invoke",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "hook", target.Name)
+}
+
+func TestResolveTargets_UnknownTarget(t *testing.T) {
+	repo := &fakeEventTargetRepository{targets: []models.EventTarget{{Name: "orders", Kind: "sqs", Arn: "arn:aws:sqs:us-east-1:1:orders"}}}
+	service := NewEventTargetService(repo)
+
+	targets, err := service.ResolveTargets([]models.EventTargetBinding{{Target: "orders", InputTemplate: `{"id":"<id>"}`}})
+	assert.NoError(t, err)
+	assert.Equal(t, []scheduler.ScheduleTarget{{ID: "orders", Kind: "sqs", Arn: "arn:aws:sqs:us-east-1:1:orders", InputTemplate: `{"id":"<id>"}`}}, targets)
+
+	_, err = service.ResolveTargets([]models.EventTargetBinding{{Target: "orders"}, {Target: "missing"}})
+	assert.ErrorIs(t, err, ErrUnknownEventTarget)
+	assert.Contains(t, err.Error(), "missing")
+}
+
+func TestDeleteTarget_InUse(t *testing.T) {
+	repo := &fakeEventTargetRepository{
+		targets: []models.EventTarget{{Name: "orders"}},
+		inUse:   map[string]bool{"orders": true},
+	}
+	service := NewEventTargetService(repo)
+
+	assert.ErrorIs(t, service.DeleteTarget("orders"), ErrEventTargetInUse)
+	assert.ErrorIs(t, service.DeleteTarget("missing"), ErrEventTargetNotFound)
+	assert.Len(t, repo.targets, 1)
+}
+
+func TestPutSchema_Validation(t *testing.T) {
+	service := NewEventTargetService(&fakeEventTargetRepository{})
+
+	_, err := service.PutSchema("webhook", &models.PutEventTargetSchemaRequest{Schema: json.RawMessage(`{}`)})
+	assert.ErrorIs(t, err, ErrInvalidEventTargetSchema)
+
+	_, err = service.PutSchema(scheduler.TargetKindSQS, &models.PutEventTargetSchemaRequest{Schema: json.RawMessage(`{"type": 7}`)})
+	assert.ErrorIs(t, err, ErrInvalidEventTargetSchema)
+
+	// Schemas cannot pull in files or remote documents
+	_, err = service.PutSchema(scheduler.TargetKindSQS, &models.PutEventTargetSchemaRequest{Schema: json.RawMessage(`{"$ref": "file:///etc/passwd"}`)})
+	assert.ErrorIs(t, err, ErrInvalidEventTargetSchema)
+
+	assert.ErrorIs(t, service.DeleteSchema(scheduler.TargetKindSQS), ErrEventTargetSchemaNotFound)
+}
+
+func TestValidatePayload(t *testing.T) {
+	service := NewEventTargetService(&fakeEventTargetRepository{})
+	_, err := service.PutSchema(scheduler.TargetKindSQS, &models.PutEventTargetSchemaRequest{Schema: json.RawMessage(`{
+		"type": "object",
+		"required": ["order"],
+		"properties": {"order": {"type": "object", "properties": {"qty": {"type": "integer", "minimum": 1}}}}
+	}`)})
+	assert.NoError(t, err)
+
+	assert.NoError(t, service.ValidatePayload([]string{scheduler.TargetKindSQS}, json.RawMessage(`{"order":{"qty":3}}`)))
+	// Kinds without a schema take anything
+	assert.NoError(t, service.ValidatePayload([]string{scheduler.TargetKindLambda}, json.RawMessage(`{"free":true}`)))
+
+	err = service.ValidatePayload([]string{scheduler.TargetKindLambda, scheduler.TargetKindSQS}, json.RawMessage(`{"order":{"qty":0}}`))
+	assert.ErrorIs(t, err, ErrPayloadRejected)
+	assert.Contains(t, err.Error(), "/order/qty")
+}
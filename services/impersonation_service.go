@@ -0,0 +1,146 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/repositories"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrImpersonationForbidden = errors.New("cannot impersonate yourself or another superadmin")
+	ErrImpersonationInactive  = errors.New("impersonation session is no longer active")
+)
+
+const (
+	DefaultImpersonationDuration = 15 * time.Minute
+	MaxImpersonationDuration     = time.Hour
+)
+
+type ImpersonationService interface {
+	StartImpersonation(actor, target, reason string, duration time.Duration) (*models.ImpersonationSession, string, error)
+	ResolveSession(token, actor string) (*models.ImpersonationSession, error)
+	EndImpersonation(token, actor string) (*models.ImpersonationSession, error)
+}
+
+type impersonationService struct {
+	repo        repositories.ImpersonationRepository
+	userService UserService
+}
+
+func NewImpersonationService(repo repositories.ImpersonationRepository, userService UserService) ImpersonationService {
+	return &impersonationService{repo: repo, userService: userService}
+}
+
+// StartImpersonation opens a short-lived session for actor to act as target.
+// The returned token is only ever stored hashed.
+func (s *impersonationService) StartImpersonation(actor, target, reason string, duration time.Duration) (*models.ImpersonationSession, string, error) {
+	if actor == target {
+		return nil, "", ErrImpersonationForbidden
+	}
+	if duration <= 0 {
+		duration = DefaultImpersonationDuration
+	}
+	if duration > MaxImpersonationDuration {
+		duration = MaxImpersonationDuration
+	}
+
+	role, err := s.userService.GetUserRole(target)
+	if err != nil {
+		return nil, "", err
+	}
+	if role == "" {
+		return nil, "", ErrUserNotFound
+	}
+	if role == "superadmin" {
+		return nil, "", ErrImpersonationForbidden
+	}
+
+	token, err := newImpersonationToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	session := &models.ImpersonationSession{
+		ID:                uuid.New().String(),
+		ActorAuth0UserID:  actor,
+		TargetAuth0UserID: target,
+		Reason:            reason,
+		ExpiresAt:         time.Now().Add(duration),
+	}
+	if err := s.repo.Create(session, hashImpersonationToken(token)); err != nil {
+		return nil, "", err
+	}
+	return session, token, nil
+}
+
+// ResolveSession returns the active session for token, which must belong to
+// actor. The session is ended once actor is no longer an active superadmin.
+func (s *impersonationService) ResolveSession(token, actor string) (*models.ImpersonationSession, error) {
+	session, err := s.repo.GetActiveByTokenHash(hashImpersonationToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrImpersonationInactive
+		}
+		return nil, err
+	}
+	if session.ActorAuth0UserID != actor {
+		return nil, ErrImpersonationInactive
+	}
+
+	// The actor may have been demoted or suspended since the session began
+	allowed, err := s.actorMayImpersonate(actor)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		if err := s.repo.End(session.ID); err != nil {
+			return nil, err
+		}
+		return nil, ErrImpersonationInactive
+	}
+	return session, nil
+}
+
+// actorMayImpersonate reports whether actor is still an active superadmin.
+func (s *impersonationService) actorMayImpersonate(actor string) (bool, error) {
+	superadmin, err := s.userService.IsUserInRole(actor, "superadmin")
+	if err != nil || !superadmin {
+		return false, err
+	}
+	status, err := s.userService.GetUserStatus(actor)
+	if err != nil {
+		return false, err
+	}
+	return status.Status == models.UserStatusActive, nil
+}
+
+func (s *impersonationService) EndImpersonation(token, actor string) (*models.ImpersonationSession, error) {
+	session, err := s.ResolveSession(token, actor)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.End(session.ID); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func newImpersonationToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func hashImpersonationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
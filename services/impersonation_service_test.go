@@ -0,0 +1,79 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeImpersonationRepository struct {
+	session *models.ImpersonationSession
+	ended   []string
+}
+
+func (r *fakeImpersonationRepository) Create(session *models.ImpersonationSession, tokenHash string) error {
+	r.session = session
+	return nil
+}
+
+func (r *fakeImpersonationRepository) GetActiveByTokenHash(tokenHash string) (*models.ImpersonationSession, error) {
+	return r.session, nil
+}
+
+func (r *fakeImpersonationRepository) End(id string) error {
+	r.ended = append(r.ended, id)
+	return nil
+}
+
+type fakeActorUserService struct {
+	UserService
+	roles    map[string]string
+	statuses map[string]string
+}
+
+func (s *fakeActorUserService) IsUserInRole(auth0UserID, roleName string) (bool, error) {
+	return s.roles[auth0UserID] == roleName, nil
+}
+
+func (s *fakeActorUserService) GetUserStatus(auth0UserID string) (*models.UserStatus, error) {
+	status := s.statuses[auth0UserID]
+	if status == "" {
+		status = models.UserStatusActive
+	}
+	return &models.UserStatus{Status: status}, nil
+}
+
+func TestResolveSession_EndsSessionWhenActorLosesAccess(t *testing.T) {
+	cases := map[string]*fakeActorUserService{
+		"still superadmin": {roles: map[string]string{"auth0|admin": "superadmin"}},
+		"demoted":          {roles: map[string]string{"auth0|admin": "member"}},
+		"banned": {
+			roles:    map[string]string{"auth0|admin": "superadmin"},
+			statuses: map[string]string{"auth0|admin": models.UserStatusBanned},
+		},
+	}
+	for name, users := range cases {
+		t.Run(name, func(t *testing.T) {
+			repo := &fakeImpersonationRepository{session: &models.ImpersonationSession{
+				ID:                "session-1",
+				ActorAuth0UserID:  "auth0|admin",
+				TargetAuth0UserID: "auth0|member",
+				ExpiresAt:         time.Now().Add(time.Hour),
+			}}
+			service := NewImpersonationService(repo, users)
+
+			session, err := service.ResolveSession("token", "auth0|admin")
+
+			if name == "still superadmin" {
+				assert.NoError(t, err)
+				assert.Equal(t, "auth0|member", session.TargetAuth0UserID)
+				assert.Empty(t, repo.ended)
+				return
+			}
+			assert.ErrorIs(t, err, ErrImpersonationInactive)
+			assert.Equal(t, []string{"session-1"}, repo.ended)
+		})
+	}
+}
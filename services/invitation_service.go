@@ -0,0 +1,162 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/repositories"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvitationsDisabled     = errors.New("invitations are not configured")
+	ErrInvitationInvalid       = errors.New("invitation token is invalid")
+	ErrInvitationUnavailable   = errors.New("invitation has already been used, revoked or expired")
+	ErrInvitationEmailMismatch = errors.New("invitation email does not match a verified login email")
+)
+
+const DefaultInvitationTTL = 7 * 24 * time.Hour
+
+type InvitationService interface {
+	CreateInvitation(email, roleName, invitedBy string) (*models.Invitation, string, error)
+	ListInvitations() ([]models.Invitation, error)
+	RevokeInvitation(id string) error
+	VerifyToken(token string) (string, error)
+	RedeemInvitation(token, auth0UserID, email string, emailVerified bool) (*models.Invitation, error)
+}
+
+type invitationService struct {
+	repo        repositories.InvitationRepository
+	userService UserService
+	secret      []byte
+	acceptURL   string
+	ttl         time.Duration
+}
+
+// NewInvitationService creates an invitation service. Invite links point at
+// acceptURL and carry a token signed with secret; an empty secret disables invitations.
+func NewInvitationService(repo repositories.InvitationRepository, userService UserService, secret []byte, acceptURL string, ttl time.Duration) InvitationService {
+	if ttl <= 0 {
+		ttl = DefaultInvitationTTL
+	}
+	return &invitationService{
+		repo:        repo,
+		userService: userService,
+		secret:      secret,
+		acceptURL:   acceptURL,
+		ttl:         ttl,
+	}
+}
+
+func (s *invitationService) CreateInvitation(email, roleName, invitedBy string) (*models.Invitation, string, error) {
+	if len(s.secret) == 0 {
+		return nil, "", ErrInvitationsDisabled
+	}
+
+	invitation := &models.Invitation{
+		ID:        uuid.New().String(),
+		Email:     strings.ToLower(strings.TrimSpace(email)),
+		Role:      roleName,
+		InvitedBy: invitedBy,
+		ExpiresAt: time.Now().Add(s.ttl),
+	}
+	if err := s.repo.Create(invitation); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, "", ErrRoleNotFound
+		}
+		return nil, "", err
+	}
+
+	return invitation, s.inviteURL(s.signToken(invitation.ID)), nil
+}
+
+func (s *invitationService) ListInvitations() ([]models.Invitation, error) {
+	return s.repo.List()
+}
+
+func (s *invitationService) RevokeInvitation(id string) error {
+	if err := s.repo.Revoke(id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvitationUnavailable
+		}
+		return err
+	}
+	return nil
+}
+
+// VerifyToken checks the token signature and returns the invitation ID it carries.
+func (s *invitationService) VerifyToken(token string) (string, error) {
+	if len(s.secret) == 0 {
+		return "", ErrInvitationsDisabled
+	}
+
+	id, signature, ok := strings.Cut(token, ".")
+	if !ok || id == "" {
+		return "", ErrInvitationInvalid
+	}
+	expected := s.signToken(id)
+	if !hmac.Equal([]byte(expected), []byte(id+"."+signature)) {
+		return "", ErrInvitationInvalid
+	}
+	return id, nil
+}
+
+// RedeemInvitation consumes the invitation for the logged-in user and assigns
+// its role. The login must carry a verified email matching the invited address.
+func (s *invitationService) RedeemInvitation(token, auth0UserID, email string, emailVerified bool) (*models.Invitation, error) {
+	id, err := s.VerifyToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation, err := s.repo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvitationInvalid
+		}
+		return nil, err
+	}
+	if !emailVerified || !strings.EqualFold(strings.TrimSpace(email), invitation.Email) {
+		return nil, ErrInvitationEmailMismatch
+	}
+
+	if err := s.repo.Claim(id, auth0UserID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvitationUnavailable
+		}
+		return nil, err
+	}
+
+	// The email goes first: it is the login's own verified address, so it
+	// can stay if the role fails, and the claim is released on either failure
+	if err := s.userService.SetUserEmail(auth0UserID, invitation.Email); err != nil {
+		_ = s.repo.Release(id)
+		return nil, err
+	}
+	if err := s.userService.SetUserRole(auth0UserID, invitation.Role); err != nil {
+		_ = s.repo.Release(id)
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (s *invitationService) signToken(id string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("invitation:" + id))
+	return id + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *invitationService) inviteURL(token string) string {
+	if s.acceptURL == "" {
+		return ""
+	}
+	return s.acceptURL + "?token=" + url.QueryEscape(token)
+}
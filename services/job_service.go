@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/repositories"
+)
+
+var (
+	ErrJobNotFound      = errors.New("no dead job with that ID")
+	ErrInvalidJobFilter = errors.New("invalid job filter")
+)
+
+const (
+	// DefaultJobMaxAttempts is how often a job runs before it is dead-lettered.
+	DefaultJobMaxAttempts = 8
+
+	// jobTimeout bounds one run of a job. It is well inside jobLease, after
+	// which a running job is assumed abandoned and handed to another worker.
+	jobTimeout = 2 * time.Minute
+	jobLease   = 5 * time.Minute
+
+	jobBackoffBase = 10 * time.Second
+	jobBackoffMax  = time.Hour
+
+	outboxBatchSize = 100
+	// jobRetention is how long succeeded jobs stay visible to admins.
+	jobRetention = 7 * 24 * time.Hour
+)
+
+// JobHandler performs one job. An error, or a panic, retries the job later.
+type JobHandler func(ctx context.Context, payload json.RawMessage) error
+
+// JobEnqueuer adds jobs that follow from other jobs.
+type JobEnqueuer func(messages ...models.OutboxMessage) error
+
+type JobService interface {
+	Handle(kind string, handler JobHandler)
+	Enqueue(messages ...models.OutboxMessage) error
+	RunNext() (bool, error)
+	RunWorkers(ctx context.Context, workers int, poll time.Duration)
+	RunOutboxRelay(ctx context.Context, interval time.Duration)
+	ListJobs(status, kind string, page, pageSize int) (*models.JobPage, error)
+	RetryJob(id int64) error
+}
+
+type jobService struct {
+	repo     repositories.JobRepository
+	handlers map[string]JobHandler
+}
+
+// NewJobService runs jobs from repo. Register every handler with Handle
+// before starting workers.
+func NewJobService(repo repositories.JobRepository) JobService {
+	return &jobService{repo: repo, handlers: make(map[string]JobHandler)}
+}
+
+func (s *jobService) Handle(kind string, handler JobHandler) {
+	s.handlers[kind] = handler
+}
+
+func (s *jobService) Enqueue(messages ...models.OutboxMessage) error {
+	return s.repo.Enqueue(DefaultJobMaxAttempts, messages...)
+}
+
+// RunNext claims and runs one due job, reporting whether there was one. The
+// job runs under its own timeout rather than the caller's context, so a
+// shutdown lets it finish.
+func (s *jobService) RunNext() (bool, error) {
+	job, err := s.repo.Claim(jobLease)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	runErr := s.run(job)
+	if runErr == nil {
+		if err := s.repo.Complete(job); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return true, err
+		}
+		return true, nil
+	}
+
+	status, err := s.repo.Fail(job, runErr.Error(), time.Now().Add(jobBackoff(job.Attempts)))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return true, err
+	}
+	if status == models.JobStatusDead {
+		log.Printf("jobs: %s job %d dead-lettered after %d attempts: %v", job.Kind, job.ID, job.Attempts, runErr)
+	}
+	return true, nil
+}
+
+func (s *jobService) run(job *models.Job) (err error) {
+	handler, ok := s.handlers[job.Kind]
+	if !ok {
+		return fmt.Errorf("no handler for job kind %q", job.Kind)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), jobTimeout)
+	defer cancel()
+	return handler(ctx, json.RawMessage(job.Payload))
+}
+
+// jobBackoff doubles the wait after each attempt up to jobBackoffMax, adding
+// up to a fifth again as jitter so failed jobs don't retry in lockstep.
+func jobBackoff(attempts int) time.Duration {
+	wait := jobBackoffBase
+	for i := 1; i < attempts && wait < jobBackoffMax; i++ {
+		wait *= 2
+	}
+	if wait > jobBackoffMax {
+		wait = jobBackoffMax
+	}
+	return wait + time.Duration(rand.Int63n(int64(wait/5)+1))
+}
+
+// RunWorkers runs jobs on the given number of workers, each checking for
+// due jobs every poll while idle. Once ctx is done it returns after the jobs
+// in progress finish.
+func (s *jobService) RunWorkers(ctx context.Context, workers int, poll time.Duration) {
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				worked, err := s.RunNext()
+				if err != nil {
+					log.Printf("jobs: %v", err)
+				}
+				if worked && err == nil {
+					continue
+				}
+				select {
+				case <-ctx.Done():
+				case <-time.After(poll):
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// RunOutboxRelay moves outbox messages into jobs every interval until ctx is
+// done, and clears out succeeded jobs past jobRetention.
+func (s *jobService) RunOutboxRelay(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastPrune time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for {
+				moved, err := s.repo.RelayOutbox(outboxBatchSize, DefaultJobMaxAttempts)
+				if err != nil {
+					log.Printf("jobs: outbox relay: %v", err)
+				}
+				if err != nil || moved < outboxBatchSize {
+					break
+				}
+			}
+			if time.Since(lastPrune) > time.Hour {
+				lastPrune = time.Now()
+				if _, err := s.repo.DeleteFinished(lastPrune.Add(-jobRetention)); err != nil {
+					log.Printf("jobs: prune: %v", err)
+				}
+			}
+		}
+	}
+}
+
+// ListJobs pages through jobs newest first, optionally only those with a
+// status or kind.
+func (s *jobService) ListJobs(status, kind string, page, pageSize int) (*models.JobPage, error) {
+	switch status {
+	case "", models.JobStatusPending, models.JobStatusRunning, models.JobStatusSucceeded, models.JobStatusDead:
+	default:
+		return nil, fmt.Errorf("%w: status must be pending, running, succeeded or dead", ErrInvalidJobFilter)
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultEventPageSize
+	}
+	if pageSize > maxEventPageSize {
+		pageSize = maxEventPageSize
+	}
+
+	total, err := s.repo.Count(status, kind)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.repo.ListPage(status, kind, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.Job{}
+	}
+
+	return &models.JobPage{
+		Items:    items,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// RetryJob requeues a dead job with fresh attempts.
+func (s *jobService) RetryJob(id int64) error {
+	err := s.repo.Retry(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrJobNotFound
+	}
+	return err
+}
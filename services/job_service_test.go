@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type failedJob struct {
+	id        int64
+	errorText string
+	retryAt   time.Time
+}
+
+// fakeJobRepository hands out queued jobs in order and records outcomes.
+type fakeJobRepository struct {
+	queue     []*models.Job
+	completed []int64
+	failed    []failedJob
+}
+
+func (r *fakeJobRepository) Enqueue(maxAttempts int, messages ...models.OutboxMessage) error {
+	for _, message := range messages {
+		r.queue = append(r.queue, &models.Job{
+			ID:          int64(len(r.queue) + 1),
+			Kind:        message.Kind,
+			Payload:     message.Payload,
+			Status:      models.JobStatusPending,
+			MaxAttempts: maxAttempts,
+		})
+	}
+	return nil
+}
+
+func (r *fakeJobRepository) RelayOutbox(limit, maxAttempts int) (int, error) {
+	return 0, nil
+}
+
+func (r *fakeJobRepository) Claim(lease time.Duration) (*models.Job, error) {
+	for _, job := range r.queue {
+		if job.Status == models.JobStatusPending {
+			now := time.Now()
+			job.Status = models.JobStatusRunning
+			job.Attempts++
+			job.LockedAt = &now
+			return job, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeJobRepository) Complete(job *models.Job) error {
+	job.Status = models.JobStatusSucceeded
+	r.completed = append(r.completed, job.ID)
+	return nil
+}
+
+func (r *fakeJobRepository) Fail(job *models.Job, errorText string, retryAt time.Time) (string, error) {
+	r.failed = append(r.failed, failedJob{job.ID, errorText, retryAt})
+	job.Status = models.JobStatusPending
+	if job.Attempts >= job.MaxAttempts {
+		job.Status = models.JobStatusDead
+	}
+	return job.Status, nil
+}
+
+func (r *fakeJobRepository) Retry(id int64) error {
+	for _, job := range r.queue {
+		if job.ID == id && job.Status == models.JobStatusDead {
+			job.Status = models.JobStatusPending
+			job.Attempts = 0
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (r *fakeJobRepository) DeleteFinished(before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (r *fakeJobRepository) ListPage(status, kind string, limit, offset int) ([]models.Job, error) {
+	return nil, nil
+}
+
+func (r *fakeJobRepository) Count(status, kind string) (int, error) {
+	return 0, nil
+}
+
+func TestRunNext_CompletesSuccessfulJob(t *testing.T) {
+	repo := &fakeJobRepository{}
+	service := NewJobService(repo)
+	var got string
+	service.Handle("greet", func(ctx context.Context, payload json.RawMessage) error {
+		got = string(payload)
+		return nil
+	})
+	assert.NoError(t, service.Enqueue(models.OutboxMessage{Kind: "greet", Payload: models.JSONObject(`{"name":"a"}`)}))
+
+	worked, err := service.RunNext()
+
+	assert.True(t, worked)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name":"a"}`, got)
+	assert.Equal(t, []int64{1}, repo.completed)
+
+	worked, err = service.RunNext()
+	assert.False(t, worked)
+	assert.NoError(t, err)
+}
+
+func TestRunNext_RetriesThenDeadLetters(t *testing.T) {
+	repo := &fakeJobRepository{}
+	service := NewJobService(repo)
+	service.Handle("flaky", func(ctx context.Context, payload json.RawMessage) error {
+		return errors.New("endpoint down")
+	})
+	assert.NoError(t, repo.Enqueue(2, models.OutboxMessage{Kind: "flaky"}))
+
+	before := time.Now()
+	_, err := service.RunNext()
+	assert.NoError(t, err)
+	assert.Equal(t, models.JobStatusPending, repo.queue[0].Status)
+	if assert.Len(t, repo.failed, 1) {
+		assert.Equal(t, "endpoint down", repo.failed[0].errorText)
+		assert.True(t, repo.failed[0].retryAt.After(before.Add(jobBackoffBase-time.Second)))
+	}
+
+	_, err = service.RunNext()
+	assert.NoError(t, err)
+	assert.Equal(t, models.JobStatusDead, repo.queue[0].Status)
+
+	assert.NoError(t, service.RetryJob(1))
+	assert.Equal(t, models.JobStatusPending, repo.queue[0].Status)
+	assert.ErrorIs(t, service.RetryJob(1), ErrJobNotFound)
+}
+
+func TestRunNext_FailsUnknownAndPanickingJobs(t *testing.T) {
+	repo := &fakeJobRepository{}
+	service := NewJobService(repo)
+	service.Handle("explode", func(ctx context.Context, payload json.RawMessage) error {
+		panic("boom")
+	})
+	assert.NoError(t, repo.Enqueue(1, models.OutboxMessage{Kind: "mystery"}, models.OutboxMessage{Kind: "explode"}))
+
+	_, err := service.RunNext()
+	assert.NoError(t, err)
+	_, err = service.RunNext()
+	assert.NoError(t, err)
+
+	if assert.Len(t, repo.failed, 2) {
+		assert.Contains(t, repo.failed[0].errorText, `no handler for job kind "mystery"`)
+		assert.Contains(t, repo.failed[1].errorText, "panicked: boom")
+	}
+}
+
+func TestJobBackoff(t *testing.T) {
+	assert.GreaterOrEqual(t, jobBackoff(1), jobBackoffBase)
+	assert.LessOrEqual(t, jobBackoff(1), jobBackoffBase*6/5)
+	assert.GreaterOrEqual(t, jobBackoff(3), 4*jobBackoffBase)
+	assert.LessOrEqual(t, jobBackoff(50), jobBackoffMax*6/5)
+}
+
+func TestRunWorkers_StopsWhenContextDone(t *testing.T) {
+	service := NewJobService(&fakeJobRepository{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		service.RunWorkers(ctx, 2, time.Millisecond)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("workers did not stop")
+	}
+}
+
+func TestListJobs_RejectsUnknownStatus(t *testing.T) {
+	service := NewJobService(&fakeJobRepository{})
+
+	_, err := service.ListJobs("exploded", "", 1, 20)
+	assert.ErrorIs(t, err, ErrInvalidJobFilter)
+
+	page, err := service.ListJobs(models.JobStatusDead, "", 0, 0)
+	assert.NoError(t, err)
+	assert.NotNil(t, page.Items)
+	assert.Equal(t, 1, page.Page)
+}
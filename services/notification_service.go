@@ -0,0 +1,299 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/notify"
+	"github.com/dat1010/go-api/repositories"
+)
+
+var (
+	ErrNotificationPreferenceNotFound = errors.New("notification preference not found")
+	ErrInvalidNotificationPreference  = errors.New("invalid notification preference")
+	ErrNotificationChannelUnavailable = errors.New("notification channel is not configured")
+	ErrNotificationDeliveryFailed     = errors.New("notification could not be delivered")
+)
+
+const maxNotificationText = 500
+
+type NotificationService interface {
+	ListPreferences(auth0UserID string) ([]models.NotificationPreference, error)
+	PutPreference(auth0UserID, channel string, req *models.PutNotificationPreferenceRequest) (*models.NotificationPreference, error)
+	DeletePreference(auth0UserID, channel string) error
+	SendTest(ctx context.Context, auth0UserID, channel string) error
+	HandlePostCreated(ctx context.Context, payload json.RawMessage) error
+	HandleEventFailed(ctx context.Context, payload json.RawMessage) error
+	HandleDeliver(ctx context.Context, payload json.RawMessage) error
+}
+
+type notificationService struct {
+	repo      repositories.NotificationRepository
+	notifiers map[string]notify.Notifier
+	enqueue   JobEnqueuer
+}
+
+// NewNotificationService delivers through notifiers, keyed by channel.
+// Channels without a notifier cannot be chosen. Notifications go out as
+// jobs: the outbox announces what happened, and its handlers enqueue one
+// delivery per recipient.
+func NewNotificationService(repo repositories.NotificationRepository, notifiers map[string]notify.Notifier, enqueue JobEnqueuer) NotificationService {
+	return &notificationService{repo: repo, notifiers: notifiers, enqueue: enqueue}
+}
+
+func (s *notificationService) ListPreferences(auth0UserID string) ([]models.NotificationPreference, error) {
+	prefs, err := s.repo.ListByUser(auth0UserID)
+	if err != nil {
+		return nil, err
+	}
+	if prefs == nil {
+		prefs = []models.NotificationPreference{}
+	}
+	return prefs, nil
+}
+
+func (s *notificationService) PutPreference(auth0UserID, channel string, req *models.PutNotificationPreferenceRequest) (*models.NotificationPreference, error) {
+	notifier, err := s.notifierFor(channel)
+	if err != nil {
+		return nil, err
+	}
+	if err := notifier.Validate(req.Destination); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNotificationPreference, err)
+	}
+
+	kinds := models.StringSet{}
+	for _, kind := range req.Kinds {
+		if !isNotificationKind(kind) {
+			return nil, fmt.Errorf("%w: unknown kind %q", ErrInvalidNotificationPreference, kind)
+		}
+		if !kinds.Has(kind) {
+			kinds = append(kinds, kind)
+		}
+	}
+
+	pref := &models.NotificationPreference{
+		Auth0UserID: auth0UserID,
+		Channel:     channel,
+		Destination: req.Destination,
+		Kinds:       kinds,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+	}
+	if err := s.repo.Put(pref); err != nil {
+		return nil, err
+	}
+	return pref, nil
+}
+
+func (s *notificationService) DeletePreference(auth0UserID, channel string) error {
+	err := s.repo.Delete(auth0UserID, channel)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotificationPreferenceNotFound
+	}
+	return err
+}
+
+// SendTest delivers a test message on the channel right away, even when the
+// preference is disabled, so users can check their destination works.
+func (s *notificationService) SendTest(ctx context.Context, auth0UserID, channel string) error {
+	pref, err := s.repo.Get(auth0UserID, channel)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotificationPreferenceNotFound
+		}
+		return err
+	}
+	notifier, err := s.notifierFor(channel)
+	if err != nil {
+		return err
+	}
+	err = notifier.Notify(ctx, pref.Destination, notify.Message{
+		Kind:  "test",
+		Title: "Test notification",
+		Text:  "Notifications on this channel are working.",
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotificationDeliveryFailed, err)
+	}
+	return nil
+}
+
+// postCreatedJob is the payload of a JobKindNotifyPostCreated job.
+type postCreatedJob struct {
+	PostID   string `json:"post_id"`
+	AuthorID string `json:"author_id"`
+	Title    string `json:"title"`
+	Slug     string `json:"slug"`
+	Excerpt  string `json:"excerpt"`
+}
+
+// eventFailedJob is the payload of a JobKindNotifyEventFailed job.
+type eventFailedJob struct {
+	OwnerID   string `json:"owner_id"`
+	EventName string `json:"event_name"`
+	RunID     string `json:"run_id"`
+	Error     string `json:"error"`
+}
+
+// deliverJob is the payload of a JobKindNotifyDeliver job.
+type deliverJob struct {
+	Auth0UserID string         `json:"auth0_user_id"`
+	Channel     string         `json:"channel"`
+	Message     notify.Message `json:"message"`
+}
+
+// postCreatedMessage is the outbox message announcing a new post.
+func postCreatedMessage(post *models.Post) models.OutboxMessage {
+	return outboxMessage(models.JobKindNotifyPostCreated, postCreatedJob{
+		PostID:   post.ID,
+		AuthorID: post.Auth0UserID,
+		Title:    post.Title,
+		Slug:     post.Slug,
+		Excerpt:  truncateRunes(post.Content, maxNotificationText),
+	})
+}
+
+// eventFailedMessage is the outbox message announcing a failed run.
+func eventFailedMessage(event *models.Event, report *models.EventRunReport) models.OutboxMessage {
+	return outboxMessage(models.JobKindNotifyEventFailed, eventFailedJob{
+		OwnerID:   event.OwnerID,
+		EventName: event.Name,
+		RunID:     report.RunID,
+		Error:     truncateRunes(report.Error, maxNotificationText),
+	})
+}
+
+func outboxMessage(kind string, payload interface{}) models.OutboxMessage {
+	// The payloads are plain structs of strings, which always marshal
+	raw, _ := json.Marshal(payload)
+	return models.OutboxMessage{Kind: kind, Payload: raw}
+}
+
+// HandlePostCreated queues a delivery to everyone subscribed to new posts,
+// except the author.
+func (s *notificationService) HandlePostCreated(ctx context.Context, payload json.RawMessage) error {
+	var job postCreatedJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return err
+	}
+	prefs, err := s.repo.ListSubscribers(models.NotificationPostCreated, job.AuthorID)
+	if err != nil {
+		return err
+	}
+	return s.queueDeliveries(prefs, notify.Message{
+		Kind:  models.NotificationPostCreated,
+		Title: "New post: " + job.Title,
+		Text:  job.Excerpt,
+		Data: map[string]string{
+			"id":    job.PostID,
+			"title": job.Title,
+			"slug":  job.Slug,
+		},
+	})
+}
+
+// HandleEventFailed queues a delivery to each of the owner's channels
+// subscribed to failures.
+func (s *notificationService) HandleEventFailed(ctx context.Context, payload json.RawMessage) error {
+	var job eventFailedJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return err
+	}
+	prefs, err := s.repo.ListByUser(job.OwnerID)
+	if err != nil {
+		return err
+	}
+	var subscribed []models.NotificationPreference
+	for _, pref := range prefs {
+		if pref.Enabled && pref.Kinds.Has(models.NotificationEventFailed) {
+			subscribed = append(subscribed, pref)
+		}
+	}
+
+	text := job.Error
+	if text == "" {
+		text = "The run reported no error message."
+	}
+	return s.queueDeliveries(subscribed, notify.Message{
+		Kind:  models.NotificationEventFailed,
+		Title: "Event " + job.EventName + " failed",
+		Text:  text,
+		Data: map[string]string{
+			"event":  job.EventName,
+			"run_id": job.RunID,
+			"error":  job.Error,
+		},
+	})
+}
+
+// queueDeliveries gives each recipient their own job, so one failing
+// destination is retried without repeating the others.
+func (s *notificationService) queueDeliveries(prefs []models.NotificationPreference, msg notify.Message) error {
+	messages := make([]models.OutboxMessage, 0, len(prefs))
+	for _, pref := range prefs {
+		messages = append(messages, outboxMessage(models.JobKindNotifyDeliver, deliverJob{
+			Auth0UserID: pref.Auth0UserID,
+			Channel:     pref.Channel,
+			Message:     msg,
+		}))
+	}
+	return s.enqueue(messages...)
+}
+
+// HandleDeliver sends one notification. The preference is read again, so a
+// user who turned the channel off meanwhile gets nothing.
+func (s *notificationService) HandleDeliver(ctx context.Context, payload json.RawMessage) error {
+	var job deliverJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return err
+	}
+	pref, err := s.repo.Get(job.Auth0UserID, job.Channel)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !pref.Enabled || !pref.Kinds.Has(job.Message.Kind) {
+		return nil
+	}
+	notifier, ok := s.notifiers[pref.Channel]
+	if !ok {
+		log.Printf("notifications: dropping %s to %s, channel %s is not configured", job.Message.Kind, pref.Auth0UserID, pref.Channel)
+		return nil
+	}
+	return notifier.Notify(ctx, pref.Destination, job.Message)
+}
+
+func (s *notificationService) notifierFor(channel string) (notify.Notifier, error) {
+	if !notify.IsChannel(channel) {
+		return nil, fmt.Errorf("%w: unknown channel %q", ErrInvalidNotificationPreference, channel)
+	}
+	notifier, ok := s.notifiers[channel]
+	if !ok {
+		return nil, ErrNotificationChannelUnavailable
+	}
+	return notifier, nil
+}
+
+func isNotificationKind(kind string) bool {
+	for _, known := range models.NotificationKinds {
+		if kind == known {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateRunes cuts s to at most n characters, marking the cut with an ellipsis.
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
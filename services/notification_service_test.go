@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/notify"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeNotificationRepository struct {
+	prefs []models.NotificationPreference
+}
+
+func (r *fakeNotificationRepository) ListByUser(auth0UserID string) ([]models.NotificationPreference, error) {
+	var prefs []models.NotificationPreference
+	for _, pref := range r.prefs {
+		if pref.Auth0UserID == auth0UserID {
+			prefs = append(prefs, pref)
+		}
+	}
+	return prefs, nil
+}
+
+func (r *fakeNotificationRepository) Get(auth0UserID, channel string) (*models.NotificationPreference, error) {
+	for _, pref := range r.prefs {
+		if pref.Auth0UserID == auth0UserID && pref.Channel == channel {
+			return &pref, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeNotificationRepository) Put(pref *models.NotificationPreference) error {
+	r.prefs = append(r.prefs, *pref)
+	return nil
+}
+
+func (r *fakeNotificationRepository) Delete(auth0UserID, channel string) error {
+	for i, pref := range r.prefs {
+		if pref.Auth0UserID == auth0UserID && pref.Channel == channel {
+			r.prefs = append(r.prefs[:i], r.prefs[i+1:]...)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (r *fakeNotificationRepository) ListSubscribers(kind, excludeUserID string) ([]models.NotificationPreference, error) {
+	var prefs []models.NotificationPreference
+	for _, pref := range r.prefs {
+		if pref.Enabled && pref.Kinds.Has(kind) && pref.Auth0UserID != excludeUserID {
+			prefs = append(prefs, pref)
+		}
+	}
+	return prefs, nil
+}
+
+type sentNotification struct {
+	destination string
+	msg         notify.Message
+}
+
+type fakeNotifier struct {
+	sent []sentNotification
+	err  error
+}
+
+func (n *fakeNotifier) Validate(destination string) error {
+	if destination == "bad" {
+		return notify.ErrInvalidDestination
+	}
+	return nil
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, destination string, msg notify.Message) error {
+	n.sent = append(n.sent, sentNotification{destination, msg})
+	return n.err
+}
+
+type queuedJobs struct {
+	messages []models.OutboxMessage
+}
+
+func (q *queuedJobs) enqueue(messages ...models.OutboxMessage) error {
+	q.messages = append(q.messages, messages...)
+	return nil
+}
+
+// deliveries decodes the queued delivery jobs.
+func (q *queuedJobs) deliveries(t *testing.T) []deliverJob {
+	var jobs []deliverJob
+	for _, message := range q.messages {
+		assert.Equal(t, models.JobKindNotifyDeliver, message.Kind)
+		var job deliverJob
+		assert.NoError(t, json.Unmarshal(message.Payload, &job))
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+func newTestNotificationService(repo *fakeNotificationRepository, notifier *fakeNotifier, queue *queuedJobs) NotificationService {
+	return NewNotificationService(repo, map[string]notify.Notifier{notify.ChannelDiscord: notifier}, queue.enqueue)
+}
+
+func TestPutPreference(t *testing.T) {
+	repo := &fakeNotificationRepository{}
+	service := newTestNotificationService(repo, &fakeNotifier{}, &queuedJobs{})
+
+	pref, err := service.PutPreference("auth0|1", notify.ChannelDiscord, &models.PutNotificationPreferenceRequest{
+		Destination: "https://discord.com/api/webhooks/1/a",
+		Kinds:       []string{models.NotificationPostCreated, models.NotificationPostCreated},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, models.StringSet{models.NotificationPostCreated}, pref.Kinds)
+	assert.True(t, pref.Enabled)
+
+	_, err = service.PutPreference("auth0|1", notify.ChannelDiscord, &models.PutNotificationPreferenceRequest{Destination: "bad"})
+	assert.ErrorIs(t, err, ErrInvalidNotificationPreference)
+	_, err = service.PutPreference("auth0|1", notify.ChannelDiscord, &models.PutNotificationPreferenceRequest{Destination: "x", Kinds: []string{"post.liked"}})
+	assert.ErrorIs(t, err, ErrInvalidNotificationPreference)
+	_, err = service.PutPreference("auth0|1", "pager", &models.PutNotificationPreferenceRequest{Destination: "x"})
+	assert.ErrorIs(t, err, ErrInvalidNotificationPreference)
+	_, err = service.PutPreference("auth0|1", notify.ChannelEmail, &models.PutNotificationPreferenceRequest{Destination: "a@example.com"})
+	assert.ErrorIs(t, err, ErrNotificationChannelUnavailable)
+}
+
+func TestHandlePostCreated_QueuesSubscribersButNotAuthor(t *testing.T) {
+	repo := &fakeNotificationRepository{prefs: []models.NotificationPreference{
+		{Auth0UserID: "auth0|author", Channel: notify.ChannelDiscord, Destination: "author", Kinds: models.StringSet{models.NotificationPostCreated}, Enabled: true},
+		{Auth0UserID: "auth0|reader", Channel: notify.ChannelDiscord, Destination: "reader", Kinds: models.StringSet{models.NotificationPostCreated}, Enabled: true},
+		{Auth0UserID: "auth0|muted", Channel: notify.ChannelDiscord, Destination: "muted", Kinds: models.StringSet{models.NotificationPostCreated}},
+		{Auth0UserID: "auth0|events", Channel: notify.ChannelDiscord, Destination: "events", Kinds: models.StringSet{models.NotificationEventFailed}, Enabled: true},
+	}}
+	queue := &queuedJobs{}
+	service := newTestNotificationService(repo, &fakeNotifier{}, queue)
+	message := postCreatedMessage(&models.Post{ID: "p1", Title: "Hello", Content: "World", Auth0UserID: "auth0|author"})
+
+	assert.NoError(t, service.HandlePostCreated(context.Background(), json.RawMessage(message.Payload)))
+
+	deliveries := queue.deliveries(t)
+	if assert.Len(t, deliveries, 1) {
+		assert.Equal(t, "auth0|reader", deliveries[0].Auth0UserID)
+		assert.Equal(t, models.NotificationPostCreated, deliveries[0].Message.Kind)
+		assert.Equal(t, "New post: Hello", deliveries[0].Message.Title)
+	}
+}
+
+func TestHandleEventFailed_QueuesSubscribedOwner(t *testing.T) {
+	repo := &fakeNotificationRepository{prefs: []models.NotificationPreference{
+		{Auth0UserID: "auth0|owner", Channel: notify.ChannelDiscord, Destination: "owner", Kinds: models.StringSet{models.NotificationEventFailed}, Enabled: true},
+		{Auth0UserID: "auth0|other", Channel: notify.ChannelDiscord, Destination: "other", Kinds: models.StringSet{models.NotificationEventFailed}, Enabled: true},
+	}}
+	queue := &queuedJobs{}
+	service := newTestNotificationService(repo, &fakeNotifier{}, queue)
+	message := eventFailedMessage(&models.Event{Name: "daily", OwnerID: "auth0|owner"}, &models.EventRunReport{RunID: "r1", Error: "boom"})
+
+	assert.NoError(t, service.HandleEventFailed(context.Background(), json.RawMessage(message.Payload)))
+
+	deliveries := queue.deliveries(t)
+	if assert.Len(t, deliveries, 1) {
+		assert.Equal(t, "auth0|owner", deliveries[0].Auth0UserID)
+		assert.Equal(t, "Event daily failed", deliveries[0].Message.Title)
+		assert.Equal(t, "boom", deliveries[0].Message.Text)
+	}
+}
+
+func TestHandleDeliver(t *testing.T) {
+	repo := &fakeNotificationRepository{prefs: []models.NotificationPreference{
+		{Auth0UserID: "auth0|on", Channel: notify.ChannelDiscord, Destination: "on", Kinds: models.StringSet{models.NotificationPostCreated}, Enabled: true},
+		{Auth0UserID: "auth0|off", Channel: notify.ChannelDiscord, Destination: "off", Kinds: models.StringSet{models.NotificationPostCreated}},
+	}}
+	notifier := &fakeNotifier{}
+	service := newTestNotificationService(repo, notifier, &queuedJobs{})
+	deliver := func(userID string) error {
+		message := outboxMessage(models.JobKindNotifyDeliver, deliverJob{
+			Auth0UserID: userID,
+			Channel:     notify.ChannelDiscord,
+			Message:     notify.Message{Kind: models.NotificationPostCreated, Text: "hi"},
+		})
+		return service.HandleDeliver(context.Background(), json.RawMessage(message.Payload))
+	}
+
+	assert.NoError(t, deliver("auth0|on"))
+	assert.NoError(t, deliver("auth0|off"))
+	assert.NoError(t, deliver("auth0|gone"))
+	if assert.Len(t, notifier.sent, 1) {
+		assert.Equal(t, "on", notifier.sent[0].destination)
+	}
+
+	// Failed deliveries are returned so the job is retried
+	notifier.err = errors.New("webhook down")
+	assert.Error(t, deliver("auth0|on"))
+}
+
+func TestSendTest(t *testing.T) {
+	repo := &fakeNotificationRepository{prefs: []models.NotificationPreference{
+		{Auth0UserID: "auth0|1", Channel: notify.ChannelDiscord, Destination: "hook"},
+	}}
+	notifier := &fakeNotifier{}
+	service := newTestNotificationService(repo, notifier, &queuedJobs{})
+
+	assert.NoError(t, service.SendTest(context.Background(), "auth0|1", notify.ChannelDiscord))
+	assert.Len(t, notifier.sent, 1)
+	assert.ErrorIs(t, service.SendTest(context.Background(), "auth0|1", notify.ChannelSlack), ErrNotificationPreferenceNotFound)
+
+	notifier.err = errors.New("404 Not Found")
+	assert.ErrorIs(t, service.SendTest(context.Background(), "auth0|1", notify.ChannelDiscord), ErrNotificationDeliveryFailed)
+}
@@ -36,7 +36,8 @@ func (s *postService) CreatePost(req *models.CreatePostRequest, auth0UserID stri
 		UpdatedAt:   time.Now(),
 	}
 
-	err := s.postRepo.Create(post)
+	// Subscribers and webhooks are notified once the post commits
+	err := s.postRepo.Create(post, postCreatedMessage(post), webhookEventMessage(models.WebhookEventPostCreated, post))
 	if err != nil {
 		return nil, err
 	}
@@ -74,15 +75,19 @@ func (s *postService) UpdatePost(id string, req *models.UpdatePostRequest, auth0
 		"auth0_user_id": auth0UserID,
 	}
 
+	updated := *existingPost
 	if req.Title != "" {
 		updates["title"] = req.Title
+		updated.Title = req.Title
 	}
 	if req.Content != "" {
 		updates["content"] = req.Content
+		updated.Content = req.Content
 	}
+	updated.UpdatedAt = time.Now()
 
 	// Update the post
-	err = s.postRepo.Update(id, updates)
+	err = s.postRepo.Update(id, updates, webhookEventMessage(models.WebhookEventPostUpdated, &updated))
 	if err != nil {
 		return nil, err
 	}
@@ -105,7 +110,7 @@ func (s *postService) DeletePost(id, auth0UserID string) error {
 		return sql.ErrNoRows // Use this to indicate permission denied
 	}
 
-	return s.postRepo.Delete(id, auth0UserID)
+	return s.postRepo.Delete(id, auth0UserID, webhookEventMessage(models.WebhookEventPostDeleted, existingPost))
 }
 
 func (s *postService) ListPosts(author *string) ([]models.Post, error) {
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/repositories"
+)
+
+const (
+	// DefaultPostStreamBuffer is how many recent events are kept for clients
+	// resuming with Last-Event-ID.
+	DefaultPostStreamBuffer = 256
+
+	postSubscriberBuffer    = 32
+	postListenRetryBase     = time.Second
+	postListenRetryMax      = 30 * time.Second
+	postListenHealthyPeriod = time.Minute
+)
+
+// PostLoader fetches a post for the stream, returning sql.ErrNoRows when it
+// is gone or hidden.
+type PostLoader func(id string) (*models.Post, error)
+
+// PostSubscription is one client's view of the post stream.
+type PostSubscription struct {
+	// Replay holds the buffered events after the one the client resumed from
+	Replay []models.PostEvent
+	// Reset is set when the client resumed from an event no longer buffered,
+	// so it may have missed changes and should reload posts
+	Reset bool
+	// Events delivers new events. It is closed when the client falls too far
+	// behind, when events may have been lost, and on shutdown; clients then
+	// reconnect and resume.
+	Events <-chan models.PostEvent
+
+	close func()
+}
+
+// Close unsubscribes. It is safe to call more than once.
+func (s *PostSubscription) Close() {
+	if s.close != nil {
+		s.close()
+	}
+}
+
+type PostStream interface {
+	Subscribe(lastEventID string) *PostSubscription
+	Run(ctx context.Context)
+}
+
+type postStream struct {
+	listener repositories.PostEventListener
+	load     PostLoader
+	size     int
+
+	mu          sync.Mutex
+	buffer      []models.PostEvent
+	subscribers map[chan models.PostEvent]struct{}
+	stopped     bool
+}
+
+// NewPostStream relays the post changes announced by listener, which every
+// API instance receives, to subscribers. The last bufferSize events are
+// kept for resuming.
+func NewPostStream(listener repositories.PostEventListener, load PostLoader, bufferSize int) PostStream {
+	if bufferSize < 1 {
+		bufferSize = DefaultPostStreamBuffer
+	}
+	return &postStream{
+		listener:    listener,
+		load:        load,
+		size:        bufferSize,
+		subscribers: make(map[chan models.PostEvent]struct{}),
+	}
+}
+
+func (s *postStream) Subscribe(lastEventID string) *PostSubscription {
+	events := make(chan models.PostEvent, postSubscriberBuffer)
+	subscription := &PostSubscription{Events: events}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		close(events)
+		return subscription
+	}
+	if lastEventID != "" {
+		subscription.Reset = true
+		for i, event := range s.buffer {
+			if event.ID == lastEventID {
+				subscription.Replay = append([]models.PostEvent(nil), s.buffer[i+1:]...)
+				subscription.Reset = false
+				break
+			}
+		}
+	}
+
+	s.subscribers[events] = struct{}{}
+	subscription.close = func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.drop(events)
+	}
+	return subscription
+}
+
+// Run listens for post events until ctx is done, then ends every
+// subscription. If listening fails it starts again with backoff; events may
+// have been missed meanwhile, so the buffer is cleared and subscribers are
+// dropped to make them resume with a reset.
+func (s *postStream) Run(ctx context.Context) {
+	defer func() {
+		s.mu.Lock()
+		s.stopped = true
+		s.mu.Unlock()
+		s.reset()
+	}()
+
+	retry := postListenRetryBase
+	for {
+		started := time.Now()
+		err := s.listener.Listen(ctx, s.receive)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("post stream: listening stopped: %v", err)
+		s.reset()
+
+		if time.Since(started) > postListenHealthyPeriod {
+			retry = postListenRetryBase
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retry):
+		}
+		if retry *= 2; retry > postListenRetryMax {
+			retry = postListenRetryMax
+		}
+	}
+}
+
+// postNotification is the payload sent on repositories.PostEventsChannel.
+type postNotification struct {
+	ID     int64  `json:"id"`
+	Type   string `json:"type"`
+	PostID string `json:"post_id"`
+}
+
+func (s *postStream) receive(payload string) {
+	var notification postNotification
+	if err := json.Unmarshal([]byte(payload), &notification); err != nil {
+		log.Printf("post stream: ignoring malformed notification: %v", err)
+		return
+	}
+	event := models.PostEvent{
+		ID:     strconv.FormatInt(notification.ID, 10),
+		Type:   notification.Type,
+		PostID: notification.PostID,
+	}
+	if event.Type != models.WebhookEventPostDeleted {
+		post, err := s.load(event.PostID)
+		if errors.Is(err, sql.ErrNoRows) {
+			// Deleted since, or its author is banned
+			return
+		}
+		if err != nil {
+			// Events always carry their post, so skip this one and make
+			// subscribers resume with a reset, which reloads posts
+			log.Printf("post stream: loading post %s: %v", event.PostID, err)
+			s.reset()
+			return
+		}
+		event.Post = post
+	}
+	s.publish(event)
+}
+
+func (s *postStream) publish(event models.PostEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buffer = append(s.buffer, event)
+	if len(s.buffer) > s.size {
+		s.buffer = append(s.buffer[:0], s.buffer[len(s.buffer)-s.size:]...)
+	}
+
+	for events := range s.subscribers {
+		select {
+		case events <- event:
+		default:
+			// Too far behind; it can resume from the buffer
+			s.drop(events)
+		}
+	}
+}
+
+// drop ends a subscription. The caller holds s.mu.
+func (s *postStream) drop(events chan models.PostEvent) {
+	if _, ok := s.subscribers[events]; ok {
+		delete(s.subscribers, events)
+		close(events)
+	}
+}
+
+// reset ends every subscription and forgets the buffered events.
+func (s *postStream) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for events := range s.subscribers {
+		s.drop(events)
+	}
+	s.buffer = nil
+}
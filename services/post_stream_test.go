@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakePostEventListener hands out payloads sent on notifications until ctx
+// is done or fail is closed.
+type fakePostEventListener struct {
+	notifications chan string
+	fail          chan struct{}
+}
+
+func newFakePostEventListener() *fakePostEventListener {
+	return &fakePostEventListener{notifications: make(chan string), fail: make(chan struct{})}
+}
+
+func (l *fakePostEventListener) Listen(ctx context.Context, handle func(payload string)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-l.fail:
+			return errors.New("connection lost")
+		case payload := <-l.notifications:
+			handle(payload)
+		}
+	}
+}
+
+func postNotificationPayload(id int, eventType, postID string) string {
+	return fmt.Sprintf(`{"id":%d,"type":%q,"post_id":%q}`, id, eventType, postID)
+}
+
+func loadTestPost(id string) (*models.Post, error) {
+	switch id {
+	case "hidden":
+		return nil, sql.ErrNoRows
+	case "unreachable":
+		return nil, errors.New("connection refused")
+	}
+	return &models.Post{ID: id, Title: "Post " + id}, nil
+}
+
+func receiveEvent(t *testing.T, events <-chan models.PostEvent) models.PostEvent {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return models.PostEvent{}
+	}
+}
+
+func TestPostStream_DeliversAndReplaysEvents(t *testing.T) {
+	listener := newFakePostEventListener()
+	stream := NewPostStream(listener, loadTestPost, 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go stream.Run(ctx)
+
+	live := stream.Subscribe("")
+	defer live.Close()
+	assert.False(t, live.Reset)
+
+	listener.notifications <- postNotificationPayload(1, models.WebhookEventPostCreated, "p1")
+	listener.notifications <- postNotificationPayload(2, models.WebhookEventPostCreated, "hidden")
+	listener.notifications <- postNotificationPayload(3, models.WebhookEventPostUpdated, "p1")
+	listener.notifications <- postNotificationPayload(4, models.WebhookEventPostDeleted, "p2")
+
+	created := receiveEvent(t, live.Events)
+	assert.Equal(t, "1", created.ID)
+	assert.Equal(t, "Post p1", created.Post.Title)
+	assert.Equal(t, "3", receiveEvent(t, live.Events).ID)
+	deleted := receiveEvent(t, live.Events)
+	assert.Equal(t, "p2", deleted.PostID)
+	assert.Nil(t, deleted.Post)
+
+	// The buffer holds the last two events
+	resumed := stream.Subscribe("3")
+	defer resumed.Close()
+	assert.False(t, resumed.Reset)
+	if assert.Len(t, resumed.Replay, 1) {
+		assert.Equal(t, "4", resumed.Replay[0].ID)
+	}
+
+	stale := stream.Subscribe("1")
+	defer stale.Close()
+	assert.True(t, stale.Reset)
+	assert.Empty(t, stale.Replay)
+}
+
+func TestPostStream_DropsSubscribersThatFallBehind(t *testing.T) {
+	listener := newFakePostEventListener()
+	stream := NewPostStream(listener, loadTestPost, DefaultPostStreamBuffer)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go stream.Run(ctx)
+
+	slow := stream.Subscribe("")
+	for i := 1; i <= postSubscriberBuffer+1; i++ {
+		listener.notifications <- postNotificationPayload(i, models.WebhookEventPostDeleted, "p")
+	}
+
+	received := 0
+	for range slow.Events {
+		received++
+	}
+	assert.Equal(t, postSubscriberBuffer, received)
+	slow.Close()
+
+	resumed := stream.Subscribe(fmt.Sprint(postSubscriberBuffer))
+	defer resumed.Close()
+	if assert.Len(t, resumed.Replay, 1) {
+		assert.Equal(t, fmt.Sprint(postSubscriberBuffer+1), resumed.Replay[0].ID)
+	}
+}
+
+func TestPostStream_ResetsWhenListeningFailsAndStopsWithContext(t *testing.T) {
+	listener := newFakePostEventListener()
+	stream := NewPostStream(listener, loadTestPost, DefaultPostStreamBuffer)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		stream.Run(ctx)
+		close(done)
+	}()
+
+	listener.notifications <- postNotificationPayload(1, models.WebhookEventPostDeleted, "p1")
+	subscription := stream.Subscribe("")
+	close(listener.fail)
+
+	_, open := <-subscription.Events
+	assert.False(t, open)
+	assert.True(t, stream.Subscribe("1").Reset)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("stream did not stop")
+	}
+	_, open = <-stream.Subscribe("").Events
+	assert.False(t, open)
+}
+
+func TestPostStream_ResetsWhenAPostCannotBeLoaded(t *testing.T) {
+	listener := newFakePostEventListener()
+	stream := NewPostStream(listener, loadTestPost, DefaultPostStreamBuffer)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go stream.Run(ctx)
+
+	listener.notifications <- postNotificationPayload(1, models.WebhookEventPostCreated, "p1")
+	subscription := stream.Subscribe("1")
+	listener.notifications <- postNotificationPayload(2, models.WebhookEventPostUpdated, "unreachable")
+
+	// No event without its post; the subscriber is ended and resumes with a reset
+	_, open := <-subscription.Events
+	assert.False(t, open)
+	resumed := stream.Subscribe("1")
+	defer resumed.Close()
+	assert.True(t, resumed.Reset)
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/repositories"
+)
+
+var (
+	ErrRoleExists        = errors.New("role already exists")
+	ErrUnknownPermission = errors.New("unknown permission")
+)
+
+type RoleService interface {
+	ListRoles() ([]models.Role, error)
+	CreateRole(name string, permissions []string) error
+	SetRolePermissions(name string, permissions []string) error
+}
+
+type roleService struct {
+	repo repositories.RoleRepository
+}
+
+func NewRoleService(repo repositories.RoleRepository) RoleService {
+	return &roleService{repo: repo}
+}
+
+func (s *roleService) ListRoles() ([]models.Role, error) {
+	return s.repo.ListRoles()
+}
+
+func (s *roleService) CreateRole(name string, permissions []string) error {
+	if err := validatePermissions(permissions); err != nil {
+		return err
+	}
+	if err := s.repo.CreateRole(name, permissions); err != nil {
+		if repositories.IsUniqueViolation(err) {
+			return ErrRoleExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *roleService) SetRolePermissions(name string, permissions []string) error {
+	if err := validatePermissions(permissions); err != nil {
+		return err
+	}
+	if err := s.repo.SetRolePermissions(name, permissions); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrRoleNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func validatePermissions(permissions []string) error {
+	for _, permission := range permissions {
+		if !slices.Contains(models.KnownPermissions, permission) {
+			return fmt.Errorf("%w: %s", ErrUnknownPermission, permission)
+		}
+	}
+	return nil
+}
@@ -3,20 +3,39 @@ package services
 import (
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/dat1010/go-api/models"
 	"github.com/dat1010/go-api/repositories"
 )
 
-var ErrRoleNotFound = errors.New("role not found")
+var (
+	ErrRoleNotFound      = errors.New("role not found")
+	ErrUserNotFound      = errors.New("user not found")
+	ErrInvalidSuspension = errors.New("suspension must end in the future")
+	ErrInvalidEventQuota = errors.New("event quota cannot be negative")
+)
+
+// DefaultMaxActiveEvents is the active event quota for users without their own.
+const DefaultMaxActiveEvents = 5
 
 type UserService interface {
-	EnsureUserWithDefaultRole(auth0UserID, defaultRole string) error
+	// EnsureUserWithDefaultRole creates the user if needed, gives them
+	// defaultRole if they have none, and returns their role.
+	EnsureUserWithDefaultRole(auth0UserID, defaultRole string) (string, error)
 	ListUsersWithRoles() ([]models.UserWithRole, error)
 	SetUserRole(auth0UserID, roleName string) error
 	DeleteUser(auth0UserID string) error
 	IsUserInRole(auth0UserID, roleName string) (bool, error)
+	HasPermission(auth0UserID, permission string) (bool, error)
+	GetEventQuota(auth0UserID string) (int, error)
+	SetEventQuota(auth0UserID string, maxActiveEvents *int) error
 	GetUserRole(auth0UserID string) (string, error)
+	SetUserEmail(auth0UserID, email string) error
+	GetUserStatus(auth0UserID string) (*models.UserStatus, error)
+	SuspendUser(auth0UserID, reason string, until time.Time, changedBy string) error
+	BanUser(auth0UserID, reason, changedBy string) error
+	ReinstateUser(auth0UserID, changedBy string) error
 }
 
 type userService struct {
@@ -27,18 +46,21 @@ func NewUserService(repo repositories.UserRepository) UserService {
 	return &userService{repo: repo}
 }
 
-func (s *userService) EnsureUserWithDefaultRole(auth0UserID, defaultRole string) error {
-	if err := s.repo.EnsureUser(auth0UserID); err != nil {
-		return err
+func (s *userService) EnsureUserWithDefaultRole(auth0UserID, defaultRole string) (string, error) {
+	if err := s.ensureUser(auth0UserID); err != nil {
+		return "", err
 	}
 	role, err := s.repo.GetUserRole(auth0UserID)
 	if err != nil {
-		return err
+		return "", err
 	}
 	if role != "" {
-		return nil
+		return role, nil
+	}
+	if err := s.SetUserRole(auth0UserID, defaultRole); err != nil {
+		return "", err
 	}
-	return s.SetUserRole(auth0UserID, defaultRole)
+	return defaultRole, nil
 }
 
 func (s *userService) ListUsersWithRoles() ([]models.UserWithRole, error) {
@@ -46,10 +68,11 @@ func (s *userService) ListUsersWithRoles() ([]models.UserWithRole, error) {
 }
 
 func (s *userService) SetUserRole(auth0UserID, roleName string) error {
-	if err := s.repo.EnsureUser(auth0UserID); err != nil {
+	if err := s.ensureUser(auth0UserID); err != nil {
 		return err
 	}
-	if err := s.repo.SetUserRole(auth0UserID, roleName); err != nil {
+	changed := webhookEventMessage(models.WebhookEventUserRoleUpdated, webhookUserData{Auth0UserID: auth0UserID, Role: roleName})
+	if err := s.repo.SetUserRole(auth0UserID, roleName, changed); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return ErrRoleNotFound
 		}
@@ -58,14 +81,118 @@ func (s *userService) SetUserRole(auth0UserID, roleName string) error {
 	return nil
 }
 
+// DeleteUser deletes the user and their events. Schedule sync jobs then
+// remove the events' rules, which no longer have an event to match.
 func (s *userService) DeleteUser(auth0UserID string) error {
-	return s.repo.DeleteUser(auth0UserID)
+	return s.repo.DeleteUser(auth0UserID, scheduleSyncMessage, webhookEventMessage(models.WebhookEventUserDeleted, webhookUserData{Auth0UserID: auth0UserID}))
 }
 
 func (s *userService) IsUserInRole(auth0UserID, roleName string) (bool, error) {
 	return s.repo.IsUserInRole(auth0UserID, roleName)
 }
 
+func (s *userService) HasPermission(auth0UserID, permission string) (bool, error) {
+	return s.repo.HasPermission(auth0UserID, permission)
+}
+
+// GetEventQuota returns how many enabled events the user may have at once.
+func (s *userService) GetEventQuota(auth0UserID string) (int, error) {
+	quota, err := s.repo.GetEventQuota(auth0UserID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+	if quota == nil {
+		return DefaultMaxActiveEvents, nil
+	}
+	return *quota, nil
+}
+
+// SetEventQuota overrides the user's quota; nil restores the default.
+func (s *userService) SetEventQuota(auth0UserID string, maxActiveEvents *int) error {
+	if maxActiveEvents != nil && *maxActiveEvents < 0 {
+		return ErrInvalidEventQuota
+	}
+	if err := s.repo.SetEventQuota(auth0UserID, maxActiveEvents); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	return nil
+}
+
 func (s *userService) GetUserRole(auth0UserID string) (string, error) {
 	return s.repo.GetUserRole(auth0UserID)
 }
+
+func (s *userService) SetUserEmail(auth0UserID, email string) error {
+	if err := s.ensureUser(auth0UserID); err != nil {
+		return err
+	}
+	return s.repo.SetUserEmail(auth0UserID, email)
+}
+
+// GetUserStatus returns the user's effective status; suspensions that have run out count as active.
+func (s *userService) GetUserStatus(auth0UserID string) (*models.UserStatus, error) {
+	status, err := s.repo.GetUserStatus(auth0UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &models.UserStatus{Status: models.UserStatusActive}, nil
+		}
+		return nil, err
+	}
+	if status.Status == models.UserStatusSuspended && status.SuspendedUntil != nil && !status.SuspendedUntil.After(time.Now()) {
+		status.Status = models.UserStatusActive
+	}
+	return status, nil
+}
+
+func (s *userService) SuspendUser(auth0UserID, reason string, until time.Time, changedBy string) error {
+	if !until.After(time.Now()) {
+		return ErrInvalidSuspension
+	}
+	return s.setUserStatus(auth0UserID, &models.UserStatus{
+		Status:          models.UserStatusSuspended,
+		Reason:          reason,
+		SuspendedUntil:  &until,
+		StatusChangedBy: changedBy,
+	})
+}
+
+func (s *userService) BanUser(auth0UserID, reason, changedBy string) error {
+	return s.setUserStatus(auth0UserID, &models.UserStatus{
+		Status:          models.UserStatusBanned,
+		Reason:          reason,
+		StatusChangedBy: changedBy,
+	})
+}
+
+func (s *userService) ReinstateUser(auth0UserID, changedBy string) error {
+	return s.setUserStatus(auth0UserID, &models.UserStatus{
+		Status:          models.UserStatusActive,
+		StatusChangedBy: changedBy,
+	})
+}
+
+// userStatusEvents maps each status to the webhook event announcing it.
+var userStatusEvents = map[string]string{
+	models.UserStatusActive:    models.WebhookEventUserReinstated,
+	models.UserStatusSuspended: models.WebhookEventUserSuspended,
+	models.UserStatusBanned:    models.WebhookEventUserBanned,
+}
+
+func (s *userService) setUserStatus(auth0UserID string, status *models.UserStatus) error {
+	changed := webhookEventMessage(userStatusEvents[status.Status], webhookUserData{Auth0UserID: auth0UserID, Status: status})
+	if err := s.repo.SetUserStatus(auth0UserID, status, changed); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ensureUser creates the user on first sight, announcing them to webhooks.
+func (s *userService) ensureUser(auth0UserID string) error {
+	return s.repo.EnsureUser(auth0UserID, webhookEventMessage(models.WebhookEventUserCreated, webhookUserData{Auth0UserID: auth0UserID}))
+}
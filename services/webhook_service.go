@@ -0,0 +1,490 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/dat1010/go-api/notify"
+	"github.com/dat1010/go-api/repositories"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrInvalidWebhook          = errors.New("invalid webhook")
+	ErrWebhookLimitReached     = errors.New("webhook limit reached")
+	ErrWebhookDisabled         = errors.New("webhook is disabled")
+)
+
+const (
+	// MaxWebhooksPerUser caps how many webhooks one user may register.
+	MaxWebhooksPerUser = 10
+	// WebhookMaxAttempts is how many times a delivery is tried before it fails.
+	WebhookMaxAttempts = 6
+	// WebhookDisableAfter is how many deliveries in a row may fail before the
+	// webhook is disabled.
+	WebhookDisableAfter = 5
+
+	webhookTimeout         = 10 * time.Second
+	maxWebhookURLBytes     = 2048
+	maxWebhookDescription  = 200
+	maxWebhookResponseBody = 1 << 10
+	webhookSecretPrefix    = "whsec_"
+)
+
+var webhookDisabledReason = fmt.Sprintf("disabled after %d failed deliveries in a row", WebhookDisableAfter)
+
+type WebhookService interface {
+	ListWebhooks(ownerID string) ([]models.Webhook, error)
+	GetWebhook(ownerID, id string) (*models.Webhook, error)
+	CreateWebhook(ownerID string, req *models.CreateWebhookRequest) (*models.CreatedWebhook, error)
+	UpdateWebhook(ownerID, id string, req *models.UpdateWebhookRequest) (*models.Webhook, error)
+	DeleteWebhook(ownerID, id string) error
+	ListDeliveries(ownerID, webhookID string, page, pageSize int) (*models.WebhookDeliveryPage, error)
+	Redeliver(ownerID, webhookID, deliveryID string) (*models.WebhookDelivery, error)
+	HandleEvent(ctx context.Context, payload json.RawMessage) error
+	HandleDeliver(ctx context.Context, payload json.RawMessage) error
+}
+
+type webhookService struct {
+	repo   repositories.WebhookRepository
+	client *http.Client
+}
+
+// NewWebhookService manages webhooks owned by users, and admin webhooks with
+// an empty ownerID. Deliveries are sent with client; nil means a client that
+// refuses private addresses and redirects.
+func NewWebhookService(repo repositories.WebhookRepository, client *http.Client) WebhookService {
+	if client == nil {
+		client = notify.NewGuardedClient(webhookTimeout)
+	}
+	return &webhookService{repo: repo, client: client}
+}
+
+func (s *webhookService) ListWebhooks(ownerID string) ([]models.Webhook, error) {
+	webhooks, err := s.repo.ListByOwner(ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if webhooks == nil {
+		webhooks = []models.Webhook{}
+	}
+	return webhooks, nil
+}
+
+func (s *webhookService) GetWebhook(ownerID, id string) (*models.Webhook, error) {
+	webhook, err := s.repo.Get(ownerID, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// CreateWebhook returns the signing secret alongside the webhook; it is
+// never shown again.
+func (s *webhookService) CreateWebhook(ownerID string, req *models.CreateWebhookRequest) (*models.CreatedWebhook, error) {
+	if err := validateWebhookURL(req.URL); err != nil {
+		return nil, err
+	}
+	eventTypes, err := webhookEventTypes(ownerID, req.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Description) > maxWebhookDescription {
+		return nil, fmt.Errorf("%w: description is longer than %d characters", ErrInvalidWebhook, maxWebhookDescription)
+	}
+
+	if ownerID != "" {
+		count, err := s.repo.CountByOwner(ownerID)
+		if err != nil {
+			return nil, err
+		}
+		if count >= MaxWebhooksPerUser {
+			return nil, ErrWebhookLimitReached
+		}
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	webhook := &models.Webhook{
+		ID:          uuid.New().String(),
+		URL:         req.URL,
+		Description: req.Description,
+		Secret:      secret,
+		EventTypes:  eventTypes,
+		Enabled:     true,
+	}
+	if ownerID != "" {
+		webhook.OwnerID = &ownerID
+	}
+	if err := s.repo.Create(webhook); err != nil {
+		return nil, err
+	}
+	return &models.CreatedWebhook{Webhook: *webhook, Secret: secret}, nil
+}
+
+func (s *webhookService) UpdateWebhook(ownerID, id string, req *models.UpdateWebhookRequest) (*models.Webhook, error) {
+	webhook, err := s.GetWebhook(ownerID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		if err := validateWebhookURL(*req.URL); err != nil {
+			return nil, err
+		}
+		webhook.URL = *req.URL
+	}
+	if req.EventTypes != nil {
+		if webhook.EventTypes, err = webhookEventTypes(ownerID, req.EventTypes); err != nil {
+			return nil, err
+		}
+	}
+	if req.Description != nil {
+		if len(*req.Description) > maxWebhookDescription {
+			return nil, fmt.Errorf("%w: description is longer than %d characters", ErrInvalidWebhook, maxWebhookDescription)
+		}
+		webhook.Description = *req.Description
+	}
+	if req.Enabled != nil {
+		webhook.Enabled = *req.Enabled
+	}
+
+	if err := s.repo.Update(webhook); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (s *webhookService) DeleteWebhook(ownerID, id string) error {
+	err := s.repo.Delete(ownerID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrWebhookNotFound
+	}
+	return err
+}
+
+func (s *webhookService) ListDeliveries(ownerID, webhookID string, page, pageSize int) (*models.WebhookDeliveryPage, error) {
+	if _, err := s.GetWebhook(ownerID, webhookID); err != nil {
+		return nil, err
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultEventPageSize
+	}
+	if pageSize > maxEventPageSize {
+		pageSize = maxEventPageSize
+	}
+
+	total, err := s.repo.CountDeliveries(webhookID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.repo.ListDeliveries(webhookID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.WebhookDelivery{}
+	}
+
+	return &models.WebhookDeliveryPage{
+		Items:    items,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// Redeliver queues a new delivery of the same event, whatever became of the
+// original. The event ID stays the same so receivers can spot repeats.
+func (s *webhookService) Redeliver(ownerID, webhookID, deliveryID string) (*models.WebhookDelivery, error) {
+	webhook, err := s.GetWebhook(ownerID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if !webhook.Enabled {
+		return nil, ErrWebhookDisabled
+	}
+	original, err := s.repo.GetDelivery(webhookID, deliveryID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	delivery := models.WebhookDelivery{
+		ID:           uuid.New().String(),
+		WebhookID:    webhookID,
+		EventID:      original.EventID,
+		EventType:    original.EventType,
+		Payload:      original.Payload,
+		Status:       models.WebhookDeliveryPending,
+		RedeliveryOf: &original.ID,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := s.repo.CreateDeliveries([]models.WebhookDelivery{delivery}, []models.OutboxMessage{webhookDeliverMessage(delivery.ID)}); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// webhookDeliverJob is the payload of a JobKindWebhookDeliver job.
+type webhookDeliverJob struct {
+	DeliveryID string `json:"delivery_id"`
+}
+
+// webhookEventMessage is the outbox message announcing an event to webhooks.
+// Its payload is the body every delivery of the event sends.
+func webhookEventMessage(eventType string, data interface{}) models.OutboxMessage {
+	raw, _ := json.Marshal(data)
+	return outboxMessage(models.JobKindWebhookEvent, models.WebhookEvent{
+		ID:        uuid.New().String(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      raw,
+	})
+}
+
+func webhookDeliverMessage(deliveryID string) models.OutboxMessage {
+	return outboxMessage(models.JobKindWebhookDeliver, webhookDeliverJob{DeliveryID: deliveryID})
+}
+
+// webhookUserData is the data of user.* events.
+type webhookUserData struct {
+	Auth0UserID string             `json:"auth0_user_id"`
+	Role        string             `json:"role,omitempty"`
+	Status      *models.UserStatus `json:"status,omitempty"`
+}
+
+// HandleEvent logs a delivery for each webhook subscribed to the event and
+// queues them to be sent.
+func (s *webhookService) HandleEvent(ctx context.Context, payload json.RawMessage) error {
+	var event models.WebhookEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return err
+	}
+	webhooks, err := s.repo.ListSubscribed(event.Type)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var deliveries []models.WebhookDelivery
+	var outbox []models.OutboxMessage
+	for _, webhook := range webhooks {
+		// User webhooks cannot subscribe to these, but may have been
+		// created before a type was restricted
+		if webhook.OwnerID != nil && !containsString(models.UserWebhookEventTypes, event.Type) {
+			continue
+		}
+		delivery := models.WebhookDelivery{
+			ID:        uuid.New().String(),
+			WebhookID: webhook.ID,
+			EventID:   event.ID,
+			EventType: event.Type,
+			Payload:   models.JSONObject(payload),
+			Status:    models.WebhookDeliveryPending,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		deliveries = append(deliveries, delivery)
+		outbox = append(outbox, webhookDeliverMessage(delivery.ID))
+	}
+	return s.repo.CreateDeliveries(deliveries, outbox)
+}
+
+// HandleDeliver makes one attempt at a delivery. A failed attempt is
+// returned, so the job retries it, until the delivery runs out of attempts.
+func (s *webhookService) HandleDeliver(ctx context.Context, payload json.RawMessage) error {
+	var job webhookDeliverJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return err
+	}
+	delivery, err := s.repo.GetDeliveryByID(job.DeliveryID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if delivery.Status != models.WebhookDeliveryPending {
+		return nil
+	}
+	webhook, err := s.repo.GetByID(delivery.WebhookID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !webhook.Enabled {
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.Error = ErrWebhookDisabled.Error()
+		_, err := s.repo.RecordAttempt(delivery, WebhookDisableAfter, webhookDisabledReason)
+		return err
+	}
+
+	sendErr := s.send(ctx, webhook, delivery)
+	delivery.Attempts++
+	switch {
+	case sendErr == nil:
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.Error = ""
+		now := time.Now()
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= WebhookMaxAttempts:
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.Error = sendErr.Error()
+	default:
+		delivery.Error = sendErr.Error()
+	}
+
+	disabled, err := s.repo.RecordAttempt(delivery, WebhookDisableAfter, webhookDisabledReason)
+	if err != nil {
+		return err
+	}
+	if disabled {
+		log.Printf("webhooks: disabled webhook %s: %s", webhook.ID, webhookDisabledReason)
+	}
+	if delivery.Status == models.WebhookDeliveryPending {
+		return fmt.Errorf("webhook delivery %s attempt %d: %w", delivery.ID, delivery.Attempts, sendErr)
+	}
+	return nil
+}
+
+// send posts the delivery's payload, recording the response on delivery.
+// Anything but a 2xx response is an error.
+func (s *webhookService) send(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) error {
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return errors.New("invalid webhook URL")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-api-webhooks")
+	req.Header.Set("X-Webhook-Id", delivery.ID)
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+SignWebhook(webhook.Secret, timestamp, body))
+
+	delivery.ResponseStatus = nil
+	delivery.ResponseBody = ""
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	duration := time.Since(start).Milliseconds()
+	delivery.DurationMs = &duration
+	if err != nil {
+		// The URL may carry credentials, so leave it out of the log
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponseBody))
+	status := resp.StatusCode
+	delivery.ResponseStatus = &status
+	delivery.ResponseBody = strings.ToValidUTF8(string(responseBody), "")
+	if status < 200 || status > 299 {
+		return fmt.Errorf("endpoint responded %s", resp.Status)
+	}
+	return nil
+}
+
+// SignWebhook returns the signature sent in X-Webhook-Signature, after the
+// "sha256=" prefix. Receivers should check it and reject stale timestamps.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	return signTimestamped([]byte(secret), timestamp, body)
+}
+
+func newWebhookSecret() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return webhookSecretPrefix + hex.EncodeToString(raw), nil
+}
+
+// webhookEventTypes checks the types requested for a webhook, dropping
+// repeats. Only admin webhooks may subscribe to user events.
+func webhookEventTypes(ownerID string, requested []string) (models.StringSet, error) {
+	allowed := models.WebhookEventTypes
+	if ownerID != "" {
+		allowed = models.UserWebhookEventTypes
+	}
+	eventTypes := models.StringSet{}
+	for _, eventType := range requested {
+		if !containsString(allowed, eventType) {
+			return nil, fmt.Errorf("%w: unknown event type %q", ErrInvalidWebhook, eventType)
+		}
+		if !eventTypes.Has(eventType) {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	if len(eventTypes) == 0 {
+		return nil, fmt.Errorf("%w: at least one event type is required", ErrInvalidWebhook)
+	}
+	return eventTypes, nil
+}
+
+// validateWebhookURL accepts https URLs that do not name a local or private
+// host. Names that resolve to private addresses are refused when dialing.
+func validateWebhookURL(raw string) error {
+	if len(raw) > maxWebhookURLBytes {
+		return fmt.Errorf("%w: url is too long", ErrInvalidWebhook)
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return fmt.Errorf("%w: url must be an https URL", ErrInvalidWebhook)
+	}
+	if err := notify.CheckPublicHost(u.Hostname()); err != nil {
+		return fmt.Errorf("%w: url %v", ErrInvalidWebhook, err)
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,373 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dat1010/go-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeWebhookRepository keeps webhooks and deliveries in memory, and the
+// outbox messages queued with deliveries.
+type fakeWebhookRepository struct {
+	webhooks   []*models.Webhook
+	deliveries []*models.WebhookDelivery
+	outbox     []models.OutboxMessage
+}
+
+func ownedBy(webhook *models.Webhook, ownerID string) bool {
+	if webhook.OwnerID == nil {
+		return ownerID == ""
+	}
+	return *webhook.OwnerID == ownerID
+}
+
+func (r *fakeWebhookRepository) Create(webhook *models.Webhook) error {
+	r.webhooks = append(r.webhooks, webhook)
+	return nil
+}
+
+func (r *fakeWebhookRepository) Get(ownerID, id string) (*models.Webhook, error) {
+	for _, webhook := range r.webhooks {
+		if webhook.ID == id && ownedBy(webhook, ownerID) {
+			copied := *webhook
+			return &copied, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeWebhookRepository) GetByID(id string) (*models.Webhook, error) {
+	for _, webhook := range r.webhooks {
+		if webhook.ID == id {
+			copied := *webhook
+			return &copied, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeWebhookRepository) ListByOwner(ownerID string) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	for _, webhook := range r.webhooks {
+		if ownedBy(webhook, ownerID) {
+			webhooks = append(webhooks, *webhook)
+		}
+	}
+	return webhooks, nil
+}
+
+func (r *fakeWebhookRepository) CountByOwner(ownerID string) (int, error) {
+	webhooks, _ := r.ListByOwner(ownerID)
+	return len(webhooks), nil
+}
+
+func (r *fakeWebhookRepository) Update(webhook *models.Webhook) error {
+	for i, existing := range r.webhooks {
+		if existing.ID == webhook.ID {
+			if webhook.Enabled {
+				webhook.ConsecutiveFailures = 0
+				webhook.DisabledReason = ""
+			}
+			r.webhooks[i] = webhook
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (r *fakeWebhookRepository) Delete(ownerID, id string) error {
+	for i, webhook := range r.webhooks {
+		if webhook.ID == id && ownedBy(webhook, ownerID) {
+			r.webhooks = append(r.webhooks[:i], r.webhooks[i+1:]...)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (r *fakeWebhookRepository) ListSubscribed(eventType string) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	for _, webhook := range r.webhooks {
+		if webhook.Enabled && webhook.EventTypes.Has(eventType) {
+			webhooks = append(webhooks, *webhook)
+		}
+	}
+	return webhooks, nil
+}
+
+func (r *fakeWebhookRepository) CreateDeliveries(deliveries []models.WebhookDelivery, outbox []models.OutboxMessage) error {
+	for i := range deliveries {
+		r.deliveries = append(r.deliveries, &deliveries[i])
+	}
+	r.outbox = append(r.outbox, outbox...)
+	return nil
+}
+
+func (r *fakeWebhookRepository) GetDelivery(webhookID, id string) (*models.WebhookDelivery, error) {
+	delivery, err := r.GetDeliveryByID(id)
+	if err != nil || delivery.WebhookID != webhookID {
+		return nil, sql.ErrNoRows
+	}
+	return delivery, nil
+}
+
+func (r *fakeWebhookRepository) GetDeliveryByID(id string) (*models.WebhookDelivery, error) {
+	for _, delivery := range r.deliveries {
+		if delivery.ID == id {
+			copied := *delivery
+			return &copied, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeWebhookRepository) RecordAttempt(delivery *models.WebhookDelivery, disableAfter int, reason string) (bool, error) {
+	for i, existing := range r.deliveries {
+		if existing.ID == delivery.ID {
+			r.deliveries[i] = delivery
+		}
+	}
+	for _, webhook := range r.webhooks {
+		if webhook.ID != delivery.WebhookID {
+			continue
+		}
+		switch {
+		case delivery.Status == models.WebhookDeliverySucceeded:
+			webhook.ConsecutiveFailures = 0
+		case delivery.Status == models.WebhookDeliveryFailed && webhook.Enabled:
+			webhook.ConsecutiveFailures++
+			if webhook.ConsecutiveFailures >= disableAfter {
+				webhook.Enabled = false
+				webhook.DisabledReason = reason
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeWebhookRepository) ListDeliveries(webhookID string, limit, offset int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if delivery.WebhookID == webhookID {
+			deliveries = append(deliveries, *delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+func (r *fakeWebhookRepository) CountDeliveries(webhookID string) (int, error) {
+	deliveries, _ := r.ListDeliveries(webhookID, 0, 0)
+	return len(deliveries), nil
+}
+
+// deliverQueued runs every queued delivery job once, as the job queue would.
+func deliverQueued(t *testing.T, service WebhookService, repo *fakeWebhookRepository) []error {
+	queued := repo.outbox
+	repo.outbox = nil
+	var errs []error
+	for _, message := range queued {
+		assert.Equal(t, models.JobKindWebhookDeliver, message.Kind)
+		errs = append(errs, service.HandleDeliver(context.Background(), json.RawMessage(message.Payload)))
+	}
+	return errs
+}
+
+func TestCreateWebhook(t *testing.T) {
+	repo := &fakeWebhookRepository{}
+	service := NewWebhookService(repo, nil)
+
+	created, err := service.CreateWebhook("auth0|1", &models.CreateWebhookRequest{
+		URL:        "https://example.com/hooks",
+		EventTypes: []string{models.WebhookEventPostCreated, models.WebhookEventPostCreated},
+	})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(created.Secret, webhookSecretPrefix))
+	assert.Equal(t, created.Secret, repo.webhooks[0].Secret)
+	assert.Equal(t, models.StringSet{models.WebhookEventPostCreated}, created.EventTypes)
+	assert.True(t, created.Enabled)
+
+	invalid := []models.CreateWebhookRequest{
+		{URL: "http://example.com/hooks", EventTypes: []string{models.WebhookEventPostCreated}},
+		{URL: "https://localhost/hooks", EventTypes: []string{models.WebhookEventPostCreated}},
+		{URL: "https://10.0.0.1/hooks", EventTypes: []string{models.WebhookEventPostCreated}},
+		{URL: "https://[::1]/hooks", EventTypes: []string{models.WebhookEventPostCreated}},
+		{URL: "https://example.com/hooks", EventTypes: []string{}},
+		{URL: "https://example.com/hooks", EventTypes: []string{"post.liked"}},
+		// User events are for admin webhooks only
+		{URL: "https://example.com/hooks", EventTypes: []string{models.WebhookEventUserBanned}},
+	}
+	for _, req := range invalid {
+		_, err := service.CreateWebhook("auth0|1", &req)
+		assert.ErrorIs(t, err, ErrInvalidWebhook, req.URL)
+	}
+
+	admin, err := service.CreateWebhook("", &models.CreateWebhookRequest{
+		URL:        "https://example.com/admin",
+		EventTypes: []string{models.WebhookEventUserBanned},
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, admin.OwnerID)
+}
+
+func TestCreateWebhook_LimitsUserWebhooks(t *testing.T) {
+	repo := &fakeWebhookRepository{}
+	service := NewWebhookService(repo, nil)
+	req := &models.CreateWebhookRequest{URL: "https://example.com/hooks", EventTypes: []string{models.WebhookEventPostCreated}}
+
+	for i := 0; i < MaxWebhooksPerUser; i++ {
+		_, err := service.CreateWebhook("auth0|1", req)
+		assert.NoError(t, err)
+	}
+	_, err := service.CreateWebhook("auth0|1", req)
+	assert.ErrorIs(t, err, ErrWebhookLimitReached)
+	_, err = service.CreateWebhook("auth0|2", req)
+	assert.NoError(t, err)
+}
+
+func TestWebhooksAreScopedToOwner(t *testing.T) {
+	owner := "auth0|1"
+	repo := &fakeWebhookRepository{webhooks: []*models.Webhook{
+		{ID: "mine", OwnerID: &owner, EventTypes: models.StringSet{models.WebhookEventPostCreated}},
+		{ID: "admin", EventTypes: models.StringSet{models.WebhookEventPostCreated}},
+	}}
+	service := NewWebhookService(repo, nil)
+
+	_, err := service.GetWebhook(owner, "admin")
+	assert.ErrorIs(t, err, ErrWebhookNotFound)
+	_, err = service.GetWebhook("auth0|2", "mine")
+	assert.ErrorIs(t, err, ErrWebhookNotFound)
+	assert.ErrorIs(t, service.DeleteWebhook("", "mine"), ErrWebhookNotFound)
+
+	webhooks, err := service.ListWebhooks("")
+	assert.NoError(t, err)
+	if assert.Len(t, webhooks, 1) {
+		assert.Equal(t, "admin", webhooks[0].ID)
+	}
+}
+
+func TestHandleEvent_DeliversSignedPayloadToSubscribers(t *testing.T) {
+	var received *http.Request
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	owner := "auth0|1"
+	repo := &fakeWebhookRepository{webhooks: []*models.Webhook{
+		{ID: "posts", OwnerID: &owner, URL: server.URL, Secret: "whsec_test", EventTypes: models.StringSet{models.WebhookEventPostCreated}, Enabled: true},
+		{ID: "users", URL: server.URL, Secret: "whsec_other", EventTypes: models.StringSet{models.WebhookEventUserBanned}, Enabled: true},
+		{ID: "off", URL: server.URL, Secret: "whsec_off", EventTypes: models.StringSet{models.WebhookEventPostCreated}},
+	}}
+	service := NewWebhookService(repo, server.Client())
+	event := webhookEventMessage(models.WebhookEventPostCreated, &models.Post{ID: "p1", Title: "Hello"})
+
+	assert.NoError(t, service.HandleEvent(context.Background(), json.RawMessage(event.Payload)))
+	if !assert.Len(t, repo.deliveries, 1) {
+		return
+	}
+	assert.Equal(t, "posts", repo.deliveries[0].WebhookID)
+
+	assert.Equal(t, []error{nil}, deliverQueued(t, service, repo))
+	delivery := repo.deliveries[0]
+	assert.Equal(t, models.WebhookDeliverySucceeded, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusNoContent, *delivery.ResponseStatus)
+	assert.NotNil(t, delivery.DeliveredAt)
+
+	assert.JSONEq(t, string(event.Payload), string(receivedBody))
+	assert.Equal(t, delivery.ID, received.Header.Get("X-Webhook-Id"))
+	assert.Equal(t, models.WebhookEventPostCreated, received.Header.Get("X-Webhook-Event"))
+	timestamp := received.Header.Get("X-Webhook-Timestamp")
+	assert.Equal(t, "sha256="+SignWebhook("whsec_test", timestamp, receivedBody), received.Header.Get("X-Webhook-Signature"))
+
+	var envelope models.WebhookEvent
+	assert.NoError(t, json.Unmarshal(receivedBody, &envelope))
+	assert.Equal(t, models.WebhookEventPostCreated, envelope.Type)
+	assert.Contains(t, string(envelope.Data), `"id":"p1"`)
+}
+
+func TestHandleDeliver_RetriesThenDisablesFailingWebhook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down for maintenance", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	repo := &fakeWebhookRepository{webhooks: []*models.Webhook{
+		{ID: "flaky", URL: server.URL, Secret: "whsec_test", EventTypes: models.StringSet{models.WebhookEventUserCreated}, Enabled: true},
+	}}
+	service := NewWebhookService(repo, server.Client())
+
+	for i := 0; i < WebhookDisableAfter; i++ {
+		event := webhookEventMessage(models.WebhookEventUserCreated, webhookUserData{Auth0UserID: "auth0|new"})
+		assert.NoError(t, service.HandleEvent(context.Background(), json.RawMessage(event.Payload)))
+		message := repo.outbox[0]
+		repo.outbox = nil
+
+		// The job retries until the delivery runs out of attempts
+		for attempt := 1; attempt < WebhookMaxAttempts; attempt++ {
+			assert.Error(t, service.HandleDeliver(context.Background(), json.RawMessage(message.Payload)))
+		}
+		assert.NoError(t, service.HandleDeliver(context.Background(), json.RawMessage(message.Payload)))
+
+		delivery := repo.deliveries[i]
+		assert.Equal(t, models.WebhookDeliveryFailed, delivery.Status)
+		assert.Equal(t, WebhookMaxAttempts, delivery.Attempts)
+		assert.Equal(t, http.StatusServiceUnavailable, *delivery.ResponseStatus)
+		assert.Contains(t, delivery.ResponseBody, "down for maintenance")
+		assert.Contains(t, delivery.Error, "503")
+	}
+
+	webhook := repo.webhooks[0]
+	assert.False(t, webhook.Enabled)
+	assert.Equal(t, webhookDisabledReason, webhook.DisabledReason)
+
+	// A disabled webhook gets no new deliveries
+	event := webhookEventMessage(models.WebhookEventUserCreated, webhookUserData{Auth0UserID: "auth0|later"})
+	assert.NoError(t, service.HandleEvent(context.Background(), json.RawMessage(event.Payload)))
+	assert.Empty(t, repo.outbox)
+
+	_, err := service.Redeliver("", "flaky", repo.deliveries[0].ID)
+	assert.ErrorIs(t, err, ErrWebhookDisabled)
+
+	enabled := true
+	updated, err := service.UpdateWebhook("", "flaky", &models.UpdateWebhookRequest{Enabled: &enabled})
+	assert.NoError(t, err)
+	assert.Zero(t, updated.ConsecutiveFailures)
+}
+
+func TestRedeliver(t *testing.T) {
+	owner := "auth0|1"
+	repo := &fakeWebhookRepository{
+		webhooks: []*models.Webhook{{ID: "w1", OwnerID: &owner, Enabled: true}},
+		deliveries: []*models.WebhookDelivery{
+			{ID: "d1", WebhookID: "w1", EventID: "e1", EventType: models.WebhookEventPostDeleted, Payload: models.JSONObject(`{"id":"e1"}`), Status: models.WebhookDeliveryFailed},
+		},
+	}
+	service := NewWebhookService(repo, nil)
+
+	delivery, err := service.Redeliver(owner, "w1", "d1")
+	assert.NoError(t, err)
+	assert.Equal(t, "e1", delivery.EventID)
+	assert.Equal(t, models.WebhookDeliveryPending, delivery.Status)
+	assert.Equal(t, "d1", *delivery.RedeliveryOf)
+	if assert.Len(t, repo.outbox, 1) {
+		assert.JSONEq(t, `{"delivery_id":"`+delivery.ID+`"}`, string(repo.outbox[0].Payload))
+	}
+
+	_, err = service.Redeliver(owner, "w1", "missing")
+	assert.ErrorIs(t, err, ErrWebhookDeliveryNotFound)
+	_, err = service.Redeliver("auth0|2", "w1", "d1")
+	assert.ErrorIs(t, err, ErrWebhookNotFound)
+}
@@ -5,8 +5,18 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// GetAuth0UserID extracts the Auth0 user ID from Gin context
+// GetAuth0UserID extracts the Auth0 user ID the request acts as. While a
+// superadmin is impersonating, this is the impersonated user.
 func GetAuth0UserID(c *gin.Context) (string, bool) {
+	if session, ok := GetImpersonation(c); ok {
+		return session.TargetAuth0UserID, true
+	}
+	return GetActorUserID(c)
+}
+
+// GetActorUserID extracts the Auth0 user ID from the verified token, which is
+// always the real actor even during impersonation.
+func GetActorUserID(c *gin.Context) (string, bool) {
 	claims, exists := c.Get("user")
 	if !exists {
 		return "", false
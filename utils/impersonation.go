@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"github.com/dat1010/go-api/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// ImpersonationHeader carries an impersonation token for API clients.
+	ImpersonationHeader = "X-Impersonation-Token"
+	// ImpersonationCookie carries an impersonation token for the browser frontend.
+	ImpersonationCookie = "impersonation_token"
+	// ImpersonatingHeader marks every response served under impersonation.
+	ImpersonatingHeader = "X-Impersonating"
+
+	impersonationContextKey = "impersonation"
+)
+
+// GetImpersonationToken returns the impersonation token sent with the request, if any
+func GetImpersonationToken(c *gin.Context) string {
+	if token := c.GetHeader(ImpersonationHeader); token != "" {
+		return token
+	}
+	if token, err := c.Cookie(ImpersonationCookie); err == nil {
+		return token
+	}
+	return ""
+}
+
+// SetImpersonation makes the request act as the session's target user
+func SetImpersonation(c *gin.Context, session *models.ImpersonationSession) {
+	c.Set(impersonationContextKey, session)
+}
+
+// GetImpersonation returns the active impersonation session for the request, if any
+func GetImpersonation(c *gin.Context) (*models.ImpersonationSession, bool) {
+	value, exists := c.Get(impersonationContextKey)
+	if !exists {
+		return nil, false
+	}
+	session, ok := value.(*models.ImpersonationSession)
+	return session, ok && session != nil
+}
@@ -0,0 +1,8 @@
+package utils
+
+import "github.com/gin-gonic/gin"
+
+// GetRequestID returns the request ID assigned by the RequestID middleware
+func GetRequestID(c *gin.Context) string {
+	return c.GetString("request_id")
+}